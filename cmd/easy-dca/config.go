@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+const configUsage = "usage: easy-dca [-config file] [-cron expr] config validate|explain [flags]"
+
+// runConfig implements the config subcommand. "config validate" checks the configuration and lists
+// every problem found; "config explain" describes what easy-dca would do with it.
+func runConfig(configFile, cronFlag string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+	src, err := config.NewSource(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config %s: %v\n", args[0], err)
+		return 1
+	}
+	// The -cron flag overrides EASY_DCA_CRON like when running
+	if cronFlag != "" {
+		if len(src.Plans()) > 0 {
+			fmt.Fprintf(os.Stderr, "config %s: the -cron flag cannot be used with multiple plans; set schedule.cron per plan\n", args[0])
+			return 1
+		}
+		os.Setenv("EASY_DCA_CRON", cronFlag)
+	}
+
+	switch args[0] {
+	case "validate":
+		return runConfigValidate(src, args[1:])
+	case "explain":
+		return runConfigExplain(src, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+}
+
+// runConfigValidate loads the configuration and exits non-zero listing every problem found.
+func runConfigValidate(src *config.Source, args []string) int {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	fs.Parse(args)
+
+	errs := src.Validate()
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "config validate: %d problem(s) found:\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, "  -", err)
+		}
+		return 1
+	}
+	if len(src.Plans()) > 0 {
+		fmt.Printf("Configuration is valid (%d plans)\n", len(src.Plans()))
+	} else {
+		fmt.Println("Configuration is valid")
+	}
+	return 0
+}
+
+// explanation describes the configuration of a plan as printed by config explain.
+type explanation struct {
+	Plan         string               `json:"plan,omitempty"`
+	Summary      []config.SummaryLine `json:"summary"`
+	NextRuns     []time.Time          `json:"next_runs"`
+	MonthlySpend *monthlySpend        `json:"projected_monthly_spend"` // nil if the runs are not scheduled by easy-dca
+	AmountPerBuy string               `json:"amount_per_buy"`
+	Currency     string               `json:"currency"`
+}
+
+// monthlySpend is the fiat spent by the runs in the next 31 days.
+type monthlySpend struct {
+	Amount string `json:"amount"`
+	Buys   int    `json:"buys"`
+}
+
+// runConfigExplain prints the configuration summary, the next runs and the projected monthly spend.
+func runConfigExplain(src *config.Source, args []string) int {
+	fs := flag.NewFlagSet("config explain", flag.ExitOnError)
+	n := fs.Int("n", 5, "Number of upcoming runs to show")
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Parse(args)
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "config explain: unsupported -format %s (supported: text, json)\n", *format)
+		return 2
+	}
+
+	cfgs, err := src.LoadPlans()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config explain: invalid configuration:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  -", line)
+		}
+		return 1
+	}
+	explanations := make([]explanation, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		explanations = append(explanations, explain(cfg, time.Now(), *n))
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(explanations); err != nil {
+			fmt.Fprintln(os.Stderr, "config explain:", err)
+			return 1
+		}
+		return 0
+	}
+	for i, e := range explanations {
+		if i > 0 {
+			fmt.Println()
+		}
+		printExplanation(e)
+	}
+	return 0
+}
+
+// explain describes cfg with its next n runs after now.
+func explain(cfg config.Config, now time.Time, n int) explanation {
+	e := explanation{
+		Plan:         cfg.Plan,
+		Summary:      config.Summary(cfg),
+		NextRuns:     cfg.NextRuns(now, n),
+		AmountPerBuy: cfg.FiatPerBuy().StringFixed(2),
+		Currency:     cfg.Pair.GetFiatCurrency(),
+	}
+	if e.NextRuns == nil {
+		e.NextRuns = []time.Time{}
+	} else {
+		spend := cfg.FiatPerBuy().Mul(order.NewDecimalFromInt(int64(cfg.BuysPerMonth)))
+		e.MonthlySpend = &monthlySpend{Amount: spend.StringFixed(2), Buys: cfg.BuysPerMonth}
+	}
+	return e
+}
+
+// printExplanation prints an explanation as text.
+func printExplanation(e explanation) {
+	if e.Plan != "" {
+		fmt.Printf("Plan %s\n", e.Plan)
+	}
+	fmt.Println("Configuration:")
+	for _, line := range e.Summary {
+		fields := make([]string, len(line.Attrs))
+		for i, f := range line.Fields() {
+			if strings.ContainsAny(f.Value, " =") {
+				fields[i] = fmt.Sprintf("%s=%q", f.Key, f.Value)
+			} else {
+				fields[i] = f.Key + "=" + f.Value
+			}
+		}
+		if len(fields) == 0 {
+			fmt.Printf("  %s\n", line.Message)
+		} else {
+			fmt.Printf("  %s  %s\n", line.Message, strings.Join(fields, " "))
+		}
+	}
+
+	fmt.Println("Next runs:")
+	if len(e.NextRuns) == 0 {
+		fmt.Println("  Not scheduled by easy-dca (manual or systemd mode)")
+	}
+	for _, run := range e.NextRuns {
+		fmt.Printf("  %s\n", run.Format("Mon 2006-01-02 15:04 MST"))
+	}
+
+	if e.MonthlySpend != nil {
+		fmt.Printf("Projected monthly spend: %s %s (%d buys of %s %s in the next 31 days)\n",
+			e.MonthlySpend.Amount, e.Currency, e.MonthlySpend.Buys, e.AmountPerBuy, e.Currency)
+	} else {
+		fmt.Printf("Projected monthly spend: Depends on how often easy-dca is started (%s %s per run)\n",
+			e.AmountPerBuy, e.Currency)
+	}
+}
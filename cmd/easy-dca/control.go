@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/control"
+	"github.com/mayrf/easy-dca/internal/history"
+)
+
+// controlHandler returns the control API for the scheduler.
+func controlHandler(cfg config.Config, sched control.Scheduler) http.Handler {
+	opts := control.Options{
+		Config:    cfg,
+		Token:     cfg.ControlToken,
+		Scheduler: sched,
+	}
+	if cfg.HistoryFile != "" {
+		opts.History = history.NewStore(cfg.HistoryFile)
+	}
+	return control.New(opts)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/dashboard"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/kraken"
+)
+
+// dashboardHandler returns the read-only web dashboard, behind basic auth if credentials are configured.
+func dashboardHandler(cfg config.Config, sched any) http.Handler {
+	opts := dashboard.Options{
+		Config: cfg,
+		OpenOrders: func() ([]kraken.OrderInfo, error) {
+			return kraken.GetOpenOrders(cfg.PublicKey, cfg.PrivateKey)
+		},
+	}
+	if cfg.HistoryFile != "" {
+		opts.History = history.NewStore(cfg.HistoryFile)
+	}
+	if s, ok := sched.(dashboard.Schedule); ok {
+		opts.Schedule = s
+	}
+	h := dashboard.New(opts)
+	if cfg.DashboardUser != "" {
+		h = dashboard.BasicAuth(h, cfg.DashboardUser, cfg.DashboardPassword)
+	}
+	return h
+}
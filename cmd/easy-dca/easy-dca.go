@@ -13,27 +13,27 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
-	"strings"
+	"slices"
 	"syscall"
 
-	"github.com/joho/godotenv"
 	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/control"
 	"github.com/mayrf/easy-dca/internal/dca"
-	"github.com/mayrf/easy-dca/internal/notifications"
-	"github.com/mayrf/easy-dca/internal/scheduler"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/metrics"
+	"github.com/mayrf/easy-dca/internal/server"
 )
 
 var Version = "dev"
 
 // main is the entrypoint for the easy-dca CLI application.
 func main() {
-	config.ConfigureLogging()
-
 	versionFlag := flag.Bool("version", false, "Print version and exit")
 	cronFlag := flag.String("cron", "", "Cron expression for scheduling (overrides EASY_DCA_CRON)")
+	configFlag := flag.String("config", "", "YAML or TOML configuration file (defaults to EASY_DCA_CONFIG); environment variables override its values")
 	flag.Parse()
 	
 	if *versionFlag {
@@ -41,33 +41,80 @@ func main() {
 		return
 	}
 
-	err := godotenv.Load()
-	if err != nil && !strings.Contains(err.Error(), "no such file or directory") {
-		log.Printf("Error loading .env file: %v (continuing with process environment)", err)
+	dotEnv := config.NewDotEnv(".env")
+	if err := dotEnv.Load(); err != nil {
+		slog.Warn("Error loading .env file, continuing with process environment", "error", err)
+	}
+
+	configFile := *configFlag
+	if configFile == "" {
+		configFile = os.Getenv("EASY_DCA_CONFIG")
+	}
+	// The config subcommand checks and explains the configuration without running it
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfig(configFile, *cronFlag, flag.Args()[1:]))
+	}
+	// The keys subcommand encrypts the API keys into a key file
+	if flag.Arg(0) == "keys" {
+		os.Exit(runKeys(configFile, flag.Args()[1:]))
+	}
+
+	src, err := config.NewSource(configFile)
+	if err != nil {
+		slog.Error("Error loading config file", "error", err)
+		os.Exit(1)
+	}
+
+	// The healthcheck subcommand probes a running instance and needs no further configuration
+	if flag.Arg(0) == "healthcheck" {
+		os.Exit(runHealthcheck(src, flag.Args()[1:]))
 	}
 
-	cfg, err := config.LoadConfig()
+	// Configure logging after loading .env and the config file so their log settings apply
+	src.ConfigureLogging()
+
+	cfgs, err := src.LoadPlans()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		slog.Error("Error loading config", "error", err)
 		os.Exit(1)
 	}
+	// Process-wide settings are the same in every plan
+	cfg := cfgs[0]
 
 	// CLI flag overrides env
 	if *cronFlag != "" {
-		cfg.CronExpr = *cronFlag
-		log.Printf("⏰ Cron expression overridden by CLI flag: %s", *cronFlag)
+		if len(cfgs) > 1 {
+			slog.Error("The -cron flag cannot be used with multiple plans; set schedule.cron per plan")
+			os.Exit(1)
+		}
+		cfgs[0].CronExpr = *cronFlag
+		slog.Info("Cron expression overridden by CLI flag", "cron", *cronFlag)
+	}
+	for _, cfg := range cfgs {
+		config.LogConfiguration(cfg)
 	}
 
-	// Create notifier
-	notifier := notifications.CreateNotifier(cfg)
+	// Use a persistent nonce so runs sharing the API key never reuse a nonce
+	if cfg.NonceFile != "" {
+		kraken.SetNonceProvider(kraken.NewFileNonceProvider(cfg.NonceFile))
+	}
 
-	// Create DCA runner
-	runner := dca.NewRunner(cfg, notifier)
+	// Check that the API keys work and have the permissions the configuration needs before the first run
+	for _, cfg := range cfgs {
+		if !cfg.CheckPermissions {
+			continue
+		}
+		if err := dca.CheckPermissions(cfg); err != nil {
+			cfg.Logger().Error("API key check failed", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// Create scheduler
-	sched, err := scheduler.CreateScheduler(runner, cfg)
+	// Create the notifier, runner and scheduler of every plan
+	plans, err := newPlans(cfgs)
 	if err != nil {
-		log.Fatalf("Failed to create scheduler: %v", err)
+		slog.Error("Failed to create scheduler", "error", err)
+		os.Exit(1)
 	}
 
 	// Set up context with cancellation for graceful shutdown
@@ -79,16 +126,59 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		log.Print("Received shutdown signal, stopping scheduler...")
+		slog.Info("Received shutdown signal, stopping schedulers")
 		cancel()
 	}()
 
-	// Start the scheduler
-	log.Printf("Starting easy-dca with configuration: %s", cfg.Pair.String())
-	if err := sched.Start(ctx); err != nil {
-		log.Printf("Scheduler error: %v", err)
+	// Reload the configuration while cron schedulers run
+	reload := &reloader{configFile: configFile, cronFlag: *cronFlag, dotEnv: dotEnv, plans: plans}
+	if slices.ContainsFunc(plans, func(p *plan) bool { return p.config().SchedulerMode == "cron" }) {
+		go reload.run(ctx, cfg.ReloadWatchInterval)
+	}
+
+	// Serve metrics, health checks, the dashboard and the control API while the schedulers run
+	if cfg.HTTPAddr != "" {
+		srv := server.New(cfg.HTTPAddr)
+		srv.Handle("/metrics", metrics.Handler())
+		srv.Handle("/healthz", livenessChecks(plans).Handler())
+		srv.Handle("/readyz", readinessChecks(plans, reload).Handler())
+		// The dashboard and the control API require a single plan, see config.validatePlans
+		if cfg.Dashboard {
+			reload.dashboard = newSwapHandler(dashboardHandler(cfg, plans[0].sched))
+			srv.Handle("/", reload.dashboard)
+		}
+		// The control API requires cron mode, see config.validateControl
+		if cs, ok := plans[0].sched.(control.Scheduler); ok && cfg.ControlToken != "" {
+			reload.control = newSwapHandler(controlHandler(cfg, cs))
+			srv.Handle(control.Prefix, reload.control)
+		}
+		ln, err := srv.Listen()
+		if err != nil {
+			slog.Error("Failed to start HTTP server", "error", err)
+			os.Exit(1)
+		}
+		go func() {
+			if err := srv.Serve(ctx, ln); err != nil {
+				slog.Error("HTTP server error", "error", err)
+			}
+		}()
+	}
+
+	// Answer Telegram commands; they require cron mode and a single plan, see config.validatePlans
+	if cs, ok := plans[0].sched.(control.Scheduler); ok && cfg.NotifyTelegramCommands {
+		go telegramBot(plans[0], cs).Run(ctx)
+	}
+
+	// Start the schedulers
+	if len(plans) == 1 {
+		slog.Info("Starting easy-dca", "version", Version, "pair", cfg.Pair.String())
+	} else {
+		slog.Info("Starting easy-dca", "version", Version, "plans", len(plans))
+	}
+	if err := runPlans(ctx, plans); err != nil {
+		slog.Error("Scheduler error", "error", err)
 		os.Exit(1)
 	}
 
-	log.Print("easy-dca stopped")
+	slog.Info("easy-dca stopped")
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/health"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/metrics"
+)
+
+// krakenStatusTTL limits how often /readyz asks Kraken for its system status.
+const krakenStatusTTL = 30 * time.Second
+
+// aliveChecker is implemented by schedulers that can report whether their loop is running.
+type aliveChecker interface {
+	Alive(ctx context.Context) error
+}
+
+// livenessChecks returns the checks served at /healthz: the process is up and the scheduler loops run.
+func livenessChecks(plans []*plan) *health.Checker {
+	checks := health.NewChecker()
+	for _, p := range plans {
+		if s, ok := p.sched.(aliveChecker); ok {
+			checks.Add(checkName("scheduler", p), s.Alive)
+		}
+	}
+	return checks
+}
+
+// checkName returns the name of a per-plan check, e.g. scheduler or scheduler:weekly.
+func checkName(name string, p *plan) string {
+	if p.name() == "" {
+		return name
+	}
+	return name + ":" + p.name()
+}
+
+// readinessChecks returns the checks served at /readyz: the last configuration reload succeeded,
+// Kraken is reachable and accepting orders, and recent runs of each plan have not kept failing.
+func readinessChecks(plans []*plan, reload *reloader) *health.Checker {
+	checks := health.NewChecker()
+	// The process only starts with a valid configuration, so only reloads can fail
+	checks.Add("config", func(context.Context) error { return reload.Err() })
+	checks.Add("kraken", health.Cached(krakenStatusTTL, func(context.Context) error {
+		status, err := kraken.GetSystemStatus()
+		if err != nil {
+			return err
+		}
+		if status != "online" {
+			return fmt.Errorf("kraken system status is %q", status)
+		}
+		return nil
+	}))
+	for _, p := range plans {
+		checks.Add(checkName("runs", p), func(context.Context) error {
+			cfg := p.config()
+			failed := int(metrics.ConsecutiveFailures.Value(cfg.Plan))
+			if failed >= cfg.ReadyMaxFailedRuns {
+				return fmt.Errorf("%d consecutive runs failed (limit %d)", failed, cfg.ReadyMaxFailedRuns)
+			}
+			return nil
+		})
+	}
+	return checks
+}
+
+// runHealthcheck implements the healthcheck subcommand, which probes the health endpoint
+// of a running instance and exits non-zero if it is unhealthy. It needs no tools inside the
+// container, so it can be used as a Docker HEALTHCHECK.
+func runHealthcheck(src *config.Source, args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	ready := fs.Bool("ready", false, "Probe /readyz instead of /healthz")
+	addr := fs.String("addr", src.Get("EASY_DCA_HTTP_ADDR"), "Address of the HTTP server (defaults to EASY_DCA_HTTP_ADDR)")
+	timeout := fs.Duration("timeout", 5*time.Second, "Timeout of the probe")
+	fs.Parse(args)
+
+	if *addr == "" {
+		fmt.Fprintln(os.Stderr, "healthcheck: EASY_DCA_HTTP_ADDR is not set; enable the HTTP server or pass -addr")
+		return 1
+	}
+	path := "/healthz"
+	if *ready {
+		path = "/readyz"
+	}
+	url, err := health.LocalURL(*addr, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := health.Probe(ctx, url); err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/secret"
+	"golang.org/x/term"
+)
+
+const keysUsage = "usage: easy-dca [-config file] keys encrypt [-out file] [-force] [-plan name]"
+
+// minPassphraseLength is the minimum length of the passphrase of new key files.
+const minPassphraseLength = 12
+
+// runKeys implements the keys subcommand. "keys encrypt" writes the API keys of the configuration
+// into a passphrase-encrypted key file for EASY_DCA_KEY_FILE.
+func runKeys(configFile string, args []string) int {
+	if len(args) == 0 || args[0] != "encrypt" {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("keys encrypt", flag.ExitOnError)
+	out := fs.String("out", "easy-dca.keys.age", "Encrypted key file to write")
+	force := fs.Bool("force", false, "Overwrite the key file if it exists")
+	plan := fs.String("plan", "", "Plan whose keys to encrypt, if the configuration has plans")
+	fs.Parse(args[1:])
+
+	src, err := config.NewSource(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keys encrypt: %v\n", err)
+		return 1
+	}
+	if src, err = selectPlan(src, *plan); err != nil {
+		fmt.Fprintf(os.Stderr, "keys encrypt: %v\n", err)
+		return 1
+	}
+	keys, err := src.LoadKeys()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keys encrypt: %v\n", err)
+		return 1
+	}
+	passphrase, err := newPassphrase(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keys encrypt: %v\n", err)
+		return 1
+	}
+	if err := writeKeyFile(*out, keys, passphrase, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "keys encrypt: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Encrypted the API keys to %s\n", *out)
+	fmt.Printf("Set EASY_DCA_KEY_FILE=%s and a passphrase source such as EASY_DCA_KEY_PASSPHRASE_FD, then delete the plaintext key files.\n", *out)
+	return 0
+}
+
+// selectPlan returns the source of the named plan, or src if the configuration has no plans.
+func selectPlan(src *config.Source, name string) (*config.Source, error) {
+	plans := src.Plans()
+	if len(plans) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("the configuration has no plans")
+		}
+		return src, nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the configuration has %d plans; choose one with -plan", len(plans))
+	}
+	for _, p := range plans {
+		if p.Plan() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no plan named %q", name)
+}
+
+// newPassphrase returns the configured key file passphrase, or asks for one on the terminal.
+func newPassphrase(src *config.Source) (string, error) {
+	passphrase, err := src.KeyPassphrase()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", errors.New("no passphrase: set EASY_DCA_KEY_PASSPHRASE_FD, EASY_DCA_KEY_PASSPHRASE_SECRET, EASY_DCA_KEY_PASSPHRASE_PATH or EASY_DCA_KEY_PASSPHRASE, or run in a terminal")
+		}
+		if passphrase, err = readPassphrase("Passphrase: "); err != nil {
+			return "", err
+		}
+		repeated, err := readPassphrase("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if repeated != passphrase {
+			return "", errors.New("the passphrases do not match")
+		}
+	}
+	if len(passphrase) < minPassphraseLength {
+		return "", fmt.Errorf("the passphrase must be at least %d characters", minPassphraseLength)
+	}
+	return passphrase, nil
+}
+
+// readPassphrase reads a passphrase from the terminal without echoing it.
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	defer clear(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %w", err)
+	}
+	return string(data), nil
+}
+
+// writeKeyFile writes the encrypted key file, readable by the owner only.
+func writeKeyFile(path string, keys secret.KeyPair, passphrase string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := secret.EncryptKeys(f, keys, passphrase); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/dca"
+	"github.com/mayrf/easy-dca/internal/notifications"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+// plan is a DCA plan and its scheduler. A configuration reload replaces its configuration and notifier.
+type plan struct {
+	sched scheduler.Scheduler
+
+	mu       sync.Mutex
+	cfg      config.Config
+	notifier notifications.Notifier
+}
+
+// config returns the current configuration of the plan.
+func (p *plan) config() config.Config {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg
+}
+
+// name returns the name of the plan, or "" if the configuration defines no plans.
+func (p *plan) name() string {
+	return p.config().Plan
+}
+
+// update replaces the configuration and notifier of the plan after a reload.
+func (p *plan) update(cfg config.Config, notifier notifications.Notifier) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg, p.notifier = cfg, notifier
+}
+
+// notify sends a notification about the plan if a notifier is configured, logging delivery failures.
+func (p *plan) notify(subject, message string) {
+	p.mu.Lock()
+	cfg, notifier := p.cfg, p.notifier
+	p.mu.Unlock()
+	if notifier == nil {
+		return
+	}
+	if err := notifier.Notify(context.Background(), subject, message); err != nil {
+		cfg.Logger().Error("Failed to send notification", "subject", subject, "error", err)
+	}
+}
+
+// newPlans creates the notifier, runner and scheduler of every plan.
+func newPlans(cfgs []config.Config) ([]*plan, error) {
+	plans := make([]*plan, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		notifier := notifications.CreateNotifier(cfg)
+		sched, err := scheduler.CreateScheduler(dca.NewRunner(cfg, notifier), cfg)
+		if err != nil {
+			if cfg.Plan != "" {
+				err = fmt.Errorf("plan %s: %w", cfg.Plan, err)
+			}
+			return nil, err
+		}
+		plans = append(plans, &plan{sched: sched, cfg: cfg, notifier: notifier})
+	}
+	return plans, nil
+}
+
+// runPlans runs the schedulers of all plans concurrently and waits until all have stopped.
+// A failing plan does not stop the others; the errors of all failed plans are returned.
+func runPlans(ctx context.Context, plans []*plan) error {
+	if len(plans) == 1 {
+		return plans[0].sched.Start(ctx)
+	}
+	errs := make([]error, len(plans))
+	var wg sync.WaitGroup
+	for i, p := range plans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.sched.Start(ctx); err != nil {
+				errs[i] = fmt.Errorf("plan %s: %w", p.name(), err)
+				p.config().Logger().Error("Scheduler error", "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/control"
+	"github.com/mayrf/easy-dca/internal/dca"
+	"github.com/mayrf/easy-dca/internal/notifications"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+// reloadable is implemented by schedulers whose configuration can be replaced while they run.
+type reloadable interface {
+	Reload(runner scheduler.DCARunner, cfg config.Config) error
+}
+
+// reloader reloads the configuration on SIGHUP and when the configuration or .env file changes.
+// The new configuration is fully validated before it replaces the running one: if it is invalid,
+// or changes a setting that needs a restart, the running configuration is kept.
+type reloader struct {
+	configFile string         // Configuration file (empty if none)
+	cronFlag   string         // Cron expression of the -cron flag (empty if not set)
+	dotEnv     *config.DotEnv // Loader of the .env file
+	plans      []*plan        // Running plans
+	dashboard  *swapHandler   // Dashboard handler (nil if not served)
+	control    *swapHandler   // Control API handler (nil if not served)
+	mu         sync.Mutex     // Serializes reloads
+
+	failure atomic.Pointer[error] // Error of the last reload, nil if it succeeded
+}
+
+// run reloads the configuration on SIGHUP and, if interval is positive, when the content
+// of the configuration or .env file changes. It returns when ctx is done.
+func (r *reloader) run(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	last := r.fingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = r.fingerprint()
+			r.reload("SIGHUP")
+		case <-tick:
+			if current := r.fingerprint(); current != last {
+				last = current
+				r.reload("file change")
+			}
+		}
+	}
+}
+
+// fingerprint returns a hash of the configuration and .env files. Missing files hash as empty.
+func (r *reloader) fingerprint() [sha256.Size]byte {
+	h := sha256.New()
+	for _, path := range []string{r.configFile, r.dotEnv.Path()} {
+		if path == "" {
+			continue
+		}
+		data, _ := os.ReadFile(path)
+		fmt.Fprintf(h, "%s:%d:", path, len(data))
+		h.Write(data)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// reload loads and validates the configuration and swaps it into the running plans,
+// logging and notifying what changed. The running configuration is kept if the new one is rejected.
+func (r *reloader) reload(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slog.Info("Reloading configuration", "reason", reason)
+	cfgs, env, err := r.load()
+	if err != nil {
+		slog.Error("Configuration reload failed, keeping the current configuration", "error", err)
+		r.failure.Store(&err)
+		for _, p := range r.plans {
+			p.notify("DCA Config Error", fmt.Sprintf("Configuration reload (%s) failed, keeping the current configuration: %v", reason, err))
+		}
+		return
+	}
+	r.failure.Store(nil)
+
+	changed := false
+	for i, p := range r.plans {
+		cfg := cfgs[i]
+		changes := config.Diff(p.config(), cfg)
+		if len(changes) == 0 {
+			continue
+		}
+		changed = true
+		notifier := notifications.CreateNotifier(cfg)
+		if s, ok := p.sched.(reloadable); ok {
+			// Cannot fail: the cron expression was validated with the configuration
+			if err := s.Reload(dca.NewRunner(cfg, notifier), cfg); err != nil {
+				cfg.Logger().Error("Failed to reload the schedule", "error", err)
+				continue
+			}
+		}
+		p.update(cfg, notifier)
+
+		lines := make([]string, len(changes))
+		for i, c := range changes {
+			lines[i] = "- " + c.String()
+		}
+		cfg.Logger().Info("Configuration reloaded", "reason", reason, "changes", strings.Join(lines, "; "))
+		msg := fmt.Sprintf("Configuration reloaded (%s):\n%s", reason, strings.Join(lines, "\n"))
+		if cfg.Plan != "" {
+			msg = fmt.Sprintf("Plan %s: %s", cfg.Plan, msg)
+		}
+		p.notify("DCA Config Reloaded", msg)
+		config.LogConfiguration(cfg)
+	}
+	// The new configuration is in place; only now does the .env file change the process environment
+	r.dotEnv.Apply(env)
+	if !changed {
+		slog.Info("Configuration unchanged", "reason", reason)
+		return
+	}
+	r.swapHandlers(cfgs[0])
+}
+
+// load reads the .env and configuration files and returns the configuration of every plan and the
+// environment of the .env file, rejecting configurations that cannot replace the running one without
+// a restart. The .env file is not applied to the process environment, so a rejected reload leaves it unchanged.
+func (r *reloader) load() ([]config.Config, *config.DotEnvValues, error) {
+	env, err := r.dotEnv.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+	src, err := config.NewSourceWithEnv(r.configFile, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfgs, err := src.LoadPlans()
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.cronFlag != "" && len(cfgs) == 1 {
+		cfgs[0].CronExpr = r.cronFlag
+	}
+
+	running := make([]config.Config, len(r.plans))
+	for i, p := range r.plans {
+		running[i] = p.config()
+	}
+	if err := config.CheckReload(running, cfgs); err != nil {
+		return nil, nil, err
+	}
+	for i, old := range running {
+		cfg := cfgs[i]
+		// Check new keys, and the permissions live trading needs when leaving dry run mode
+		recheck := cfg.PublicKey != old.PublicKey || cfg.PrivateKey != old.PrivateKey || cfg.DryRun != old.DryRun || !old.CheckPermissions
+		if cfg.CheckPermissions && recheck {
+			if err := dca.CheckPermissions(cfg); err != nil && cfg.Plan != "" {
+				return nil, nil, fmt.Errorf("plan %s: %w", cfg.Plan, err)
+			} else if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Apply the log settings before the new runners and schedulers pick up the default logger
+	src.ConfigureLogging()
+	return cfgs, env, nil
+}
+
+// Err returns the error of the last configuration reload, or nil if it succeeded or none was attempted.
+func (r *reloader) Err() error {
+	if err := r.failure.Load(); err != nil {
+		return fmt.Errorf("configuration reload failed, running the previous configuration: %w", *err)
+	}
+	return nil
+}
+
+// swapHandlers rebuilds the dashboard and control API with the reloaded configuration.
+func (r *reloader) swapHandlers(cfg config.Config) {
+	sched := r.plans[0].sched
+	if r.dashboard != nil {
+		r.dashboard.Store(dashboardHandler(cfg, sched))
+	}
+	if cs, ok := sched.(control.Scheduler); ok && r.control != nil {
+		r.control.Store(controlHandler(cfg, cs))
+	}
+}
+
+// swapHandler serves the handler stored last, so a handler can be replaced while the server runs.
+type swapHandler struct {
+	h atomic.Pointer[http.Handler]
+}
+
+// newSwapHandler returns a swapHandler serving h.
+func newSwapHandler(h http.Handler) *swapHandler {
+	s := &swapHandler{}
+	s.Store(h)
+	return s
+}
+
+// Store replaces the handler.
+func (s *swapHandler) Store(h http.Handler) {
+	s.h.Store(&h)
+}
+
+func (s *swapHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*s.h.Load()).ServeHTTP(w, req)
+}
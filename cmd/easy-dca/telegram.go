@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/mayrf/easy-dca/internal/control"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/telegram"
+)
+
+// telegramBot returns the Telegram bot answering commands for the plan's scheduler.
+func telegramBot(p *plan, sched control.Scheduler) *telegram.Bot {
+	cfg := p.config()
+	opts := telegram.Options{
+		Client:    &telegram.Client{Token: cfg.NotifyTelegramToken, APIURL: cfg.NotifyTelegramAPIURL},
+		ChatIDs:   cfg.NotifyTelegramChatIDs,
+		Scheduler: sched,
+		Config:    p.config,
+	}
+	if cfg.HistoryFile != "" {
+		opts.History = history.NewStore(cfg.HistoryFile)
+	}
+	return telegram.NewBot(opts)
+}
@@ -201,6 +201,8 @@
                   User = cfg.user;
                   Group = cfg.group;
                   ExecStart = "${easy-dca-app}/bin/easy-dca";
+                  # Persistent nonce and state files, see STATE_DIRECTORY
+                  StateDirectory = "easy-dca";
 
                   # Security hardening
                   NoNewPrivileges = true;
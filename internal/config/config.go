@@ -2,13 +2,27 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
+	"unicode"
 
+	"github.com/mayrf/easy-dca/internal/order"
+	"github.com/mayrf/easy-dca/internal/secret"
 	"github.com/robfig/cron/v3"
 )
 
@@ -17,14 +31,32 @@ type TradingPair struct {
 	value string
 }
 
+// pairInfo holds the currency and Kraken precision rules of a trading pair
+type pairInfo struct {
+	fiat      string        // Quote (fiat) currency code
+	priceTick order.Decimal // Smallest price increment (Kraken pair_decimals)
+	lotSize   order.Decimal // Smallest volume increment (Kraken lot_decimals)
+	minVolume order.Decimal // Minimum order volume (Kraken ordermin)
+}
+
+// btcPair returns the pair info shared by all supported BTC pairs
+func btcPair(fiat string) pairInfo {
+	return pairInfo{
+		fiat:      fiat,
+		priceTick: order.MustParseDecimal("0.1"),
+		lotSize:   order.MustParseDecimal("0.00000001"),
+		minVolume: order.MustParseDecimal("0.00005"),
+	}
+}
+
 // Supported trading pairs
-var supportedPairs = map[string]string{
-	"BTC/EUR": "EUR",
-	"BTC/GBP": "GBP",
-	"BTC/CHF": "CHF",
-	"BTC/AUD": "AUD",
-	"BTC/CAD": "CAD",
-	"BTC/USD": "USD",
+var supportedPairs = map[string]pairInfo{
+	"BTC/EUR": btcPair("EUR"),
+	"BTC/GBP": btcPair("GBP"),
+	"BTC/CHF": btcPair("CHF"),
+	"BTC/AUD": btcPair("AUD"),
+	"BTC/CAD": btcPair("CAD"),
+	"BTC/USD": btcPair("USD"),
 }
 
 // NewTradingPair creates a new TradingPair with validation
@@ -43,7 +75,27 @@ func (tp TradingPair) String() string {
 
 // GetFiatCurrency returns the fiat currency code for this trading pair
 func (tp TradingPair) GetFiatCurrency() string {
-	return supportedPairs[tp.value]
+	return supportedPairs[tp.value].fiat
+}
+
+// PriceTick returns the smallest price increment accepted by Kraken for this pair
+func (tp TradingPair) PriceTick() order.Decimal {
+	return supportedPairs[tp.value].priceTick
+}
+
+// LotSize returns the smallest volume increment accepted by Kraken for this pair
+func (tp TradingPair) LotSize() order.Decimal {
+	return supportedPairs[tp.value].lotSize
+}
+
+// KrakenName returns the name Kraken uses for this pair in order descriptions, e.g. XBTEUR
+func (tp TradingPair) KrakenName() string {
+	return "XBT" + tp.GetFiatCurrency()
+}
+
+// MinVolume returns the minimum order volume for this pair
+func (tp TradingPair) MinVolume() order.Decimal {
+	return supportedPairs[tp.value].minVolume
 }
 
 // GetFiatCurrencyName returns the full name of the fiat currency
@@ -68,144 +120,468 @@ func GetSupportedPairs() []string {
 	return pairs
 }
 
+// Policies for the fiat of a buy skipped by a market-condition guard
+const (
+	SkippedBudgetForfeit = "forfeit" // The skipped fiat is not spent
+	SkippedBudgetCarry   = "carry"   // The skipped fiat is added to the next buy
+)
+
+// Instance lock backends
+const (
+	LockBackendFile    = "file"    // Advisory lock on EASY_DCA_LOCK_FILE (default)
+	LockBackendHistory = "history" // Lease in EASY_DCA_HISTORY_FILE, for hosts sharing the history file
+)
+
+// Supported order types
+const (
+	OrderTypePostOnly    = "post-only"    // Post-only limit order (maker only, default)
+	OrderTypeLimit       = "limit"        // Plain limit order (may fill as taker)
+	OrderTypeMarket      = "market"       // Market order at the best available price
+	OrderTypeLimitMarket = "limit-market" // Post-only limit order, remainder bought at market after a timeout
+)
+
+// minControlTokenLength is the minimum length of the control API token.
+const minControlTokenLength = 16
+
 // Config holds all configuration values for the application.
 type Config struct {
-	PublicKey           string      // Kraken API public key
-	PrivateKey          string      // Kraken API private key
-	Pair                TradingPair // Trading pair, e.g., BTC/EUR
-	DryRun              bool        // If true, only validate orders (dry run); if false, actually place orders
-	PriceFactor         float32     // Price factor for limit orders
-	MonthlyFiatSpending float32     // Monthly fiat spending (optional, used if FiatAmountPerBuy is not set)
-	FiatAmountPerBuy    float32     // Fixed fiat amount to spend each run (optional, takes precedence over MonthlyFiatSpending)
-	AutoAdjustMinOrder  bool        // If true, automatically adjust orders below minimum size; if false, let them fail
-	SchedulerMode       string      // Scheduler mode: "cron", "systemd", or "manual" (default: "cron" if EASY_DCA_CRON is set, otherwise "manual")
+	PublicKey           string        // Kraken API public key
+	PrivateKey          string        // Kraken API private key
+	KeySource           string        // Where the API keys came from: "file" (key file paths), "encrypted" (encrypted key file), "config" (config file), "env" or a secret provider scheme
+	KeyFile             string        // Encrypted key file, if the keys came from one
+	CheckPermissions    bool          // If true, the permissions of the API keys are checked at startup
+	ConfigFile          string        // Path of the configuration file (empty if none)
+	Plan                string        // Name of the plan in the configuration file (empty if the file defines no plans)
+	Pair                TradingPair   // Trading pair, e.g., BTC/EUR
+	DryRun              bool          // If true, only validate orders (dry run); if false, actually place orders
+	PriceFactor         order.Decimal // Price factor for limit orders
+	MonthlyFiatSpending order.Decimal // Monthly fiat spending (optional, used if FiatAmountPerBuy is not set)
+	FiatAmountPerBuy    order.Decimal // Fixed fiat amount to spend each run (optional, takes precedence over MonthlyFiatSpending)
+	AutoAdjustMinOrder  bool          // If true, automatically adjust orders below minimum size; if false, let them fail
+	MaxSpreadBps        order.Decimal // Skip the buy if the bid-ask spread is wider than this many basis points (0 disables)
+	MaxPrice            order.Decimal // Skip the buy if the ask is above this price (0 disables)
+	MaxPriceChangePct   order.Decimal // Skip the buy if the price range within PriceChangeWindow exceeds this percentage (0 disables)
+	PriceChangeWindow   time.Duration // Window for MaxPriceChangePct
+	SkippedBudget       string        // What happens to the fiat of a skipped buy: "forfeit" (default) or "carry"
+	OrderType           string        // Order type: "post-only" (default), "limit", "market" or "limit-market"
+	TimeInForce         string        // Kraken time in force for limit orders: "GTC", "IOC" or "GTD" (optional)
+	OrderExpire         time.Duration // Relative expiry of limit orders (optional, implies GTD)
+	MarketFallbackAfter time.Duration // Time after which a "limit-market" order's unfilled remainder is bought at market
+	SchedulerMode       string        // Scheduler mode: "cron", "systemd", or "manual" (default: "cron" if EASY_DCA_CRON is set, otherwise "manual")
 
 	DisplaySats bool // If true, display all BTC amounts in satoshi
 
 	CronExpr     string // Cron expression for scheduling (optional)
 	BuysPerMonth int    // Number of buys per month (calculated from cron expression)
 
-	NotifyMethod    string // Notification method (ntfy, slack, email, etc.)
+	OrderSlotInterval time.Duration // Length of a schedule slot when no cron expression is set; each slot buys at most once (0 disables the check)
+	LockFile          string        // Path of the instance lock file held during a DCA run (empty disables locking)
+	LockBackend       string        // Where the instance lock is held: file (LockFile) or history (HistoryFile)
+	NonceFile         string        // Path of the file persisting the last API nonce (empty keeps nonces in memory)
+	StateFile         string        // Path of the file persisting state between runs, such as carried-forward fiat (empty disables)
+	HistoryFile       string        // Path of the file recording past runs and orders (empty disables)
+
+	HTTPAddr           string // Listen address of the built-in HTTP server serving /metrics, /healthz and /readyz, e.g. ":9090" (empty disables)
+	ReadyMaxFailedRuns int    // /readyz fails once this many runs failed in a row
+	Dashboard          bool   // If true, serve the read-only web dashboard at / on the HTTP server
+	DashboardUser      string // Basic auth user of the dashboard (optional)
+	DashboardPassword  string // Basic auth password of the dashboard (optional)
+	ControlToken       string // Bearer token of the control API at /api/control/ (empty disables the API)
+
+	ReloadWatchInterval time.Duration // How often the config and .env files are checked for changes in cron mode (0 disables; SIGHUP always reloads)
+
+	NotifyMethod    string // Notification method (ntfy, webhook, telegram, email, slack, discord, matrix, gotify or pushover)
 	NotifyNtfyTopic string // ntfy topic (if using ntfy)
 	NotifyNtfyURL   string // ntfy server URL (if using ntfy)
+
+	NotifyWebhookURL         string        // URL the webhook notifier POSTs to
+	NotifyWebhookHeaders     http.Header   // Extra request headers of the webhook notifier
+	NotifyWebhookTemplate    string        // Go template of the request body (empty uses the default JSON payload)
+	NotifyWebhookContentType string        // Content-Type of the request body
+	NotifyWebhookHMACKey     string        // Key to sign the request body with HMAC-SHA256 (empty disables signing)
+	NotifyWebhookTimeout     time.Duration // Timeout of a webhook request
+
+	NotifyTelegramToken    string  // Bot token of the Telegram notifier
+	NotifyTelegramChatIDs  []int64 // Chats the Telegram notifier sends to, and the only chats allowed to send commands
+	NotifyTelegramCommands bool    // If true, the bot answers commands such as /status and /pause (requires cron mode)
+	NotifyTelegramAPIURL   string  // Bot API server of the Telegram notifier
+
+	NotifySMTPHost     string        // SMTP server of the email notifier
+	NotifySMTPPort     int           // SMTP server port
+	NotifySMTPSecurity string        // Connection security: starttls, tls (implicit TLS) or none
+	NotifySMTPUsername string        // SMTP username (empty disables authentication)
+	NotifySMTPPassword string        // SMTP password
+	NotifySMTPFrom     string        // Sender address
+	NotifySMTPTo       []string      // Recipient addresses
+	NotifySMTPTimeout  time.Duration // Timeout of sending an email
+
+	NotifySlackWebhookURL   string // Incoming webhook URL of the Slack notifier
+	NotifyDiscordWebhookURL string // Webhook URL of the Discord notifier
+
+	NotifyMatrixHomeserver  string // Homeserver URL of the Matrix notifier
+	NotifyMatrixAccessToken string // Access token of the Matrix account sending notifications
+	NotifyMatrixRoomID      string // Room the Matrix notifier sends to, e.g. !abc123:example.org
+
+	NotifyGotifyURL   string // Server URL of the Gotify notifier
+	NotifyGotifyToken string // Application token of the Gotify notifier
+
+	NotifyPushoverToken  string // Application API token of the Pushover notifier
+	NotifyPushoverUser   string // User or group key of the Pushover notifier
+	NotifyPushoverDevice string // Devices the Pushover notifier sends to (empty sends to all)
+
+	NotifyURLs []string // Apprise-style notification URLs, sent to in addition to NotifyMethod
 	// Add more fields for other notification methods as needed
 }
 
-// ConfigureLogging sets up the log format based on environment variables
-func ConfigureLogging() {
-	logFormat := os.Getenv("EASY_DCA_LOG_FORMAT")
+// SummaryLine is one setting of the configuration summary: a message and its attributes.
+// It encodes to JSON as {"message": ..., "fields": [{"key": ..., "value": ...}]}, the form
+// served by the dashboard and printed by config explain.
+type SummaryLine struct {
+	Message string
+	Attrs   []slog.Attr
+}
 
-	switch strings.ToLower(logFormat) {
-	case "timestamp", "time":
-		// Standard timestamp format (2006/01/02 15:04:05)
-		log.SetFlags(log.Ldate | log.Ltime)
-	case "microseconds", "micro":
-		// Full datetime with microseconds (2006/01/02 15:04:05.000000)
-		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-	default:
-		// Default: no timestamp prefix
-		log.SetFlags(0)
+// SummaryField is an attribute of a summary line, with its value as text.
+type SummaryField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Fields returns the attributes of the line with their values as text.
+func (l SummaryLine) Fields() []SummaryField {
+	var fields []SummaryField
+	for _, attr := range l.Attrs {
+		fields = append(fields, SummaryField{Key: attr.Key, Value: attr.Value.String()})
 	}
+	return fields
 }
 
-// logConfiguration prints a user-friendly summary of the loaded configuration
-func logConfiguration(cfg Config) {
-	log.Print("=== easy-dca Configuration Summary ===")
+// MarshalJSON encodes the line with its attributes as fields.
+func (l SummaryLine) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message string         `json:"message"`
+		Fields  []SummaryField `json:"fields,omitempty"`
+	}{l.Message, l.Fields()})
+}
+
+// LogConfiguration logs a user-friendly summary of the loaded configuration, one event per setting.
+func LogConfiguration(cfg Config) {
+	log := cfg.Logger()
+	log.Info("easy-dca configuration summary")
+	for _, line := range Summary(cfg) {
+		log.LogAttrs(context.Background(), slog.LevelInfo, line.Message, line.Attrs...)
+	}
+}
+
+// Logger returns the default logger, with the plan name if the configuration is a plan.
+func (c Config) Logger() *slog.Logger {
+	if c.Plan == "" {
+		return slog.Default()
+	}
+	return slog.Default().With("plan", c.Plan)
+}
+
+// defaultStateDir returns the directory of files that must survive restarts, such as the nonce, state and history files:
+// the systemd StateDirectory, $XDG_STATE_HOME/easy-dca, /var/lib/easy-dca for root or
+// ~/.local/state/easy-dca. It falls back to the system temp directory without a home directory.
+func (s *Source) defaultStateDir() string {
+	if dir, _, _ := strings.Cut(s.getenv("STATE_DIRECTORY"), ":"); dir != "" {
+		return dir
+	}
+	if dir := s.getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "easy-dca")
+	}
+	if os.Geteuid() == 0 {
+		return "/var/lib/easy-dca"
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "easy-dca")
+	}
+	return os.TempDir()
+}
+
+// inTempDir reports whether path is in a temporary directory, which may be cleared on reboot.
+func inTempDir(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range []string{os.TempDir(), "/tmp", "/var/tmp"} {
+		if rel, err := filepath.Rel(dir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
+
+// fileName returns the default name of a per-plan file, e.g. easy-dca.lock or easy-dca.weekly.lock.
+func (c Config) fileName(suffix string) string {
+	if c.Plan == "" {
+		return "easy-dca." + suffix
+	}
+	return "easy-dca." + c.Plan + "." + suffix
+}
+
+// Summary returns a user-friendly summary of the configuration, one line per setting.
+// It is logged at startup, shown by the dashboard and printed by config explain; it never contains API keys or passwords.
+func Summary(cfg Config) []SummaryLine {
+	fiat := cfg.Pair.GetFiatCurrency()
+	var lines []SummaryLine
+	add := func(msg string, args ...any) {
+		lines = append(lines, SummaryLine{Message: msg, Attrs: slog.Group("", args...).Value.Group()})
+	}
+
+	// Configuration file
+	if cfg.ConfigFile != "" {
+		add("Config file", "path", cfg.ConfigFile)
+	}
 
 	// Trading pair
-	log.Printf("📊 Trading pair: %s", cfg.Pair.String())
+	add("Trading pair", "pair", cfg.Pair.String())
 
 	// BTC unit
-	log.Printf("🪙  BTC unit: %s", cfg.GetBTCUnit())
+	add("BTC unit", "unit", cfg.GetBTCUnit())
 
 	// Execution mode
 	if cfg.DryRun {
-		log.Print("🔍 DRY RUN MODE: Orders will be validated but not executed")
+		add("DRY RUN MODE: Orders will be validated but not executed", "dry_run", true)
 	} else {
-		log.Print("🚀 LIVE TRADING MODE: Orders will be placed on Kraken")
+		add("LIVE TRADING MODE: Orders will be placed on Kraken", "dry_run", false)
 	}
 
 	// Buy amount configuration
-	if cfg.FiatAmountPerBuy > 0 {
-		log.Printf("💰 Fixed amount per buy: %.2f %s", cfg.FiatAmountPerBuy, cfg.Pair.GetFiatCurrency())
-	} else if cfg.MonthlyFiatSpending > 0 {
-		log.Printf("💰 Monthly budget: %.2f %s (%.2f %s per buy, %d buys/month)",
-			cfg.MonthlyFiatSpending, cfg.Pair.GetFiatCurrency(),
-			cfg.MonthlyFiatSpending/float32(cfg.BuysPerMonth), cfg.Pair.GetFiatCurrency(),
-			cfg.BuysPerMonth)
+	if cfg.FiatAmountPerBuy.Sign() > 0 {
+		add("Fixed amount per buy", "amount", cfg.FiatAmountPerBuy.StringFixed(2), "currency", fiat)
+	} else if cfg.MonthlyFiatSpending.Sign() > 0 {
+		add("Monthly budget",
+			"budget", cfg.MonthlyFiatSpending.StringFixed(2), "currency", fiat,
+			"amount_per_buy", cfg.FiatPerBuy().StringFixed(2), "buys_per_month", cfg.BuysPerMonth)
 	}
 
 	// Price factor explanation
-	log.Printf("📈 Price factor: %.4f (%.2f%% of ask price)", cfg.PriceFactor, cfg.PriceFactor*100)
-	if cfg.PriceFactor >= 0.999 {
-		log.Print("   → Very conservative: High fill probability, minimal savings")
-	} else if cfg.PriceFactor >= 0.995 {
-		log.Print("   → Conservative: Good fill probability, small savings")
-	} else if cfg.PriceFactor >= 0.99 {
-		log.Print("   → Balanced: Moderate fill probability, good savings")
+	var strategy string
+	if !cfg.PriceFactor.LessThan(order.MustParseDecimal("0.999")) {
+		strategy = "Very conservative: High fill probability, minimal savings"
+	} else if !cfg.PriceFactor.LessThan(order.MustParseDecimal("0.995")) {
+		strategy = "Conservative: Good fill probability, small savings"
+	} else if !cfg.PriceFactor.LessThan(order.MustParseDecimal("0.99")) {
+		strategy = "Balanced: Moderate fill probability, good savings"
 	} else {
-		log.Print("   → Aggressive: Lower fill probability, higher potential savings")
+		strategy = "Aggressive: Lower fill probability, higher potential savings"
 	}
+	add("Price factor", "price_factor", cfg.PriceFactor.StringFixed(4),
+		"percent_of_ask", cfg.PriceFactor.Mul(order.NewDecimalFromInt(100)).StringFixed(2), "strategy", strategy)
 
 	// Scheduling
 	if cfg.SchedulerMode == "systemd" {
-		log.Print("⏰ Schedule: Managed by systemd timer")
+		add("Schedule: Managed by systemd timer", "scheduler_mode", cfg.SchedulerMode)
 	} else if cfg.CronExpr != "" {
-		log.Printf("⏰ Schedule: %s (%s mode)", cfg.CronExpr, cfg.SchedulerMode)
+		add("Schedule", "cron", cfg.CronExpr, "scheduler_mode", cfg.SchedulerMode)
 	} else {
-		log.Printf("⏰ Schedule: Run once (%s mode)", cfg.SchedulerMode)
+		add("Schedule: Run once", "scheduler_mode", cfg.SchedulerMode)
+	}
+
+	// Order type
+	var orderType string
+	switch cfg.OrderType {
+	case OrderTypeLimit:
+		orderType = "Limit (may fill immediately as taker)"
+	case OrderTypeMarket:
+		orderType = "Market (fills immediately at the best available price, price factor is not used)"
+	case OrderTypeLimitMarket:
+		orderType = "Post-only limit, remainder bought at market after the fallback timeout"
+	default:
+		orderType = "Post-only limit (maker only)"
+	}
+	attrs := []any{"order_type", cfg.OrderType, "description", orderType}
+	if cfg.OrderType == OrderTypeLimitMarket {
+		attrs = append(attrs, "market_fallback_after", cfg.MarketFallbackAfter.String())
+	}
+	if cfg.TimeInForce != "" {
+		attrs = append(attrs, "time_in_force", cfg.TimeInForce)
 	}
+	if cfg.OrderExpire > 0 {
+		attrs = append(attrs, "order_expire", cfg.OrderExpire.String())
+	}
+	add("Order type", attrs...)
 
 	// Order behavior
 	if cfg.AutoAdjustMinOrder {
-		log.Print("🔧 Auto-adjustment: Enabled (orders below minimum will be increased)")
+		add("Auto-adjustment: Enabled (orders below minimum will be increased)", "auto_adjust_min_order", true)
 	} else {
-		log.Print("🔧 Auto-adjustment: Disabled (orders below minimum may fail)")
+		add("Auto-adjustment: Disabled (orders below minimum may fail)", "auto_adjust_min_order", false)
+	}
+	if cfg.CronExpr != "" {
+		add("Duplicate protection: At most one order per cron slot")
+	} else if cfg.OrderSlotInterval > 0 {
+		add("Duplicate protection: At most one order per slot", "order_slot_interval", cfg.OrderSlotInterval.String())
+	} else {
+		add("Duplicate protection: Disabled")
+	}
+	if cfg.MaxSpreadBps.Sign() > 0 || cfg.MaxPrice.Sign() > 0 || cfg.MaxPriceChangePct.Sign() > 0 {
+		attrs := []any{"skipped_budget", cfg.SkippedBudget}
+		if cfg.MaxSpreadBps.Sign() > 0 {
+			attrs = append(attrs, "max_spread_bps", cfg.MaxSpreadBps)
+		}
+		if cfg.MaxPrice.Sign() > 0 {
+			attrs = append(attrs, "max_price", cfg.MaxPrice.StringFixed(2), "currency", fiat)
+		}
+		if cfg.MaxPriceChangePct.Sign() > 0 {
+			attrs = append(attrs, "max_price_change_pct", cfg.MaxPriceChangePct, "price_change_window", cfg.PriceChangeWindow.String())
+		}
+		if cfg.SkippedBudget == SkippedBudgetCarry {
+			attrs = append(attrs, "state_file", cfg.StateFile)
+		}
+		add("Market guards: Buys are skipped in abnormal conditions", attrs...)
+	}
+	if cfg.HTTPAddr != "" {
+		add("HTTP server: /metrics, /healthz and /readyz", "http_addr", cfg.HTTPAddr, "ready_max_failed_runs", cfg.ReadyMaxFailedRuns)
+	}
+	if cfg.Dashboard {
+		if cfg.DashboardUser != "" {
+			add("Dashboard: Read-only, basic auth required", "http_addr", cfg.HTTPAddr, "dashboard_user", cfg.DashboardUser)
+		} else {
+			add("Dashboard: Read-only, no authentication", "http_addr", cfg.HTTPAddr)
+		}
+	}
+	if cfg.ControlToken != "" {
+		add("Control API: Enabled at /api/control/ (bearer token required)", "http_addr", cfg.HTTPAddr)
+	}
+	if cfg.SchedulerMode == "cron" {
+		if cfg.ReloadWatchInterval > 0 {
+			add("Config reload: On SIGHUP and file changes", "watch_interval", cfg.ReloadWatchInterval.String())
+		} else {
+			add("Config reload: On SIGHUP")
+		}
+	}
+	if cfg.HistoryFile != "" {
+		add("Run history", "history_file", cfg.HistoryFile)
+	} else {
+		add("Run history: Disabled")
+	}
+	if cfg.LockBackend == LockBackendHistory {
+		add("Instance lock: Lease in the run history", "history_file", cfg.HistoryFile)
+	} else if cfg.LockFile != "" {
+		add("Instance lock", "lock_file", cfg.LockFile)
+	} else {
+		add("Instance lock: Disabled (concurrent runs are not prevented)")
 	}
 
 	// Notifications
-	if cfg.NotifyMethod != "" {
-		log.Printf("🔔 Notifications: %s", cfg.NotifyMethod)
-		if cfg.NotifyMethod == "ntfy" {
-			if cfg.NotifyNtfyURL != "" {
-				log.Printf("   → ntfy server: %s", cfg.NotifyNtfyURL)
+	if cfg.NotifyMethod != "" || len(cfg.NotifyURLs) > 0 {
+		var attrs []any
+		if cfg.NotifyMethod != "" {
+			attrs = append(attrs, "method", cfg.NotifyMethod)
+		}
+		switch cfg.NotifyMethod {
+		case "gotify":
+			attrs = append(attrs, "gotify_url", cfg.NotifyGotifyURL)
+		case "pushover":
+			attrs = append(attrs, "pushover_device", cfg.NotifyPushoverDevice)
+		case "ntfy":
+			attrs = append(attrs, "ntfy_url", cfg.NotifyNtfyURL, "ntfy_topic", cfg.NotifyNtfyTopic)
+		case "matrix":
+			attrs = append(attrs, "matrix_homeserver", cfg.NotifyMatrixHomeserver, "matrix_room", cfg.NotifyMatrixRoomID)
+		case "email":
+			attrs = append(attrs, "smtp_server", net.JoinHostPort(cfg.NotifySMTPHost, strconv.Itoa(cfg.NotifySMTPPort)),
+				"smtp_security", cfg.NotifySMTPSecurity, "recipients", len(cfg.NotifySMTPTo))
+		case "telegram":
+			attrs = append(attrs, "telegram_chats", len(cfg.NotifyTelegramChatIDs), "telegram_commands", cfg.NotifyTelegramCommands)
+		case "webhook":
+			// The path of webhook URLs is often a secret, e.g. Home Assistant webhook IDs
+			if u, err := url.Parse(cfg.NotifyWebhookURL); err == nil {
+				attrs = append(attrs, "webhook_host", u.Host)
 			}
-			if cfg.NotifyNtfyTopic != "" {
-				log.Printf("   → ntfy topic: %s", cfg.NotifyNtfyTopic)
+			attrs = append(attrs, "signed", cfg.NotifyWebhookHMACKey != "")
+		}
+		if len(cfg.NotifyURLs) > 0 {
+			// The URLs contain tokens; show only their schemes
+			schemes := make([]string, len(cfg.NotifyURLs))
+			for i, u := range cfg.NotifyURLs {
+				schemes[i], _, _ = strings.Cut(u, "://")
 			}
+			attrs = append(attrs, "notify_urls", strings.Join(schemes, ", "))
 		}
+		add("Notifications", attrs...)
 	} else {
-		log.Print("🔔 Notifications: Disabled")
+		add("Notifications: Disabled")
 	}
 
 	// API key source
-	if os.Getenv("EASY_DCA_PUBLIC_KEY_PATH") != "" {
-		log.Print("🔑 API keys: Loaded from file paths (secure)")
+	switch cfg.KeySource {
+	case "file":
+		add("API keys: Loaded from file paths (secure)", "key_source", "file")
+	case "config":
+		add("API keys: Loaded from the config file", "key_source", "config", "config_file", cfg.ConfigFile)
+	case "encrypted":
+		add("API keys: Loaded from the encrypted key file (secure)", "key_source", "encrypted", "key_file", cfg.KeyFile)
+	case "env", "":
+		add("API keys: Loaded from environment variables", "key_source", "env")
+	default:
+		add("API keys: Loaded from secret provider", "key_source", cfg.KeySource)
+	}
+	if cfg.CheckPermissions {
+		add("API key permissions: Checked at startup")
 	} else {
-		log.Print("🔑 API keys: Loaded from environment variables")
+		add("API key permissions: Not checked")
 	}
-
-	log.Print("=====================================")
+	return lines
 }
 
-func getEnvAsFloat32(key string, defaultValue float32) float32 {
-	if value := os.Getenv(key); value != "" {
-		if floatValue, err := strconv.ParseFloat(value, 32); err == nil {
-			return float32(floatValue)
-		}
+// getEnvAsDecimal returns the decimal value of key, or defaultValue if it is unset or does not parse.
+func (s *Source) getEnvAsDecimal(key string, defaultValue order.Decimal) order.Decimal {
+	if d, err := s.parseEnvAsDecimal(key, defaultValue); err == nil {
+		return d
 	}
 	return defaultValue
 }
 
-func getEnvAsString(key string, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// parseEnvAsDecimal is like getEnvAsDecimal, but reports a value that does not parse.
+func (s *Source) parseEnvAsDecimal(key string, defaultValue order.Decimal) (order.Decimal, error) {
+	value := s.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := order.ParseDecimal(value)
+	if err != nil {
+		return order.Zero, fmt.Errorf("invalid decimal for %s: %q", key, value)
+	}
+	return d, nil
+}
+
+func (s *Source) getEnvAsDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := s.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func (s *Source) getEnvAsInt(key string, defaultValue int) (int, error) {
+	value := s.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer for %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func (s *Source) getEnvAsString(key string, defaultValue string) string {
+	if value := s.Get(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+func (s *Source) getEnvAsBool(key string, defaultValue bool) bool {
+	if value := s.Get(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
@@ -218,9 +594,233 @@ func loadFileToString(filepath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", filepath, err)
 	}
+	defer clear(content)
 	return string(content), nil
 }
 
+// LoadKeys loads the API key pair like LoadConfig, without loading the rest of the configuration.
+func (s *Source) LoadKeys() (secret.KeyPair, error) {
+	keys, _, errs := s.loadKeys()
+	return keys, errors.Join(errs...)
+}
+
+// loadKeys loads the API key pair from the encrypted key file in EASY_DCA_KEY_FILE if set, else from
+// EASY_DCA_PUBLIC_KEY and EASY_DCA_PRIVATE_KEY (see loadSecret). It returns where the keys came from:
+// "encrypted" for the key file, else the source of the public key.
+func (s *Source) loadKeys() (keys secret.KeyPair, source string, errs []error) {
+	if keyFile := s.Get("EASY_DCA_KEY_FILE"); keyFile != "" {
+		passphrase, err := s.KeyPassphrase()
+		if err != nil {
+			return keys, "encrypted", []error{err}
+		}
+		if passphrase == "" {
+			return keys, "encrypted", []error{fmt.Errorf("EASY_DCA_KEY_FILE requires a passphrase: set EASY_DCA_KEY_PASSPHRASE_FD, EASY_DCA_KEY_PASSPHRASE_SECRET, EASY_DCA_KEY_PASSPHRASE_PATH or EASY_DCA_KEY_PASSPHRASE")}
+		}
+		keys, err = secret.ReadKeyFile(keyFile, passphrase)
+		if err != nil {
+			return keys, "encrypted", []error{fmt.Errorf("EASY_DCA_KEY_FILE: %w", err)}
+		}
+		return keys, "encrypted", nil
+	}
+
+	var err error
+	keys.PublicKey, source, err = s.loadSecret("EASY_DCA_PUBLIC_KEY")
+	if err != nil {
+		errs = append(errs, err)
+	} else if keys.PublicKey == "" {
+		errs = append(errs, fmt.Errorf("No PUBLIC_KEY found, neither via EASY_DCA_KEY_FILE, EASY_DCA_PUBLIC_KEY_SECRET, EASY_DCA_PUBLIC_KEY_PATH nor EASY_DCA_PUBLIC_KEY"))
+	}
+	keys.PrivateKey, _, err = s.loadSecret("EASY_DCA_PRIVATE_KEY")
+	if err != nil {
+		errs = append(errs, err)
+	} else if keys.PrivateKey == "" {
+		errs = append(errs, fmt.Errorf("No PRIVATE_KEY found, neither via EASY_DCA_KEY_FILE, EASY_DCA_PRIVATE_KEY_SECRET, EASY_DCA_PRIVATE_KEY_PATH nor EASY_DCA_PRIVATE_KEY"))
+	}
+	return keys, source, errs
+}
+
+// KeyPassphrase returns the passphrase of the encrypted key file, read from the file descriptor in
+// EASY_DCA_KEY_PASSPHRASE_FD if set, else loaded like a secret from EASY_DCA_KEY_PASSPHRASE (see loadSecret).
+// It returns "" if no passphrase is set.
+func (s *Source) KeyPassphrase() (string, error) {
+	if fd := s.Get("EASY_DCA_KEY_PASSPHRASE_FD"); fd != "" {
+		return readPassphraseFD(fd)
+	}
+	passphrase, _, err := s.loadSecret("EASY_DCA_KEY_PASSPHRASE")
+	return passphrase, err
+}
+
+var (
+	passphraseFDsMu sync.Mutex
+	passphraseFDs   = map[int]string{} // Passphrases read from file descriptors, which can be read only once
+)
+
+// readPassphraseFD reads a passphrase from a file descriptor inherited from the parent process, e.g.
+// "3" for `easy-dca 3< passphrase-pipe`. The descriptor is read to the end and closed; the passphrase is
+// kept for later loads such as configuration reloads.
+func readPassphraseFD(value string) (string, error) {
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 0 {
+		return "", fmt.Errorf("EASY_DCA_KEY_PASSPHRASE_FD must be a file descriptor number, got %q", value)
+	}
+	passphraseFDsMu.Lock()
+	defer passphraseFDsMu.Unlock()
+	if passphrase, ok := passphraseFDs[fd]; ok {
+		return passphrase, nil
+	}
+	f := os.NewFile(uintptr(fd), "passphrase")
+	if f == nil {
+		return "", fmt.Errorf("EASY_DCA_KEY_PASSPHRASE_FD: invalid file descriptor %d", fd)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	defer clear(data)
+	if err != nil {
+		return "", fmt.Errorf("EASY_DCA_KEY_PASSPHRASE_FD: failed to read file descriptor %d: %w", fd, err)
+	}
+	passphrase := strings.TrimSpace(string(data))
+	if passphrase == "" {
+		return "", fmt.Errorf("EASY_DCA_KEY_PASSPHRASE_FD: file descriptor %d is empty", fd)
+	}
+	secret.Track(passphrase)
+	passphraseFDs[fd] = passphrase
+	return passphrase, nil
+}
+
+// loadSecret loads the secret set by key from the first of:
+//   - key_SECRET: a secret provider reference such as "systemd:kraken-private-key"; failing to resolve it is an error
+//   - key_PATH: a file; if it cannot be read, the next source is used
+//   - key itself
+//
+// It returns the secret without surrounding whitespace, or "" if none is set, and where it came from:
+// the provider scheme, "file", "config" (config file) or "env". The secret is tracked for redaction from logs.
+func (s *Source) loadSecret(key string) (value, source string, err error) {
+	if ref := s.Get(key + "_SECRET"); ref != "" {
+		value, err := secret.Resolve(s.secretContext(), ref)
+		if err != nil {
+			return "", "", fmt.Errorf("%s_SECRET: %w", key, err)
+		}
+		return value, secret.Scheme(ref), nil
+	}
+	if path := s.Get(key + "_PATH"); path != "" {
+		if value, err := loadFileToString(path); err == nil {
+			value = strings.TrimSpace(value)
+			secret.Track(value)
+			return value, "file", nil
+		}
+	}
+	value, fromFile := s.lookup(key)
+	value = strings.TrimSpace(value)
+	secret.Track(value)
+	if fromFile {
+		return value, "config", nil
+	}
+	return value, "env", nil
+}
+
+// validateOrderType checks that the order type, time in force and expiry settings can be combined.
+// An expiry without an explicit time in force selects GTD.
+func validateOrderType(cfg *Config) error {
+	switch cfg.OrderType {
+	case OrderTypePostOnly, OrderTypeLimit, OrderTypeMarket, OrderTypeLimitMarket:
+	default:
+		return fmt.Errorf("unsupported EASY_DCA_ORDER_TYPE: %s (supported: %s, %s, %s, %s)",
+			cfg.OrderType, OrderTypePostOnly, OrderTypeLimit, OrderTypeMarket, OrderTypeLimitMarket)
+	}
+	switch cfg.TimeInForce {
+	case "", "GTC", "IOC", "GTD":
+	default:
+		return fmt.Errorf("unsupported EASY_DCA_TIME_IN_FORCE: %s (supported: GTC, IOC, GTD)", cfg.TimeInForce)
+	}
+	if cfg.OrderExpire < 0 {
+		return fmt.Errorf("EASY_DCA_ORDER_EXPIRE must not be negative")
+	}
+	if cfg.OrderExpire > 0 && cfg.OrderExpire < 5*time.Second {
+		return fmt.Errorf("EASY_DCA_ORDER_EXPIRE must be at least 5s")
+	}
+
+	switch cfg.OrderType {
+	case OrderTypeMarket:
+		if cfg.TimeInForce != "" || cfg.OrderExpire > 0 {
+			return fmt.Errorf("EASY_DCA_TIME_IN_FORCE and EASY_DCA_ORDER_EXPIRE cannot be used with market orders")
+		}
+		return nil
+	case OrderTypeLimitMarket:
+		// The limit order expires when the fallback kicks in
+		if cfg.TimeInForce != "" || cfg.OrderExpire > 0 {
+			return fmt.Errorf("EASY_DCA_TIME_IN_FORCE and EASY_DCA_ORDER_EXPIRE cannot be used with limit-market orders; use EASY_DCA_MARKET_FALLBACK_AFTER")
+		}
+		if cfg.MarketFallbackAfter < 5*time.Second {
+			return fmt.Errorf("EASY_DCA_MARKET_FALLBACK_AFTER must be at least 5s")
+		}
+		return nil
+	}
+
+	if cfg.OrderType == OrderTypePostOnly && cfg.TimeInForce == "IOC" {
+		return fmt.Errorf("EASY_DCA_TIME_IN_FORCE=IOC cannot be used with post-only orders")
+	}
+	if cfg.TimeInForce == "GTD" && cfg.OrderExpire == 0 {
+		return fmt.Errorf("EASY_DCA_TIME_IN_FORCE=GTD requires EASY_DCA_ORDER_EXPIRE")
+	}
+	if cfg.OrderExpire > 0 {
+		if cfg.TimeInForce == "" {
+			cfg.TimeInForce = "GTD"
+		} else if cfg.TimeInForce != "GTD" {
+			return fmt.Errorf("EASY_DCA_ORDER_EXPIRE requires EASY_DCA_TIME_IN_FORCE=GTD (got %s)", cfg.TimeInForce)
+		}
+	}
+	return nil
+}
+
+// validateGuards checks the market-condition guard and skipped budget settings.
+func validateGuards(cfg Config) error {
+	if cfg.MaxSpreadBps.Sign() < 0 || cfg.MaxPrice.Sign() < 0 || cfg.MaxPriceChangePct.Sign() < 0 {
+		return fmt.Errorf("EASY_DCA_MAX_SPREAD_BPS, EASY_DCA_MAX_PRICE and EASY_DCA_MAX_PRICE_CHANGE_PCT must not be negative")
+	}
+	// The window is measured with 1-minute candles, of which Kraken keeps the last 720
+	if cfg.PriceChangeWindow < time.Minute || cfg.PriceChangeWindow > 12*time.Hour {
+		return fmt.Errorf("EASY_DCA_PRICE_CHANGE_WINDOW must be between 1m and 12h")
+	}
+	switch cfg.SkippedBudget {
+	case SkippedBudgetForfeit:
+	case SkippedBudgetCarry:
+		if cfg.StateFile == "" {
+			return fmt.Errorf("EASY_DCA_SKIPPED_BUDGET=carry requires EASY_DCA_STATE_FILE")
+		}
+	default:
+		return fmt.Errorf("unsupported EASY_DCA_SKIPPED_BUDGET: %s (supported: %s, %s)", cfg.SkippedBudget, SkippedBudgetForfeit, SkippedBudgetCarry)
+	}
+	return nil
+}
+
+// validateDashboard checks that the dashboard can be served and that basic auth is fully configured.
+func validateDashboard(cfg Config) error {
+	if cfg.Dashboard && cfg.HTTPAddr == "" {
+		return fmt.Errorf("EASY_DCA_DASHBOARD requires EASY_DCA_HTTP_ADDR to be set")
+	}
+	if (cfg.DashboardUser == "") != (cfg.DashboardPassword == "") {
+		return fmt.Errorf("EASY_DCA_DASHBOARD_USER and EASY_DCA_DASHBOARD_PASSWORD must be set together")
+	}
+	return nil
+}
+
+// validateControl checks that the control API can be served and has a strong enough token.
+func validateControl(cfg Config) error {
+	if cfg.ControlToken == "" {
+		return nil
+	}
+	if cfg.HTTPAddr == "" {
+		return fmt.Errorf("EASY_DCA_CONTROL_TOKEN requires EASY_DCA_HTTP_ADDR to be set")
+	}
+	if cfg.SchedulerMode != "cron" {
+		return fmt.Errorf("EASY_DCA_CONTROL_TOKEN requires the cron scheduler mode (the process exits after one run in %s mode)", cfg.SchedulerMode)
+	}
+	if len(cfg.ControlToken) < minControlTokenLength {
+		return fmt.Errorf("EASY_DCA_CONTROL_TOKEN must be at least %d characters long", minControlTokenLength)
+	}
+	return nil
+}
+
 // calculateBuysPerMonth calculates how many times the cron expression will run in a typical month
 func calculateBuysPerMonth(cronExpr string) (int, error) {
 	if cronExpr == "" {
@@ -251,61 +851,212 @@ func calculateBuysPerMonth(cronExpr string) (int, error) {
 
 	// Warn for irregular schedules (less than 2 runs or more than 31 runs in a month)
 	if runCount < 2 {
-		log.Printf("Warning: Cron schedule results in only %d run(s) per month - this may not be optimal for DCA", runCount)
+		slog.Warn("Cron schedule results in few runs per month - this may not be optimal for DCA", "runs_per_month", runCount)
 	} else if runCount > 31 {
-		log.Printf("Warning: Cron schedule results in %d runs per month - this may be more frequent than intended", runCount)
+		slog.Warn("Cron schedule results in many runs per month - this may be more frequent than intended", "runs_per_month", runCount)
 	}
 
 	return runCount, nil
 }
 
-// LoadConfig loads configuration from environment variables and files, validates it, and returns a Config struct.
-// Returns an error if required configuration is missing or invalid.
-func LoadConfig() (Config, error) {
-	var cfg Config
+// LoadPlans loads the configuration of every plan in the configuration file, or the
+// top-level configuration alone if the file defines no plans. The error lists the problems of all plans.
+func (s *Source) LoadPlans() ([]Config, error) {
+	if len(s.plans) == 0 {
+		cfg, err := s.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		return []Config{cfg}, nil
+	}
+	cfgs := make([]Config, 0, len(s.plans))
+	var errs []error
+	for _, p := range s.plans {
+		cfg, err := p.LoadConfig()
+		if err != nil {
+			errs = append(errs, prefixErrors("plan "+p.plan+": ", err)...)
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := validatePlans(cfgs); err != nil {
+		return nil, err
+	}
+	return cfgs, nil
+}
 
-	// 1. Load and validate required API keys first (fail fast)
-	publicKey, err := loadFileToString(os.Getenv("EASY_DCA_PUBLIC_KEY_PATH"))
-	if err != nil {
-		publicKey = os.Getenv("EASY_DCA_PUBLIC_KEY")
-		if publicKey == "" {
-			return cfg, fmt.Errorf("No PUBLIC_KEY found, neither via EASY_DCA_PUBLIC_KEY_PATH nor EASY_DCA_PUBLIC_KEY")
+// validatePlans checks that plans running in one process do not share per-plan files,
+// and that the process-wide features support the number of plans.
+func validatePlans(cfgs []Config) error {
+	lockFiles := map[string]string{}
+	stateFiles := map[string]string{}
+	for _, cfg := range cfgs {
+		if other, ok := lockFiles[cfg.LockFile]; ok && cfg.LockFile != "" && cfg.LockBackend == LockBackendFile {
+			return fmt.Errorf("plans %s and %s use the same lock file %s; set files.lock per plan", other, cfg.Plan, cfg.LockFile)
+		}
+		lockFiles[cfg.LockFile] = cfg.Plan
+		if other, ok := stateFiles[cfg.StateFile]; ok && cfg.StateFile != "" {
+			return fmt.Errorf("plans %s and %s use the same state file %s; set files.state per plan", other, cfg.Plan, cfg.StateFile)
+		}
+		stateFiles[cfg.StateFile] = cfg.Plan
+	}
+	if len(cfgs) > 1 {
+		if cfgs[0].Dashboard {
+			return fmt.Errorf("EASY_DCA_DASHBOARD is not supported with multiple plans")
+		}
+		if cfgs[0].ControlToken != "" {
+			return fmt.Errorf("EASY_DCA_CONTROL_TOKEN is not supported with multiple plans")
+		}
+		for _, cfg := range cfgs {
+			if cfg.NotifyTelegramCommands {
+				return fmt.Errorf("NOTIFY_TELEGRAM_COMMANDS is not supported with multiple plans")
+			}
 		}
 	}
-	cfg.PublicKey = strings.TrimSpace(publicKey)
+	return nil
+}
 
-	privateKey, err := loadFileToString(os.Getenv("EASY_DCA_PRIVATE_KEY_PATH"))
-	if err != nil {
-		privateKey = os.Getenv("EASY_DCA_PRIVATE_KEY")
-		if privateKey == "" {
-			return cfg, fmt.Errorf("No PRIVATE_KEY found, neither via EASY_DCA_PRIVATE_KEY_PATH nor EASY_DCA_PRIVATE_KEY")
+// LoadConfig loads configuration from environment variables only. See Source.LoadConfig.
+func LoadConfig() (Config, error) {
+	return envSource.LoadConfig()
+}
+
+// LoadConfig loads configuration from environment variables, the configuration file and key files,
+// validates it, and returns a Config struct. Environment variables override the configuration file.
+// Returns an error listing every missing or invalid setting, not just the first.
+func (s *Source) LoadConfig() (Config, error) {
+	cfg := Config{ConfigFile: s.path, Plan: s.plan}
+	var errs []error
+	// Invalid durations and integers are reported and replaced by their default, so they do not
+	// cause follow-up errors
+	duration := func(key string, defaultValue time.Duration) time.Duration {
+		d, err := s.getEnvAsDuration(key, defaultValue)
+		if err != nil {
+			errs = append(errs, err)
+			return defaultValue
 		}
+		return d
+	}
+	// Guard limits are reported too: a typo must not turn a safety limit off
+	guard := func(key string) order.Decimal {
+		d, err := s.parseEnvAsDecimal(key, order.Zero)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return d
 	}
-	cfg.PrivateKey = strings.TrimSpace(privateKey)
+
+	// 1. Load required API keys
+	keys, keySource, keyErrs := s.loadKeys()
+	errs = append(errs, keyErrs...)
+	cfg.PublicKey, cfg.PrivateKey, cfg.KeySource = keys.PublicKey, keys.PrivateKey, keySource
+	cfg.KeyFile = s.Get("EASY_DCA_KEY_FILE")
 
 	// 2. Load basic configuration
-	pairStr := getEnvAsString("EASY_DCA_PAIR", "BTC/EUR")
+	pairStr := s.getEnvAsString("EASY_DCA_PAIR", "BTC/EUR")
 	pair, err := NewTradingPair(pairStr)
 	if err != nil {
-		return cfg, err
+		errs = append(errs, err)
 	}
 	cfg.Pair = pair
 
-	cfg.DryRun = getEnvAsBool("EASY_DCA_DRY_RUN", true)
-	cfg.PriceFactor = getEnvAsFloat32("EASY_DCA_PRICE_FACTOR", 0.998)
-	cfg.MonthlyFiatSpending = getEnvAsFloat32("EASY_DCA_MONTHLY_FIAT_SPENDING", 0.0)
-	cfg.FiatAmountPerBuy = getEnvAsFloat32("EASY_DCA_FIAT_AMOUNT_PER_BUY", 0.0)
-	cfg.AutoAdjustMinOrder = getEnvAsBool("EASY_DCA_AUTO_ADJUST_MIN_ORDER", false)
-	cfg.CronExpr = os.Getenv("EASY_DCA_CRON")
-	cfg.SchedulerMode = os.Getenv("EASY_DCA_SCHEDULER_MODE")
-	cfg.DisplaySats = getEnvAsBool("EASY_DCA_DISPLAY_SATS", false)
+	cfg.DryRun = s.getEnvAsBool("EASY_DCA_DRY_RUN", true)
+	cfg.PriceFactor = s.getEnvAsDecimal("EASY_DCA_PRICE_FACTOR", order.MustParseDecimal("0.998"))
+	cfg.MonthlyFiatSpending = s.getEnvAsDecimal("EASY_DCA_MONTHLY_FIAT_SPENDING", order.Zero)
+	cfg.FiatAmountPerBuy = s.getEnvAsDecimal("EASY_DCA_FIAT_AMOUNT_PER_BUY", order.Zero)
+	cfg.AutoAdjustMinOrder = s.getEnvAsBool("EASY_DCA_AUTO_ADJUST_MIN_ORDER", false)
+	cfg.CronExpr = s.Get("EASY_DCA_CRON")
+	cfg.SchedulerMode = s.Get("EASY_DCA_SCHEDULER_MODE")
+	cfg.DisplaySats = s.getEnvAsBool("EASY_DCA_DISPLAY_SATS", false)
+	cfg.CheckPermissions = s.getEnvAsBool("EASY_DCA_CHECK_PERMISSIONS", true)
+	cfg.OrderType = strings.ToLower(s.getEnvAsString("EASY_DCA_ORDER_TYPE", OrderTypePostOnly))
+	cfg.TimeInForce = strings.ToUpper(s.Get("EASY_DCA_TIME_IN_FORCE"))
+	cfg.OrderExpire = duration("EASY_DCA_ORDER_EXPIRE", 0)
+	cfg.MarketFallbackAfter = duration("EASY_DCA_MARKET_FALLBACK_AFTER", time.Hour)
+	// Without a cron expression the run interval is unknown, so slots are only used when configured
+	cfg.OrderSlotInterval = duration("EASY_DCA_ORDER_SLOT_INTERVAL", 0)
+	cfg.LockFile = s.getEnvAsString("EASY_DCA_LOCK_FILE", filepath.Join(os.TempDir(), cfg.fileName("lock")))
+	switch strings.ToLower(cfg.LockFile) {
+	case "off", "none", "false":
+		cfg.LockFile = ""
+	}
+	cfg.LockBackend = strings.ToLower(s.getEnvAsString("EASY_DCA_LOCK_BACKEND", LockBackendFile))
+	cfg.NonceFile = s.getEnvAsString("EASY_DCA_NONCE_FILE", filepath.Join(s.defaultStateDir(), "easy-dca.nonce"))
+	switch strings.ToLower(cfg.NonceFile) {
+	case "off", "none", "false":
+		cfg.NonceFile = ""
+	}
+	cfg.StateFile = s.getEnvAsString("EASY_DCA_STATE_FILE", filepath.Join(s.defaultStateDir(), cfg.fileName("state.json")))
+	switch strings.ToLower(cfg.StateFile) {
+	case "off", "none", "false":
+		cfg.StateFile = ""
+	}
+	cfg.HistoryFile = s.getEnvAsString("EASY_DCA_HISTORY_FILE", filepath.Join(s.defaultStateDir(), "easy-dca.history.jsonl"))
+	switch strings.ToLower(cfg.HistoryFile) {
+	case "off", "none", "false":
+		cfg.HistoryFile = ""
+	}
+	cfg.HTTPAddr = s.Get("EASY_DCA_HTTP_ADDR")
+	cfg.ReadyMaxFailedRuns, err = s.getEnvAsInt("EASY_DCA_READY_MAX_FAILED_RUNS", 3)
+	if err != nil {
+		errs = append(errs, err)
+		cfg.ReadyMaxFailedRuns = 3
+	} else if cfg.ReadyMaxFailedRuns < 1 {
+		errs = append(errs, fmt.Errorf("EASY_DCA_READY_MAX_FAILED_RUNS must be at least 1"))
+	}
+	cfg.Dashboard = s.getEnvAsBool("EASY_DCA_DASHBOARD", false)
+	cfg.DashboardUser = s.Get("EASY_DCA_DASHBOARD_USER")
+	cfg.DashboardPassword, _, err = s.loadSecret("EASY_DCA_DASHBOARD_PASSWORD")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateDashboard(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	cfg.ControlToken, _, err = s.loadSecret("EASY_DCA_CONTROL_TOKEN")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.ReloadWatchInterval = duration("EASY_DCA_RELOAD_WATCH_INTERVAL", 10*time.Second)
+	if cfg.ReloadWatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("EASY_DCA_RELOAD_WATCH_INTERVAL must not be negative"))
+	}
+	cfg.MaxSpreadBps = guard("EASY_DCA_MAX_SPREAD_BPS")
+	cfg.MaxPrice = guard("EASY_DCA_MAX_PRICE")
+	cfg.MaxPriceChangePct = guard("EASY_DCA_MAX_PRICE_CHANGE_PCT")
+	cfg.PriceChangeWindow = duration("EASY_DCA_PRICE_CHANGE_WINDOW", time.Hour)
+	cfg.SkippedBudget = strings.ToLower(s.getEnvAsString("EASY_DCA_SKIPPED_BUDGET", SkippedBudgetForfeit))
 
-	// 3. Validate constraints immediately (fail fast)
-	if cfg.PriceFactor > 0.9999 {
-		return cfg, fmt.Errorf("priceFactor must be smaller than 0.9999 (99.99%% of ask price) to ensure maker orders")
+	// 3. Validate constraints
+	if cfg.PriceFactor.GreaterThan(order.MustParseDecimal("0.9999")) {
+		errs = append(errs, fmt.Errorf("priceFactor must be smaller than 0.9999 (99.99%% of ask price) to ensure maker orders"))
+	}
+	if cfg.PriceFactor.LessThan(order.MustParseDecimal("0.95")) {
+		errs = append(errs, fmt.Errorf("priceFactor must be at least 0.95 (95%% of ask price) to ensure reasonable fill probability"))
 	}
-	if cfg.PriceFactor < 0.95 {
-		return cfg, fmt.Errorf("priceFactor must be at least 0.95 (95%% of ask price) to ensure reasonable fill probability")
+	if err := validateOrderType(&cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.OrderSlotInterval < 0 {
+		errs = append(errs, fmt.Errorf("EASY_DCA_ORDER_SLOT_INTERVAL must not be negative"))
+	}
+	switch cfg.LockBackend {
+	case LockBackendFile:
+	case LockBackendHistory:
+		if cfg.HistoryFile == "" {
+			errs = append(errs, fmt.Errorf("EASY_DCA_LOCK_BACKEND=history requires EASY_DCA_HISTORY_FILE"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported EASY_DCA_LOCK_BACKEND: %s (supported: %s, %s)", cfg.LockBackend, LockBackendFile, LockBackendHistory))
+	}
+	if err := validateGuards(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.SkippedBudget == SkippedBudgetCarry && cfg.StateFile != "" && inTempDir(cfg.StateFile) {
+		cfg.Logger().Warn("EASY_DCA_STATE_FILE is in a temporary directory; carried-forward fiat is lost when it is cleared, e.g. on reboot",
+			"state_file", cfg.StateFile)
 	}
 
 	// 4. Set default scheduler mode based on configuration
@@ -316,25 +1067,26 @@ func LoadConfig() (Config, error) {
 			cfg.SchedulerMode = "manual"
 		}
 	}
+	if err := validateControl(cfg); err != nil {
+		errs = append(errs, err)
+	}
 
 	// 5. Handle systemd mode: ignore monthly buy option and require fixed amount
-	if cfg.SchedulerMode == "systemd" {
-		if cfg.MonthlyFiatSpending > 0 {
-			log.Printf("Warning: EASY_DCA_MONTHLY_FIAT_SPENDING is set but ignored in systemd mode. Use EASY_DCA_FIAT_AMOUNT_PER_BUY instead.")
-			cfg.MonthlyFiatSpending = 0.0 // Ignore monthly spending in systemd mode
-		}
-		if cfg.FiatAmountPerBuy == 0 {
-			return cfg, fmt.Errorf("EASY_DCA_FIAT_AMOUNT_PER_BUY is required in systemd mode (monthly buy calculations are not supported)")
-		}
+	if cfg.SchedulerMode == "systemd" && cfg.MonthlyFiatSpending.Sign() > 0 {
+		slog.Warn("EASY_DCA_MONTHLY_FIAT_SPENDING is set but ignored in systemd mode. Use EASY_DCA_FIAT_AMOUNT_PER_BUY instead.")
+		cfg.MonthlyFiatSpending = order.Zero // Ignore monthly spending in systemd mode
 	}
 
 	// 6. Validate amount configuration after systemd mode handling
-	if cfg.FiatAmountPerBuy == 0 && cfg.MonthlyFiatSpending == 0 {
-		return cfg, fmt.Errorf("either EASY_DCA_FIAT_AMOUNT_PER_BUY or EASY_DCA_MONTHLY_FIAT_SPENDING must be set")
+	if cfg.SchedulerMode == "systemd" && cfg.FiatAmountPerBuy.IsZero() {
+		errs = append(errs, fmt.Errorf("EASY_DCA_FIAT_AMOUNT_PER_BUY is required in systemd mode (monthly buy calculations are not supported)"))
+	} else if cfg.FiatAmountPerBuy.IsZero() && cfg.MonthlyFiatSpending.IsZero() {
+		errs = append(errs, fmt.Errorf("either EASY_DCA_FIAT_AMOUNT_PER_BUY or EASY_DCA_MONTHLY_FIAT_SPENDING must be set"))
 	}
 
-	if cfg.FiatAmountPerBuy > 0 && cfg.MonthlyFiatSpending > 0 {
-		log.Printf("Warning: Both EASY_DCA_FIAT_AMOUNT_PER_BUY (%.2f) and EASY_DCA_MONTHLY_FIAT_SPENDING (%.2f) are set. Amount per buy takes precedence.", cfg.FiatAmountPerBuy, cfg.MonthlyFiatSpending)
+	if cfg.FiatAmountPerBuy.Sign() > 0 && cfg.MonthlyFiatSpending.Sign() > 0 {
+		slog.Warn("Both EASY_DCA_FIAT_AMOUNT_PER_BUY and EASY_DCA_MONTHLY_FIAT_SPENDING are set. Amount per buy takes precedence.",
+			"fiat_amount_per_buy", cfg.FiatAmountPerBuy.StringFixed(2), "monthly_fiat_spending", cfg.MonthlyFiatSpending.StringFixed(2))
 	}
 
 	// 7. Do complex calculations (cron parsing) only for non-systemd modes
@@ -342,7 +1094,7 @@ func LoadConfig() (Config, error) {
 	if cfg.SchedulerMode != "systemd" {
 		buysPerMonth, err = calculateBuysPerMonth(cfg.CronExpr)
 		if err != nil {
-			return cfg, err
+			errs = append(errs, err)
 		}
 	} else {
 		buysPerMonth = 1 // Not used in systemd mode, but set to avoid division by zero
@@ -350,14 +1102,277 @@ func LoadConfig() (Config, error) {
 	cfg.BuysPerMonth = buysPerMonth
 
 	// 8. Load optional notification configuration
-	cfg.NotifyMethod = os.Getenv("NOTIFY_METHOD")
-	cfg.NotifyNtfyTopic = os.Getenv("NOTIFY_NTFY_TOPIC")
-	cfg.NotifyNtfyURL = os.Getenv("NOTIFY_NTFY_URL")
+	cfg.NotifyMethod = s.Get("NOTIFY_METHOD")
+	cfg.NotifyNtfyTopic = s.Get("NOTIFY_NTFY_TOPIC")
+	cfg.NotifyNtfyURL = s.Get("NOTIFY_NTFY_URL")
+	switch strings.ToLower(cfg.NotifyMethod) {
+	case "discord":
+		if cfg.NotifyDiscordWebhookURL, err = s.loadHTTPURL("NOTIFY_DISCORD_WEBHOOK_URL", "discord"); err != nil {
+			errs = append(errs, err)
+		}
+	case "email":
+		errs = append(errs, s.loadSMTP(&cfg)...)
+	case "gotify":
+		errs = append(errs, s.loadGotify(&cfg)...)
+	case "matrix":
+		errs = append(errs, s.loadMatrix(&cfg)...)
+	case "pushover":
+		errs = append(errs, s.loadPushover(&cfg)...)
+	case "slack":
+		if cfg.NotifySlackWebhookURL, err = s.loadHTTPURL("NOTIFY_SLACK_WEBHOOK_URL", "slack"); err != nil {
+			errs = append(errs, err)
+		}
+	case "webhook":
+		errs = append(errs, s.loadWebhook(&cfg)...)
+	case "telegram":
+		errs = append(errs, s.loadTelegram(&cfg)...)
+	}
+	// URLs may contain tokens, e.g. gotifys://host/token
+	if urls, _, err := s.loadSecret("NOTIFY_URLS"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyURLs, err = parseNotifyURLs(urls); err != nil {
+		errs = append(errs, err)
+	}
 	// Add more notification config as needed
 
-	logConfiguration(cfg)
+	return cfg, errors.Join(errs...)
+}
+
+// loadWebhook loads the configuration of the webhook notifier.
+func (s *Source) loadWebhook(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifyWebhookURL, err = s.loadHTTPURL("NOTIFY_WEBHOOK_URL", "webhook"); err != nil {
+		errs = append(errs, err)
+	}
+	// Headers may carry credentials, e.g. "Authorization: Bearer ..."
+	if headers, _, err := s.loadSecret("NOTIFY_WEBHOOK_HEADERS"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyWebhookHeaders, err = parseHeaders(headers); err != nil {
+		errs = append(errs, fmt.Errorf("NOTIFY_WEBHOOK_HEADERS: %w", err))
+	}
+	cfg.NotifyWebhookTemplate = s.Get("NOTIFY_WEBHOOK_TEMPLATE")
+	templateKey := "NOTIFY_WEBHOOK_TEMPLATE"
+	if path := s.Get("NOTIFY_WEBHOOK_TEMPLATE_PATH"); path != "" {
+		templateKey = "NOTIFY_WEBHOOK_TEMPLATE_PATH"
+		if cfg.NotifyWebhookTemplate, err = loadFileToString(path); err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_WEBHOOK_TEMPLATE_PATH: %w", err))
+		}
+	}
+	if cfg.NotifyWebhookTemplate != "" {
+		if _, err := ParseWebhookTemplate(cfg.NotifyWebhookTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", templateKey, err))
+		}
+	}
+	cfg.NotifyWebhookContentType = s.getEnvAsString("NOTIFY_WEBHOOK_CONTENT_TYPE", "application/json")
+	if cfg.NotifyWebhookHMACKey, _, err = s.loadSecret("NOTIFY_WEBHOOK_HMAC_KEY"); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.NotifyWebhookTimeout, err = s.getEnvAsDuration("NOTIFY_WEBHOOK_TIMEOUT", 10*time.Second); err != nil {
+		errs = append(errs, err)
+		cfg.NotifyWebhookTimeout = 10 * time.Second
+	} else if cfg.NotifyWebhookTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_WEBHOOK_TIMEOUT must be positive"))
+	}
+	return errs
+}
+
+// webhookTemplateFuncs are the functions available in webhook body templates.
+var webhookTemplateFuncs = template.FuncMap{
+	// json encodes a value as JSON, e.g. a quoted and escaped string
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseWebhookTemplate parses a webhook body template with the functions available to it.
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(webhookTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// loadTelegram loads the configuration of the Telegram notifier and bot.
+func (s *Source) loadTelegram(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifyTelegramToken, _, err = s.loadSecret("NOTIFY_TELEGRAM_BOT_TOKEN"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyTelegramToken == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_TELEGRAM_BOT_TOKEN is required for telegram notifications"))
+	}
+	for _, field := range strings.FieldsFunc(s.Get("NOTIFY_TELEGRAM_CHAT_IDS"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_TELEGRAM_CHAT_IDS: invalid chat ID %q (use the numeric ID, e.g. from @userinfobot)", field))
+			continue
+		}
+		cfg.NotifyTelegramChatIDs = append(cfg.NotifyTelegramChatIDs, id)
+	}
+	if len(cfg.NotifyTelegramChatIDs) == 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_TELEGRAM_CHAT_IDS is required for telegram notifications"))
+	}
+	cfg.NotifyTelegramCommands = s.getEnvAsBool("NOTIFY_TELEGRAM_COMMANDS", false)
+	if cfg.NotifyTelegramCommands && cfg.SchedulerMode != "cron" {
+		errs = append(errs, fmt.Errorf("NOTIFY_TELEGRAM_COMMANDS requires the cron scheduler mode (the process exits after one run in %s mode)", cfg.SchedulerMode))
+	}
+	cfg.NotifyTelegramAPIURL = s.getEnvAsString("NOTIFY_TELEGRAM_API_URL", "https://api.telegram.org")
+	return errs
+}
+
+// loadHTTPURL loads the secret URL of a notifier, e.g. a chat webhook, and checks that it is an http or https URL.
+func (s *Source) loadHTTPURL(key, method string) (string, error) {
+	value, _, err := s.loadSecret(key)
+	if err != nil {
+		return "", err
+	}
+	if u, err := url.Parse(value); value == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%s must be an http or https URL for %s notifications", key, method)
+	}
+	return value, nil
+}
+
+// loadMatrix loads the configuration of the Matrix notifier.
+func (s *Source) loadMatrix(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifyMatrixHomeserver, err = s.loadHTTPURL("NOTIFY_MATRIX_HOMESERVER", "matrix"); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.NotifyMatrixAccessToken, _, err = s.loadSecret("NOTIFY_MATRIX_ACCESS_TOKEN"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyMatrixAccessToken == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_MATRIX_ACCESS_TOKEN is required for matrix notifications"))
+	}
+	// Aliases such as #room:example.org would need a directory lookup
+	cfg.NotifyMatrixRoomID = s.Get("NOTIFY_MATRIX_ROOM_ID")
+	if !strings.HasPrefix(cfg.NotifyMatrixRoomID, "!") || !strings.Contains(cfg.NotifyMatrixRoomID, ":") {
+		errs = append(errs, fmt.Errorf("NOTIFY_MATRIX_ROOM_ID must be a room ID such as !abc123:example.org (see the room settings), got %q", cfg.NotifyMatrixRoomID))
+	}
+	return errs
+}
+
+// loadGotify loads the configuration of the Gotify notifier.
+func (s *Source) loadGotify(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifyGotifyURL, err = s.loadHTTPURL("NOTIFY_GOTIFY_URL", "gotify"); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.NotifyGotifyToken, _, err = s.loadSecret("NOTIFY_GOTIFY_TOKEN"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyGotifyToken == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_GOTIFY_TOKEN is required for gotify notifications"))
+	}
+	return errs
+}
+
+// loadPushover loads the configuration of the Pushover notifier.
+func (s *Source) loadPushover(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifyPushoverToken, _, err = s.loadSecret("NOTIFY_PUSHOVER_TOKEN"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyPushoverToken == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_PUSHOVER_TOKEN is required for pushover notifications"))
+	}
+	if cfg.NotifyPushoverUser, _, err = s.loadSecret("NOTIFY_PUSHOVER_USER"); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifyPushoverUser == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_PUSHOVER_USER is required for pushover notifications"))
+	}
+	cfg.NotifyPushoverDevice = s.Get("NOTIFY_PUSHOVER_DEVICE")
+	return errs
+}
+
+// parseNotifyURLs splits notification URLs separated by commas or whitespace and checks each with
+// ParseNotifyURL. Errors name the scheme only, as the URLs contain tokens.
+func parseNotifyURLs(value string) ([]string, error) {
+	urls := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	for i, u := range urls {
+		if _, err := ParseNotifyURL(u); err != nil {
+			return nil, fmt.Errorf("NOTIFY_URLS: entry %d: %w", i+1, err)
+		}
+	}
+	return urls, nil
+}
+
+// smtpPorts are the default ports of the SMTP connection security modes.
+var smtpPorts = map[string]int{"starttls": 587, "tls": 465, "none": 25}
+
+// loadSMTP loads the configuration of the email notifier.
+func (s *Source) loadSMTP(cfg *Config) []error {
+	var errs []error
+	var err error
+	if cfg.NotifySMTPHost = s.Get("NOTIFY_SMTP_HOST"); cfg.NotifySMTPHost == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_HOST is required for email notifications"))
+	}
+	cfg.NotifySMTPSecurity = strings.ToLower(s.getEnvAsString("NOTIFY_SMTP_SECURITY", "starttls"))
+	defaultPort, ok := smtpPorts[cfg.NotifySMTPSecurity]
+	if !ok {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_SECURITY must be starttls, tls or none, got %q", cfg.NotifySMTPSecurity))
+		defaultPort = smtpPorts["starttls"]
+	}
+	if cfg.NotifySMTPPort, err = s.getEnvAsInt("NOTIFY_SMTP_PORT", defaultPort); err != nil {
+		errs = append(errs, err)
+	} else if cfg.NotifySMTPPort < 1 || cfg.NotifySMTPPort > 65535 {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_PORT must be between 1 and 65535"))
+	}
+	cfg.NotifySMTPUsername = s.Get("NOTIFY_SMTP_USERNAME")
+	if cfg.NotifySMTPPassword, _, err = s.loadSecret("NOTIFY_SMTP_PASSWORD"); err != nil {
+		errs = append(errs, err)
+	} else if (cfg.NotifySMTPUsername == "") != (cfg.NotifySMTPPassword == "") {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_USERNAME and NOTIFY_SMTP_PASSWORD must be set together"))
+	}
+	if cfg.NotifySMTPFrom = s.Get("NOTIFY_SMTP_FROM"); cfg.NotifySMTPFrom == "" {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_FROM is required for email notifications"))
+	} else if _, err := mail.ParseAddress(cfg.NotifySMTPFrom); err != nil {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_FROM: invalid address %q: %w", cfg.NotifySMTPFrom, err))
+	}
+	for _, field := range strings.Split(s.Get("NOTIFY_SMTP_TO"), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(field); err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_SMTP_TO: invalid address %q: %w", field, err))
+			continue
+		}
+		cfg.NotifySMTPTo = append(cfg.NotifySMTPTo, field)
+	}
+	if len(cfg.NotifySMTPTo) == 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_TO is required for email notifications"))
+	}
+	if cfg.NotifySMTPTimeout, err = s.getEnvAsDuration("NOTIFY_SMTP_TIMEOUT", 30*time.Second); err != nil {
+		errs = append(errs, err)
+		cfg.NotifySMTPTimeout = 30 * time.Second
+	} else if cfg.NotifySMTPTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_SMTP_TIMEOUT must be positive"))
+	}
+	return errs
+}
 
-	return cfg, nil
+// parseHeaders parses HTTP headers written one per line as "Name: value".
+func parseHeaders(value string) (http.Header, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	headers := http.Header{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("expected one \"Name: value\" header per line, got %q", name)
+		}
+		headers.Add(name, strings.TrimSpace(v))
+	}
+	return headers, nil
 }
 
 // formatNumberWithSeparators formats a number with thousands separators
@@ -378,13 +1393,39 @@ func formatNumberWithSeparators(n int64) string {
 	return result
 }
 
+// FiatPerBuy returns the fiat amount to spend per buy: the fixed amount per buy if set,
+// otherwise the monthly spending divided by the buys per month, rounded down to cents.
+func (c *Config) FiatPerBuy() order.Decimal {
+	if c.FiatAmountPerBuy.Sign() > 0 || c.BuysPerMonth <= 0 {
+		return c.FiatAmountPerBuy
+	}
+	return c.MonthlyFiatSpending.Div(order.NewDecimalFromInt(int64(c.BuysPerMonth)), 2, order.RoundDown)
+}
+
+// NextRuns returns the next n run times after from, or nil if easy-dca does not schedule
+// the runs itself (manual and systemd mode).
+func (c *Config) NextRuns(from time.Time, n int) []time.Time {
+	if c.SchedulerMode != "cron" || c.CronExpr == "" {
+		return nil
+	}
+	schedule, err := cron.ParseStandard(c.CronExpr)
+	if err != nil {
+		return nil
+	}
+	runs := make([]time.Time, 0, n)
+	for next := schedule.Next(from); len(runs) < n && !next.IsZero(); next = schedule.Next(next) {
+		runs = append(runs, next)
+	}
+	return runs
+}
+
 // FormatBTC formats a BTC amount according to the display configuration
-func (c *Config) FormatBTC(amount float32) string {
+func (c *Config) FormatBTC(amount order.Decimal) string {
 	if c.DisplaySats {
-		sats := int64(amount * 100000000)
+		sats := amount.Mul(order.NewDecimalFromInt(100000000)).IntPart()
 		return formatNumberWithSeparators(sats)
 	}
-	return fmt.Sprintf("%.8f", amount)
+	return amount.StringFixed(8)
 }
 
 // GetBTCUnit returns the appropriate unit string for BTC amounts
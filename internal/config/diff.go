@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// secretFields are compared by Diff but their values are never shown.
+var secretFields = map[string]bool{
+	"PublicKey":         true,
+	"PrivateKey":        true,
+	"DashboardPassword": true,
+	"ControlToken":      true,
+
+	"NotifyWebhookURL":     true,
+	"NotifyWebhookHeaders": true,
+	"NotifyWebhookHMACKey": true,
+	"NotifyTelegramToken":  true,
+	"NotifySMTPPassword":   true,
+
+	"NotifySlackWebhookURL":   true,
+	"NotifyDiscordWebhookURL": true,
+	"NotifyMatrixAccessToken": true,
+	"NotifyGotifyToken":       true,
+	"NotifyPushoverToken":     true,
+	"NotifyPushoverUser":      true,
+	"NotifyURLs":              true,
+}
+
+// Change describes a setting that differs between two configurations.
+type Change struct {
+	Field string // Name of the Config field, e.g. PriceFactor
+	Old   string // Previous value ("<redacted>" for secrets)
+	New   string // New value ("<redacted>" for secrets)
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s → %s", c.Field, c.Old, c.New)
+}
+
+// Diff returns the settings that differ between old and new, in the order of the Config fields.
+// Secrets such as API keys are reported as changed without their values.
+func Diff(old, new Config) []Change {
+	var changes []Change
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	for i := 0; i < ov.NumField(); i++ {
+		field := ov.Type().Field(i).Name
+		o, n := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+		c := Change{Field: field, Old: formatValue(o), New: formatValue(n)}
+		if secretFields[field] {
+			c.Old, c.New = "<redacted>", "<redacted>"
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// RestartRequired returns the names of the settings that differ between old and new
+// but only take effect when the process is restarted.
+func RestartRequired(old, new Config) []string {
+	var names []string
+	check := func(name string, changed bool) {
+		if changed {
+			names = append(names, name)
+		}
+	}
+	check("EASY_DCA_SCHEDULER_MODE", old.SchedulerMode != new.SchedulerMode)
+	check("EASY_DCA_STATE_FILE", old.StateFile != new.StateFile)
+	check("EASY_DCA_NONCE_FILE", old.NonceFile != new.NonceFile)
+	check("EASY_DCA_HISTORY_FILE", old.HistoryFile != new.HistoryFile)
+	check("EASY_DCA_HTTP_ADDR", old.HTTPAddr != new.HTTPAddr)
+	check("EASY_DCA_DASHBOARD", old.Dashboard != new.Dashboard)
+	check("EASY_DCA_CONTROL_TOKEN (enabling or disabling the control API)", (old.ControlToken == "") != (new.ControlToken == ""))
+	check("EASY_DCA_RELOAD_WATCH_INTERVAL", old.ReloadWatchInterval != new.ReloadWatchInterval)
+	check("NOTIFY_TELEGRAM_COMMANDS (the command bot)", old.NotifyTelegramCommands != new.NotifyTelegramCommands ||
+		(new.NotifyTelegramCommands && (old.NotifyTelegramToken != new.NotifyTelegramToken || old.NotifyTelegramAPIURL != new.NotifyTelegramAPIURL ||
+			!slices.Equal(old.NotifyTelegramChatIDs, new.NotifyTelegramChatIDs))))
+	return names
+}
+
+// CheckReload checks that the reloaded configurations of the plans can replace the running ones
+// without a restart: the plans must keep their names and order, and no setting that needs a restart
+// may change. running and reloaded are in the order of LoadPlans.
+func CheckReload(running, reloaded []Config) error {
+	if !slices.EqualFunc(running, reloaded, func(old, new Config) bool { return old.Plan == new.Plan }) {
+		return fmt.Errorf("adding, removing or renaming plans requires a restart")
+	}
+	for i, old := range running {
+		if names := RestartRequired(old, reloaded[i]); len(names) > 0 {
+			return fmt.Errorf("changing %s requires a restart", strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
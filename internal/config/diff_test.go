@@ -0,0 +1,91 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+func TestDiff(t *testing.T) {
+	old := Config{
+		PrivateKey:  "old-secret",
+		PriceFactor: order.MustParseDecimal("0.99"),
+		CronExpr:    "0 8 * * *",
+		DryRun:      true,
+	}
+	new := old
+	new.PrivateKey = "new-secret"
+	new.PriceFactor = order.MustParseDecimal("0.98")
+	new.CronExpr = "0 9 * * *"
+
+	got := Diff(old, new)
+	want := []string{
+		`PrivateKey: <redacted> → <redacted>`,
+		`PriceFactor: 0.99 → 0.98`,
+		`CronExpr: "0 8 * * *" → "0 9 * * *"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d changes, got %v", len(want), got)
+	}
+	for i, c := range got {
+		if c.String() != want[i] {
+			t.Errorf("change %d: expected %q, got %q", i, want[i], c.String())
+		}
+	}
+
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := Config{SchedulerMode: "cron", HTTPAddr: ":9090", ReloadWatchInterval: 10 * time.Second}
+
+	new := old
+	new.PriceFactor = order.MustParseDecimal("0.98")
+	new.ControlToken = "token"
+	new.HTTPAddr = ":9091"
+	got := RestartRequired(old, new)
+	want := []string{
+		"EASY_DCA_HTTP_ADDR",
+		"EASY_DCA_CONTROL_TOKEN (enabling or disabling the control API)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Rotating the control token does not need a restart
+	old.ControlToken = "token"
+	new.HTTPAddr = old.HTTPAddr
+	new.ControlToken = "rotated"
+	if got := RestartRequired(old, new); len(got) != 0 {
+		t.Errorf("expected no restart, got %v", got)
+	}
+}
+
+func TestCheckReload(t *testing.T) {
+	running := []Config{{Plan: "weekly", HTTPAddr: ":9090"}, {Plan: "monthly"}}
+
+	reloaded := slices.Clone(running)
+	reloaded[1].PriceFactor = order.MustParseDecimal("0.98")
+	if err := CheckReload(running, reloaded); err != nil {
+		t.Errorf("expected a reloadable change to be accepted, got %v", err)
+	}
+
+	tests := map[string]struct {
+		reloaded []Config
+		want     string
+	}{
+		"renamed plan":     {[]Config{{Plan: "weekly", HTTPAddr: ":9090"}, {Plan: "daily"}}, "adding, removing or renaming plans requires a restart"},
+		"removed plan":     {[]Config{{Plan: "weekly", HTTPAddr: ":9090"}}, "adding, removing or renaming plans requires a restart"},
+		"added plan":       {append(slices.Clone(running), Config{Plan: "daily"}), "adding, removing or renaming plans requires a restart"},
+		"restart required": {[]Config{{Plan: "weekly", HTTPAddr: ":9090"}, {Plan: "monthly", NonceFile: "nonce"}}, "changing EASY_DCA_NONCE_FILE requires a restart"},
+	}
+	for name, tt := range tests {
+		if err := CheckReload(running, tt.reloaded); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", name, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// DotEnv loads a .env file into the environment without overriding variables set by the
+// process environment. Loading it again updates the variables it set and removes those
+// no longer in the file, so a configuration reload picks up edits to the file.
+type DotEnv struct {
+	path    string
+	process map[string]bool // Variables of the process environment, which the file never overrides
+	loaded  map[string]bool // Variables set from the file
+}
+
+// NewDotEnv returns a loader for the .env file at path. It must be created before the
+// file is first loaded, to tell the process environment apart from the file.
+func NewDotEnv(path string) *DotEnv {
+	d := &DotEnv{path: path, process: map[string]bool{}, loaded: map[string]bool{}}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		d.process[key] = true
+	}
+	return d
+}
+
+// Path returns the path of the file.
+func (d *DotEnv) Path() string { return d.path }
+
+// Load sets the variables of the file. A missing file sets none.
+func (d *DotEnv) Load() error {
+	env, err := d.Read()
+	if err != nil {
+		return err
+	}
+	d.Apply(env)
+	return nil
+}
+
+// Read reads the file without changing the environment. It returns the environment that
+// applying the file would produce, so a configuration can be validated against it first.
+// A missing file sets no variables.
+func (d *DotEnv) Read() (*DotEnvValues, error) {
+	values, err := godotenv.Read(d.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for key := range values {
+		if d.process[key] {
+			delete(values, key)
+		}
+	}
+	return &DotEnvValues{d: d, values: values}, nil
+}
+
+// Apply sets the variables of env, read from the file by Read, and removes those the file no longer sets.
+func (d *DotEnv) Apply(env *DotEnvValues) {
+	for key := range d.loaded {
+		if _, ok := env.values[key]; !ok {
+			os.Unsetenv(key)
+			delete(d.loaded, key)
+		}
+	}
+	for key, value := range env.values {
+		os.Setenv(key, value)
+		d.loaded[key] = true
+	}
+}
+
+// DotEnvValues is the environment with the variables of a .env file, before they are applied.
+// It implements secret.Env.
+type DotEnvValues struct {
+	d      *DotEnv
+	values map[string]string // Variables of the file, except those of the process environment
+}
+
+// Getenv returns a variable of the environment.
+func (e *DotEnvValues) Getenv(key string) string {
+	if e.d.process[key] {
+		return os.Getenv(key)
+	}
+	return e.values[key]
+}
+
+// Environ returns the environment in the form of os.Environ.
+func (e *DotEnvValues) Environ() []string {
+	var environ []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := e.values[key]; !ok && !e.d.loaded[key] {
+			environ = append(environ, kv)
+		}
+	}
+	for key, value := range e.values {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeDotEnv writes a .env file and unsets the variables it sets when the test ends.
+func writeDotEnv(t *testing.T, path, content string, keys ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		t.Cleanup(func() { os.Unsetenv(key) })
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	t.Setenv("EASY_DCA_TEST_PROCESS", "process")
+	path := filepath.Join(t.TempDir(), ".env")
+	writeDotEnv(t, path, "EASY_DCA_TEST_A=1\nEASY_DCA_TEST_B=2\nEASY_DCA_TEST_PROCESS=file\n", "EASY_DCA_TEST_A", "EASY_DCA_TEST_B")
+
+	d := NewDotEnv(path)
+	if err := d.Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if os.Getenv("EASY_DCA_TEST_A") != "1" || os.Getenv("EASY_DCA_TEST_B") != "2" {
+		t.Errorf("expected the variables of the file to be set")
+	}
+	if got := os.Getenv("EASY_DCA_TEST_PROCESS"); got != "process" {
+		t.Errorf("expected the process environment to take precedence, got %q", got)
+	}
+
+	// Reading a changed file leaves the environment alone until it is applied
+	writeDotEnv(t, path, "EASY_DCA_TEST_A=changed\nEASY_DCA_TEST_C=3\n", "EASY_DCA_TEST_C")
+	env, err := d.Read()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if os.Getenv("EASY_DCA_TEST_A") != "1" || os.Getenv("EASY_DCA_TEST_B") != "2" {
+		t.Errorf("expected Read not to change the environment")
+	}
+	if env.Getenv("EASY_DCA_TEST_A") != "changed" || env.Getenv("EASY_DCA_TEST_B") != "" || env.Getenv("EASY_DCA_TEST_PROCESS") != "process" {
+		t.Errorf("expected the read environment to reflect the changed file")
+	}
+	environ := env.Environ()
+	if !slices.Contains(environ, "EASY_DCA_TEST_A=changed") || slices.Contains(environ, "EASY_DCA_TEST_B=2") || !slices.Contains(environ, "EASY_DCA_TEST_PROCESS=process") {
+		t.Errorf("unexpected environ %v", environ)
+	}
+
+	d.Apply(env)
+	if os.Getenv("EASY_DCA_TEST_A") != "changed" || os.Getenv("EASY_DCA_TEST_C") != "3" {
+		t.Errorf("expected the changed variables to be set")
+	}
+	if _, ok := os.LookupEnv("EASY_DCA_TEST_B"); ok {
+		t.Errorf("expected the variable removed from the file to be unset")
+	}
+
+	// A removed file unsets every variable it set
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Load(); err != nil {
+		t.Fatalf("expected no error for a missing file, got %v", err)
+	}
+	for _, key := range []string{"EASY_DCA_TEST_A", "EASY_DCA_TEST_C"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("expected %s to be unset", key)
+		}
+	}
+	if os.Getenv("EASY_DCA_TEST_PROCESS") != "process" {
+		t.Errorf("expected the process environment to be kept")
+	}
+}
+
+func TestDotEnv_InvalidReload(t *testing.T) {
+	t.Setenv("EASY_DCA_PUBLIC_KEY", "test-public")
+	t.Setenv("EASY_DCA_PRIVATE_KEY", "test-private")
+	path := filepath.Join(t.TempDir(), ".env")
+	writeDotEnv(t, path, "EASY_DCA_FIAT_AMOUNT_PER_BUY=10\nEASY_DCA_PRICE_FACTOR=0.99\n", "EASY_DCA_FIAT_AMOUNT_PER_BUY", "EASY_DCA_PRICE_FACTOR")
+	d := NewDotEnv(path)
+	if err := d.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reloaded file is validated against its own environment; the process keeps the old one
+	writeDotEnv(t, path, "EASY_DCA_FIAT_AMOUNT_PER_BUY=10\nEASY_DCA_PRICE_FACTOR=2\n")
+	env, err := d.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := NewSourceWithEnv("", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.LoadConfig(); err == nil {
+		t.Fatal("expected the reloaded configuration to be rejected")
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected the running configuration to stay valid, got %v", err)
+	}
+	if cfg.PriceFactor.String() != "0.99" {
+		t.Errorf("expected the running price factor to be kept, got %s", cfg.PriceFactor)
+	}
+}
@@ -0,0 +1,567 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mayrf/easy-dca/internal/secret"
+	"gopkg.in/yaml.v3"
+)
+
+// fileSchema maps the keys of a configuration file to the environment variables they set.
+// Nested keys are joined with dots, e.g. order.type in
+//
+//	order:
+//	  type: post-only
+var fileSchema = map[string]string{
+	"pair":         "EASY_DCA_PAIR",
+	"dry_run":      "EASY_DCA_DRY_RUN",
+	"display_sats": "EASY_DCA_DISPLAY_SATS",
+
+	"keys.public_key":         "EASY_DCA_PUBLIC_KEY",
+	"keys.public_key_path":    "EASY_DCA_PUBLIC_KEY_PATH",
+	"keys.public_key_secret":  "EASY_DCA_PUBLIC_KEY_SECRET",
+	"keys.private_key":        "EASY_DCA_PRIVATE_KEY",
+	"keys.private_key_path":   "EASY_DCA_PRIVATE_KEY_PATH",
+	"keys.private_key_secret": "EASY_DCA_PRIVATE_KEY_SECRET",
+	"keys.file":               "EASY_DCA_KEY_FILE",
+	"keys.passphrase":         "EASY_DCA_KEY_PASSPHRASE",
+	"keys.passphrase_path":    "EASY_DCA_KEY_PASSPHRASE_PATH",
+	"keys.passphrase_secret":  "EASY_DCA_KEY_PASSPHRASE_SECRET",
+	"keys.passphrase_fd":      "EASY_DCA_KEY_PASSPHRASE_FD",
+	"keys.check_permissions":  "EASY_DCA_CHECK_PERMISSIONS",
+
+	"amount.per_buy":               "EASY_DCA_FIAT_AMOUNT_PER_BUY",
+	"amount.monthly":               "EASY_DCA_MONTHLY_FIAT_SPENDING",
+	"amount.auto_adjust_min_order": "EASY_DCA_AUTO_ADJUST_MIN_ORDER",
+
+	"schedule.mode":          "EASY_DCA_SCHEDULER_MODE",
+	"schedule.cron":          "EASY_DCA_CRON",
+	"schedule.slot_interval": "EASY_DCA_ORDER_SLOT_INTERVAL",
+
+	"order.type":                  "EASY_DCA_ORDER_TYPE",
+	"order.price_factor":          "EASY_DCA_PRICE_FACTOR",
+	"order.time_in_force":         "EASY_DCA_TIME_IN_FORCE",
+	"order.expire":                "EASY_DCA_ORDER_EXPIRE",
+	"order.market_fallback_after": "EASY_DCA_MARKET_FALLBACK_AFTER",
+
+	"guards.max_spread_bps":       "EASY_DCA_MAX_SPREAD_BPS",
+	"guards.max_price":            "EASY_DCA_MAX_PRICE",
+	"guards.max_price_change_pct": "EASY_DCA_MAX_PRICE_CHANGE_PCT",
+	"guards.price_change_window":  "EASY_DCA_PRICE_CHANGE_WINDOW",
+	"guards.skipped_budget":       "EASY_DCA_SKIPPED_BUDGET",
+
+	"files.lock":         "EASY_DCA_LOCK_FILE",
+	"files.lock_backend": "EASY_DCA_LOCK_BACKEND",
+	"files.nonce":        "EASY_DCA_NONCE_FILE",
+	"files.state":        "EASY_DCA_STATE_FILE",
+	"files.history":      "EASY_DCA_HISTORY_FILE",
+
+	"log.level":  "EASY_DCA_LOG_LEVEL",
+	"log.format": "EASY_DCA_LOG_FORMAT",
+
+	"http.addr":                  "EASY_DCA_HTTP_ADDR",
+	"http.ready_max_failed_runs": "EASY_DCA_READY_MAX_FAILED_RUNS",
+	"http.control_token":         "EASY_DCA_CONTROL_TOKEN",
+	"http.control_token_path":    "EASY_DCA_CONTROL_TOKEN_PATH",
+	"http.control_token_secret":  "EASY_DCA_CONTROL_TOKEN_SECRET",
+
+	"reload.watch_interval": "EASY_DCA_RELOAD_WATCH_INTERVAL",
+
+	"dashboard.enabled":         "EASY_DCA_DASHBOARD",
+	"dashboard.user":            "EASY_DCA_DASHBOARD_USER",
+	"dashboard.password":        "EASY_DCA_DASHBOARD_PASSWORD",
+	"dashboard.password_path":   "EASY_DCA_DASHBOARD_PASSWORD_PATH",
+	"dashboard.password_secret": "EASY_DCA_DASHBOARD_PASSWORD_SECRET",
+
+	"notify.method":     "NOTIFY_METHOD",
+	"notify.ntfy_topic": "NOTIFY_NTFY_TOPIC",
+	"notify.ntfy_url":   "NOTIFY_NTFY_URL",
+
+	"notify.webhook.url":             "NOTIFY_WEBHOOK_URL",
+	"notify.webhook.url_path":        "NOTIFY_WEBHOOK_URL_PATH",
+	"notify.webhook.url_secret":      "NOTIFY_WEBHOOK_URL_SECRET",
+	"notify.webhook.headers":         "NOTIFY_WEBHOOK_HEADERS",
+	"notify.webhook.headers_path":    "NOTIFY_WEBHOOK_HEADERS_PATH",
+	"notify.webhook.headers_secret":  "NOTIFY_WEBHOOK_HEADERS_SECRET",
+	"notify.webhook.template":        "NOTIFY_WEBHOOK_TEMPLATE",
+	"notify.webhook.template_path":   "NOTIFY_WEBHOOK_TEMPLATE_PATH",
+	"notify.webhook.content_type":    "NOTIFY_WEBHOOK_CONTENT_TYPE",
+	"notify.webhook.hmac_key":        "NOTIFY_WEBHOOK_HMAC_KEY",
+	"notify.webhook.hmac_key_path":   "NOTIFY_WEBHOOK_HMAC_KEY_PATH",
+	"notify.webhook.hmac_key_secret": "NOTIFY_WEBHOOK_HMAC_KEY_SECRET",
+	"notify.webhook.timeout":         "NOTIFY_WEBHOOK_TIMEOUT",
+
+	"notify.telegram.bot_token":        "NOTIFY_TELEGRAM_BOT_TOKEN",
+	"notify.telegram.bot_token_path":   "NOTIFY_TELEGRAM_BOT_TOKEN_PATH",
+	"notify.telegram.bot_token_secret": "NOTIFY_TELEGRAM_BOT_TOKEN_SECRET",
+	"notify.telegram.chat_ids":         "NOTIFY_TELEGRAM_CHAT_IDS",
+	"notify.telegram.commands":         "NOTIFY_TELEGRAM_COMMANDS",
+	"notify.telegram.api_url":          "NOTIFY_TELEGRAM_API_URL",
+
+	"notify.smtp.host":            "NOTIFY_SMTP_HOST",
+	"notify.smtp.port":            "NOTIFY_SMTP_PORT",
+	"notify.smtp.security":        "NOTIFY_SMTP_SECURITY",
+	"notify.smtp.username":        "NOTIFY_SMTP_USERNAME",
+	"notify.smtp.password":        "NOTIFY_SMTP_PASSWORD",
+	"notify.smtp.password_path":   "NOTIFY_SMTP_PASSWORD_PATH",
+	"notify.smtp.password_secret": "NOTIFY_SMTP_PASSWORD_SECRET",
+	"notify.smtp.from":            "NOTIFY_SMTP_FROM",
+	"notify.smtp.to":              "NOTIFY_SMTP_TO",
+	"notify.smtp.timeout":         "NOTIFY_SMTP_TIMEOUT",
+
+	"notify.slack.webhook_url":          "NOTIFY_SLACK_WEBHOOK_URL",
+	"notify.slack.webhook_url_path":     "NOTIFY_SLACK_WEBHOOK_URL_PATH",
+	"notify.slack.webhook_url_secret":   "NOTIFY_SLACK_WEBHOOK_URL_SECRET",
+	"notify.discord.webhook_url":        "NOTIFY_DISCORD_WEBHOOK_URL",
+	"notify.discord.webhook_url_path":   "NOTIFY_DISCORD_WEBHOOK_URL_PATH",
+	"notify.discord.webhook_url_secret": "NOTIFY_DISCORD_WEBHOOK_URL_SECRET",
+
+	"notify.matrix.homeserver":          "NOTIFY_MATRIX_HOMESERVER",
+	"notify.matrix.access_token":        "NOTIFY_MATRIX_ACCESS_TOKEN",
+	"notify.matrix.access_token_path":   "NOTIFY_MATRIX_ACCESS_TOKEN_PATH",
+	"notify.matrix.access_token_secret": "NOTIFY_MATRIX_ACCESS_TOKEN_SECRET",
+	"notify.matrix.room_id":             "NOTIFY_MATRIX_ROOM_ID",
+
+	"notify.gotify.url":          "NOTIFY_GOTIFY_URL",
+	"notify.gotify.token":        "NOTIFY_GOTIFY_TOKEN",
+	"notify.gotify.token_path":   "NOTIFY_GOTIFY_TOKEN_PATH",
+	"notify.gotify.token_secret": "NOTIFY_GOTIFY_TOKEN_SECRET",
+
+	"notify.pushover.token":        "NOTIFY_PUSHOVER_TOKEN",
+	"notify.pushover.token_path":   "NOTIFY_PUSHOVER_TOKEN_PATH",
+	"notify.pushover.token_secret": "NOTIFY_PUSHOVER_TOKEN_SECRET",
+	"notify.pushover.user":         "NOTIFY_PUSHOVER_USER",
+	"notify.pushover.user_path":    "NOTIFY_PUSHOVER_USER_PATH",
+	"notify.pushover.user_secret":  "NOTIFY_PUSHOVER_USER_SECRET",
+	"notify.pushover.device":       "NOTIFY_PUSHOVER_DEVICE",
+
+	"notify.urls":        "NOTIFY_URLS",
+	"notify.urls_path":   "NOTIFY_URLS_PATH",
+	"notify.urls_secret": "NOTIFY_URLS_SECRET",
+}
+
+// processKeys are the keys shared by all plans of a process; they cannot be set per plan.
+var processKeys = []string{"log.", "http.", "dashboard.", "reload.", "files.nonce", "files.history"}
+
+// isProcessKey reports whether key configures the process rather than a plan.
+func isProcessKey(key string) bool {
+	for _, k := range processKeys {
+		if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// planNamePattern restricts plan names to characters that are safe in file names, metric labels and URLs.
+var planNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// fileContent is the parsed content of a configuration file, by file key (e.g. order.type).
+type fileContent struct {
+	values map[string]string // Top-level values, shared by all plans
+	plans  []filePlan        // Plans, in file order
+}
+
+// filePlan is one entry of the plans list.
+type filePlan struct {
+	name   string
+	values map[string]string
+}
+
+// Source provides configuration values by their environment variable name.
+// Environment variables (including those loaded from .env) take precedence over
+// values from the configuration file. The source of a plan additionally has the values
+// of the plan, which take precedence over both.
+type Source struct {
+	path  string            // Configuration file (empty if none)
+	file  map[string]string // Top-level values from the configuration file, by environment variable name
+	plan  string            // Name of the plan (empty for the top-level source)
+	own   map[string]string // Values of the plan, by environment variable name
+	plans []*Source         // Sources of the plans in the configuration file
+	env   secret.Env        // Environment to read instead of the process environment (nil for the process environment)
+}
+
+// NewSource returns a source reading the environment and, if path is not empty,
+// a YAML (.yaml, .yml) or TOML (.toml) configuration file.
+func NewSource(path string) (*Source, error) {
+	return NewSourceWithEnv(path, nil)
+}
+
+// NewSourceWithEnv is like NewSource, but reads env instead of the process environment, also to
+// resolve secrets. A configuration reload validates the edited .env file this way before applying it.
+func NewSourceWithEnv(path string, env secret.Env) (*Source, error) {
+	s := &Source{path: path, file: map[string]string{}, env: env}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	content, err := parseConfigFile(filepath.Ext(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	s.file = byEnvName(content.values)
+	for _, p := range content.plans {
+		s.plans = append(s.plans, &Source{path: path, file: s.file, plan: p.name, own: byEnvName(p.values), env: env})
+	}
+	return s, nil
+}
+
+// byEnvName re-keys values by file key to values by environment variable name.
+func byEnvName(values map[string]string) map[string]string {
+	env := make(map[string]string, len(values))
+	for key, value := range values {
+		env[fileSchema[key]] = value
+	}
+	return env
+}
+
+// envSource reads the environment only.
+var envSource = &Source{file: map[string]string{}}
+
+// Path returns the configuration file of the source, or "" if there is none.
+func (s *Source) Path() string {
+	return s.path
+}
+
+// Plan returns the name of the plan of the source, or "" for the top-level source.
+func (s *Source) Plan() string {
+	return s.plan
+}
+
+// Plans returns the sources of the plans defined in the configuration file, or nil if it defines none.
+func (s *Source) Plans() []*Source {
+	return s.plans
+}
+
+// Get returns the value of a configuration variable: from the plan, the environment or the
+// top-level values of the configuration file, in that order. Returns "" if none sets it.
+func (s *Source) Get(key string) string {
+	value, _ := s.lookup(key)
+	return value
+}
+
+// lookup returns the value of a configuration variable and whether it came from the configuration file.
+func (s *Source) lookup(key string) (string, bool) {
+	if value, ok := s.own[key]; ok {
+		return value, true
+	}
+	if value := s.getenv(key); value != "" {
+		return value, false
+	}
+	value, ok := s.file[key]
+	return value, ok
+}
+
+// getenv returns a variable of the environment of the source.
+func (s *Source) getenv(key string) string {
+	if s.env != nil {
+		return s.env.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+// secretContext returns the context secrets of the source are resolved with.
+func (s *Source) secretContext() context.Context {
+	if s.env != nil {
+		return secret.WithEnv(context.Background(), s.env)
+	}
+	return context.Background()
+}
+
+// parseConfigFile parses a configuration file, rejecting keys that are not part of the schema.
+func parseConfigFile(ext string, data []byte) (fileContent, error) {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		return parseYAML(data)
+	case ".toml":
+		return parseTOML(data)
+	default:
+		return fileContent{}, fmt.Errorf("unsupported config file format %q (supported: .yaml, .yml, .toml)", ext)
+	}
+}
+
+// parseYAML parses a YAML configuration file. Scalars are kept as written, so decimals keep their precision.
+func parseYAML(data []byte) (fileContent, error) {
+	content := fileContent{values: map[string]string{}}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return content, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return content, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return content, fmt.Errorf("line %d: expected a mapping of keys to values", root.Line)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if keyNode, valueNode := root.Content[i], root.Content[i+1]; keyNode.Value == "plans" {
+			plans, err := parseYAMLPlans(valueNode)
+			if err != nil {
+				return content, err
+			}
+			content.plans = plans
+		}
+	}
+	if err := walkYAML("", root, content.values, false); err != nil {
+		return content, err
+	}
+	return content, nil
+}
+
+// parseYAMLPlans parses the plans list of a YAML configuration file.
+func parseYAMLPlans(node *yaml.Node) ([]filePlan, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: \"plans\" must be a list of plans", node.Line)
+	}
+	var plans []filePlan
+	for _, planNode := range node.Content {
+		if planNode.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: each plan must be a mapping of keys to values", planNode.Line)
+		}
+		plan := filePlan{values: map[string]string{}}
+		for i := 0; i+1 < len(planNode.Content); i += 2 {
+			if keyNode, valueNode := planNode.Content[i], planNode.Content[i+1]; keyNode.Value == "name" {
+				plan.name = valueNode.Value
+			}
+		}
+		if err := walkYAML("", planNode, plan.values, true); err != nil {
+			return nil, err
+		}
+		if err := checkPlanName(plan.name, plans); err != nil {
+			return nil, fmt.Errorf("line %d: %w", planNode.Line, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// walkYAML collects the values of a YAML mapping into values, by file key.
+// The plans list and plan names are parsed separately and skipped.
+func walkYAML(prefix string, node *yaml.Node, values map[string]string, inPlan bool) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := joinKey(prefix, keyNode.Value)
+		if prefix == "" && (key == "plans" || (inPlan && key == "name")) {
+			continue
+		}
+		switch valueNode.Kind {
+		case yaml.MappingNode:
+			if _, isValue := fileSchema[key]; isValue {
+				return fmt.Errorf("line %d: %q must be a single value, not a section", keyNode.Line, key)
+			}
+			if !isSection(key) {
+				return fmt.Errorf("line %d: %w", keyNode.Line, unknownKeyError(key))
+			}
+			if err := walkYAML(key, valueNode, values, inPlan); err != nil {
+				return err
+			}
+		case yaml.ScalarNode:
+			if err := checkValueKey(key, inPlan); err != nil {
+				return fmt.Errorf("line %d: %w", keyNode.Line, err)
+			}
+			if valueNode.Tag != "!!null" {
+				values[key] = valueNode.Value
+			}
+		default:
+			return fmt.Errorf("line %d: %q must be a single value", keyNode.Line, key)
+		}
+	}
+	return nil
+}
+
+// parseTOML parses a TOML configuration file.
+func parseTOML(data []byte) (fileContent, error) {
+	content := fileContent{values: map[string]string{}}
+	var doc map[string]any
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return content, fmt.Errorf("invalid TOML: %w", err)
+	}
+	if raw, ok := doc["plans"]; ok {
+		tables, ok := raw.([]map[string]any)
+		if !ok {
+			return content, fmt.Errorf("\"plans\" must be an array of tables ([[plans]])")
+		}
+		for i, table := range tables {
+			plan := filePlan{values: map[string]string{}}
+			if name, ok := table["name"].(string); ok {
+				plan.name = name
+			}
+			delete(table, "name")
+			if err := walkTOML("", table, plan.values, true); err != nil {
+				return content, fmt.Errorf("plan %d: %w", i+1, err)
+			}
+			if err := checkPlanName(plan.name, content.plans); err != nil {
+				return content, fmt.Errorf("plan %d: %w", i+1, err)
+			}
+			content.plans = append(content.plans, plan)
+		}
+		delete(doc, "plans")
+	}
+	if err := walkTOML("", doc, content.values, false); err != nil {
+		return content, err
+	}
+	return content, nil
+}
+
+// walkTOML collects the values of a TOML table into values, by file key.
+func walkTOML(prefix string, table map[string]any, values map[string]string, inPlan bool) error {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := joinKey(prefix, k)
+		switch v := table[k].(type) {
+		case map[string]any:
+			if _, isValue := fileSchema[key]; isValue {
+				return fmt.Errorf("%q must be a single value, not a table", key)
+			}
+			if !isSection(key) {
+				return unknownKeyError(key)
+			}
+			if err := walkTOML(key, v, values, inPlan); err != nil {
+				return err
+			}
+		case string, bool, int64, float64:
+			if err := checkValueKey(key, inPlan); err != nil {
+				return err
+			}
+			values[key] = formatTOMLValue(v)
+		default:
+			return fmt.Errorf("%q must be a string, number or boolean", key)
+		}
+	}
+	return nil
+}
+
+// checkPlanName returns an error if name is not a valid plan name or is used by one of plans.
+func checkPlanName(name string, plans []filePlan) error {
+	if name == "" {
+		return fmt.Errorf("every plan needs a name")
+	}
+	if !planNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid plan name %q: use lowercase letters, digits, - and _", name)
+	}
+	for _, p := range plans {
+		if p.name == name {
+			return fmt.Errorf("duplicate plan name %q", name)
+		}
+	}
+	return nil
+}
+
+func formatTOMLValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// isSection reports whether key is a section of the schema, such as order.
+func isSection(key string) bool {
+	for k := range fileSchema {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValueKey returns an error if key does not name a value of the schema, or
+// names a process-wide value inside a plan.
+func checkValueKey(key string, inPlan bool) error {
+	if _, ok := fileSchema[key]; ok {
+		if inPlan && isProcessKey(key) {
+			return fmt.Errorf("%q applies to the whole process and cannot be set per plan; set it at the top level", key)
+		}
+		return nil
+	}
+	if isSection(key) {
+		return fmt.Errorf("%q is a section; set one of its keys instead (%s)", key, strings.Join(sectionKeys(key), ", "))
+	}
+	return unknownKeyError(key)
+}
+
+// sectionKeys returns the keys of a section, sorted.
+func sectionKeys(section string) []string {
+	var keys []string
+	for k := range fileSchema {
+		if strings.HasPrefix(k, section+".") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unknownKeyError describes an unknown key, suggesting the closest known key or the
+// file key of an environment variable name.
+func unknownKeyError(key string) error {
+	for k, env := range fileSchema {
+		if strings.EqualFold(key, env) {
+			return fmt.Errorf("unknown key %q; environment variable names are not keys, use %q", key, k)
+		}
+	}
+	best, bestDist := "", 4 // Suggest keys at most 3 edits away
+	for k := range fileSchema {
+		if d := editDistance(key, k); d < bestDist || (d == bestDist && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	if best == "" {
+		// A known key in the wrong section, e.g. cron instead of schedule.cron
+		leaf := key[strings.LastIndex(key, ".")+1:]
+		for k := range fileSchema {
+			if strings.HasSuffix(k, "."+leaf) && (best == "" || k < best) {
+				best = k
+			}
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown key %q; did you mean %q?", key, best)
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+// writeConfigFile writes a configuration file to a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearConfigEnv unsets the environment variables of the schema for the duration of the test.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range fileSchema {
+		t.Setenv(env, "")
+	}
+	tmp := t.TempDir()
+	t.Setenv("EASY_DCA_LOCK_FILE", "off")
+	t.Setenv("EASY_DCA_NONCE_FILE", "off")
+	t.Setenv("EASY_DCA_STATE_FILE", filepath.Join(tmp, "state.json"))
+	t.Setenv("EASY_DCA_HISTORY_FILE", "off")
+}
+
+const testYAML = `
+pair: BTC/USD
+dry_run: false
+keys:
+  public_key: file-public
+  private_key: file-private
+amount:
+  per_buy: 25.50
+schedule:
+  mode: cron
+  cron: "0 8 * * 1"
+order:
+  type: limit-market
+  price_factor: 0.9985
+  market_fallback_after: 2h
+guards:
+  max_spread_bps: 15
+`
+
+const testTOML = `
+pair = "BTC/USD"
+dry_run = false
+
+[keys]
+public_key = "file-public"
+private_key = "file-private"
+
+[amount]
+per_buy = 25.50
+
+[schedule]
+mode = "cron"
+cron = "0 8 * * 1"
+
+[order]
+type = "limit-market"
+price_factor = 0.9985
+market_fallback_after = "2h"
+
+[guards]
+max_spread_bps = 15
+`
+
+func TestLoadConfig_File(t *testing.T) {
+	for name, content := range map[string]string{"config.yaml": testYAML, "config.toml": testTOML} {
+		t.Run(name, func(t *testing.T) {
+			clearConfigEnv(t)
+			src, err := NewSource(writeConfigFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := src.LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Pair.String() != "BTC/USD" || cfg.DryRun || cfg.PublicKey != "file-public" || cfg.KeySource != "config" {
+				t.Errorf("unexpected pair, mode or keys: %+v", cfg)
+			}
+			if cfg.FiatAmountPerBuy.Cmp(order.MustParseDecimal("25.5")) != 0 || cfg.PriceFactor.Cmp(order.MustParseDecimal("0.9985")) != 0 {
+				t.Errorf("expected amount 25.5 and price factor 0.9985, got %s and %s", cfg.FiatAmountPerBuy, cfg.PriceFactor)
+			}
+			if cfg.CronExpr != "0 8 * * 1" || cfg.OrderType != OrderTypeLimitMarket || cfg.MarketFallbackAfter != 2*time.Hour {
+				t.Errorf("unexpected schedule or order settings: %+v", cfg)
+			}
+			if cfg.MaxSpreadBps.Cmp(order.NewDecimalFromInt(15)) != 0 {
+				t.Errorf("expected max spread 15, got %s", cfg.MaxSpreadBps)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	clearConfigEnv(t)
+	src, err := NewSource(writeConfigFile(t, "config.yaml", testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "40")
+	t.Setenv("EASY_DCA_PAIR", "BTC/EUR")
+
+	cfg, err := src.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(40)) != 0 || cfg.Pair.String() != "BTC/EUR" {
+		t.Errorf("expected environment values to win, got %s %s", cfg.FiatAmountPerBuy, cfg.Pair)
+	}
+	if cfg.OrderType != OrderTypeLimitMarket {
+		t.Errorf("expected file values without an environment override to apply, got %q", cfg.OrderType)
+	}
+}
+
+// mapEnv is an environment of the given variables only.
+type mapEnv map[string]string
+
+func (e mapEnv) Getenv(key string) string { return e[key] }
+
+func (e mapEnv) Environ() []string {
+	var environ []string
+	for key, value := range e {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
+
+func TestLoadConfig_SourceWithEnv(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "40")
+	src, err := NewSourceWithEnv(writeConfigFile(t, "config.yaml", testYAML), mapEnv{"EASY_DCA_PAIR": "BTC/EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := src.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Pair.String() != "BTC/EUR" {
+		t.Errorf("expected the pair of the given environment, got %s", cfg.Pair)
+	}
+	if cfg.FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(40)) == 0 {
+		t.Error("expected the process environment not to be read")
+	}
+}
+
+func TestLoadConfig_FileValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "price factor above max",
+			content: "keys: {public_key: a, private_key: b}\namount: {per_buy: 10}\norder: {price_factor: 1.0}\n",
+			wantErr: "priceFactor must be smaller",
+		},
+		{
+			name:    "systemd mode without amount per buy",
+			content: "keys: {public_key: a, private_key: b}\namount: {monthly: 100}\nschedule: {mode: systemd}\n",
+			wantErr: "EASY_DCA_FIAT_AMOUNT_PER_BUY is required in systemd mode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			src, err := NewSource(writeConfigFile(t, "config.yaml", tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = src.LoadConfig()
+			if err == nil || !strings.Contains(strings.ToLower(err.Error()), strings.ToLower(tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewSource_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"typo", "config.yaml", "order:\n  prce_factor: 0.99\n", `line 2: unknown key "order.prce_factor"; did you mean "order.price_factor"?`},
+		{"wrong section", "config.yaml", "cron: \"0 8 * * *\"\n", `did you mean "schedule.cron"?`},
+		{"environment variable name", "config.toml", "EASY_DCA_PAIR = \"BTC/EUR\"\n", `use "pair"`},
+		{"unknown section", "config.toml", "[telemetry]\nenabled = true\n", `unknown key "telemetry"`},
+		{"section as value", "config.yaml", "order: limit\n", `"order" is a section`},
+		{"list", "config.yaml", "pair: [BTC/EUR]\n", `"pair" must be a single value`},
+		{"format", "config.json", "{}", "unsupported config file format"},
+		{"syntax", "config.toml", "pair = \n", "invalid TOML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSource(writeConfigFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+const testPlansYAML = `
+dry_run: true
+amount:
+  per_buy: 10
+plans:
+  - name: daily-eur
+    pair: BTC/EUR
+    schedule: {cron: "0 8 * * *"}
+    keys: {public_key: eur-public, private_key: eur-private}
+  - name: weekly-chf
+    pair: BTC/CHF
+    amount: {per_buy: 50}
+    schedule: {cron: "0 9 * * 1"}
+    order: {price_factor: 0.997}
+    keys: {public_key: chf-public, private_key: chf-private}
+`
+
+const testPlansTOML = `
+dry_run = true
+
+[amount]
+per_buy = 10
+
+[[plans]]
+name = "daily-eur"
+pair = "BTC/EUR"
+schedule = { cron = "0 8 * * *" }
+keys = { public_key = "eur-public", private_key = "eur-private" }
+
+[[plans]]
+name = "weekly-chf"
+pair = "BTC/CHF"
+amount = { per_buy = 50 }
+schedule = { cron = "0 9 * * 1" }
+order = { price_factor = 0.997 }
+keys = { public_key = "chf-public", private_key = "chf-private" }
+`
+
+func TestLoadPlans(t *testing.T) {
+	for name, content := range map[string]string{"config.yaml": testPlansYAML, "config.toml": testPlansTOML} {
+		t.Run(name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("EASY_DCA_LOCK_FILE", "")
+			t.Setenv("EASY_DCA_STATE_FILE", "")
+			src, err := NewSource(writeConfigFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfgs, err := src.LoadPlans()
+			if err != nil {
+				t.Fatalf("LoadPlans failed: %v", err)
+			}
+			if len(cfgs) != 2 {
+				t.Fatalf("expected 2 plans, got %d", len(cfgs))
+			}
+			daily, weekly := cfgs[0], cfgs[1]
+			if daily.Plan != "daily-eur" || daily.Pair.String() != "BTC/EUR" || daily.PublicKey != "eur-public" || daily.CronExpr != "0 8 * * *" {
+				t.Errorf("unexpected daily plan: %+v", daily)
+			}
+			if weekly.Plan != "weekly-chf" || weekly.Pair.String() != "BTC/CHF" || weekly.PublicKey != "chf-public" || weekly.PriceFactor.Cmp(order.MustParseDecimal("0.997")) != 0 {
+				t.Errorf("unexpected weekly plan: %+v", weekly)
+			}
+			// Top-level values are shared, plan values override them
+			if !daily.DryRun || !weekly.DryRun || daily.FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(10)) != 0 || weekly.FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(50)) != 0 {
+				t.Errorf("expected shared dry run and per-plan amounts, got %+v and %+v", daily, weekly)
+			}
+			if filepath.Base(daily.LockFile) != "easy-dca.daily-eur.lock" || filepath.Base(weekly.StateFile) != "easy-dca.weekly-chf.state.json" {
+				t.Errorf("expected per-plan lock and state files, got %s and %s", daily.LockFile, weekly.StateFile)
+			}
+		})
+	}
+}
+
+func TestLoadPlans_Precedence(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EASY_DCA_LOCK_FILE", "")
+	t.Setenv("EASY_DCA_STATE_FILE", "")
+	src, err := NewSource(writeConfigFile(t, "config.yaml", testPlansYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "20")
+	t.Setenv("EASY_DCA_PAIR", "BTC/USD")
+
+	cfgs, err := src.LoadPlans()
+	if err != nil {
+		t.Fatalf("LoadPlans failed: %v", err)
+	}
+	// Environment variables override top-level values, plan values override both
+	if cfgs[0].FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(20)) != 0 || cfgs[1].FiatAmountPerBuy.Cmp(order.NewDecimalFromInt(50)) != 0 {
+		t.Errorf("expected amounts 20 and 50, got %s and %s", cfgs[0].FiatAmountPerBuy, cfgs[1].FiatAmountPerBuy)
+	}
+	if cfgs[0].Pair.String() != "BTC/EUR" || cfgs[1].Pair.String() != "BTC/CHF" {
+		t.Errorf("expected plan pairs to override the environment, got %s and %s", cfgs[0].Pair, cfgs[1].Pair)
+	}
+}
+
+func TestLoadPlans_Invalid(t *testing.T) {
+	const plans = "plans:\n  - {name: a, keys: {public_key: x, private_key: y}, amount: {per_buy: 10}}\n  - {name: b, keys: {public_key: x, private_key: y}, amount: {per_buy: 10}}\n"
+	tests := []struct {
+		name    string
+		env     map[string]string
+		content string
+		wantErr string
+	}{
+		{"shared lock file", map[string]string{"EASY_DCA_LOCK_FILE": "/tmp/shared.lock"}, plans, "plans a and b use the same lock file"},
+		{"dashboard", map[string]string{"EASY_DCA_HTTP_ADDR": ":0", "EASY_DCA_DASHBOARD": "true"}, plans, "EASY_DCA_DASHBOARD is not supported with multiple plans"},
+		{"plan error", nil, "plans:\n  - {name: a, keys: {public_key: x, private_key: y}, order: {price_factor: 2}}\n", "plan a: priceFactor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("EASY_DCA_LOCK_FILE", "")
+			t.Setenv("EASY_DCA_STATE_FILE", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			src, err := NewSource(writeConfigFile(t, "config.yaml", tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = src.LoadPlans()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewSource_InvalidPlans(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"missing name", "config.yaml", "plans:\n  - pair: BTC/EUR\n", "line 2: every plan needs a name"},
+		{"duplicate name", "config.yaml", "plans:\n  - name: a\n  - name: a\n", `duplicate plan name "a"`},
+		{"invalid name", "config.toml", "[[plans]]\nname = \"Daily EUR\"\n", `plan 1: invalid plan name "Daily EUR"`},
+		{"process key", "config.yaml", "plans:\n  - name: a\n    http:\n      addr: \":9090\"\n", `"http.addr" applies to the whole process`},
+		{"not a list", "config.yaml", "plans:\n  name: a\n", `"plans" must be a list`},
+		{"unknown key", "config.toml", "[[plans]]\nname = \"a\"\nprice_factor = 0.99\n", `did you mean "order.price_factor"?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSource(writeConfigFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/secret"
+)
+
+// ConfigureLogging sets up the default slog logger based on environment variables.
+// EASY_DCA_LOG_FORMAT selects the handler and EASY_DCA_LOG_LEVEL the minimum level.
+// Output of the standard log package is routed through the same handler.
+func ConfigureLogging() {
+	envSource.ConfigureLogging()
+}
+
+// ConfigureLogging sets up the default slog logger like ConfigureLogging, also reading
+// the log section of the configuration file.
+func (s *Source) ConfigureLogging() {
+	level, err := ParseLogLevel(s.Get("EASY_DCA_LOG_LEVEL"))
+	slog.SetDefault(slog.New(NewLogHandler(os.Stderr, s.Get("EASY_DCA_LOG_FORMAT"), level)))
+	if err != nil {
+		slog.Warn("Invalid log level, using info", "error", err)
+	}
+}
+
+// ParseLogLevel parses a log level name: debug, info (default), warn or error.
+func ParseLogLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unsupported EASY_DCA_LOG_LEVEL: %s (supported: debug, info, warn, error)", value)
+	}
+}
+
+// NewLogHandler returns the slog handler for a log format:
+//   - "json": JSON lines with RFC 3339 timestamps, for log pipelines
+//   - "timestamp" or "time": text with a timestamp (2006/01/02 15:04:05)
+//   - "microseconds" or "micro": text with a microsecond timestamp (2006/01/02 15:04:05.000000)
+//   - anything else: text without a timestamp (default)
+//
+// Secrets tracked by the secret package are redacted from all formats.
+func NewLogHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	return secret.NewRedactingHandler(newLogHandler(w, format, level))
+}
+
+// newLogHandler returns the slog handler for a log format, without redaction.
+func newLogHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.NewJSONHandler(w, opts)
+	case "timestamp", "time":
+		opts.ReplaceAttr = formatTime("2006/01/02 15:04:05")
+	case "microseconds", "micro":
+		opts.ReplaceAttr = formatTime("2006/01/02 15:04:05.000000")
+	default:
+		opts.ReplaceAttr = formatTime("")
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// formatTime returns a ReplaceAttr function that formats the record time with layout, or drops it if layout is empty.
+func formatTime(layout string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) > 0 || a.Key != slog.TimeKey || a.Value.Kind() != slog.KindTime {
+			return a
+		}
+		if layout == "" {
+			return slog.Attr{}
+		}
+		return slog.String(slog.TimeKey, a.Value.Time().Format(layout))
+	}
+}
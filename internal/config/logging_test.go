@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewLogHandler_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(&buf, "json", slog.LevelInfo))
+	logger.Info("Order placed", "run_id", "abc", "txid", "OABC-123", "dry_run", false)
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if event["msg"] != "Order placed" || event["txid"] != "OABC-123" || event["run_id"] != "abc" || event["dry_run"] != false {
+		t.Errorf("unexpected event: %v", event)
+	}
+	if _, ok := event["time"]; !ok {
+		t.Error("expected a time field")
+	}
+}
+
+func TestNewLogHandler_TextFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		time   *regexp.Regexp
+	}{
+		{"", nil},
+		{"timestamp", regexp.MustCompile(`^time="\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}" `)},
+		{"micro", regexp.MustCompile(`^time="\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{6}" `)},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		slog.New(NewLogHandler(&buf, tt.format, slog.LevelInfo)).Info("hello", "pair", "BTC/EUR")
+		line := buf.String()
+		if tt.time == nil {
+			if strings.Contains(line, "time=") {
+				t.Errorf("format %q: expected no timestamp, got %q", tt.format, line)
+			}
+		} else if !tt.time.MatchString(line) {
+			t.Errorf("format %q: unexpected timestamp in %q", tt.format, line)
+		}
+		if !strings.Contains(line, "msg=hello pair=BTC/EUR") {
+			t.Errorf("format %q: unexpected line %q", tt.format, line)
+		}
+	}
+}
+
+func TestNewLogHandler_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(&buf, "", slog.LevelWarn))
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("unexpected output for warn level: %q", buf.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for value, want := range map[string]slog.Level{"": slog.LevelInfo, "DEBUG": slog.LevelDebug, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		got, err := ParseLogLevel(value)
+		if err != nil || got != want {
+			t.Errorf("ParseLogLevel(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
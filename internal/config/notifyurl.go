@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// notifyURLFormats are the supported notification URLs, following Apprise (https://github.com/caronc/apprise).
+var notifyURLFormats = map[string]string{
+	"discord": "discord://webhook_id/webhook_token",
+	"gotify":  "gotify://host[:port][/path]/token",
+	"gotifys": "gotifys://host[:port][/path]/token",
+	"json":    "json://host[:port]/path",
+	"jsons":   "jsons://host[:port]/path",
+	"ntfy":    "ntfy://topic or ntfy://host[:port]/topic",
+	"ntfys":   "ntfys://topic or ntfys://host[:port]/topic",
+	"pover":   "pover://user_key@app_token[/device...]",
+	"slack":   "slack://token_a/token_b/token_c",
+	"tgram":   "tgram://bot_token/chat_id[/chat_id...]",
+}
+
+// NotifyURL is a notification URL split into its parts. URLs are split by hand, as Telegram bot
+// tokens ("123456:ABC...") are not valid hosts for url.Parse.
+type NotifyURL struct {
+	Scheme  string   // Lower case, e.g. "gotifys"
+	User    string   // The Pushover user key
+	Host    string   // The host, or the first token for schemes without one, e.g. the Telegram bot token
+	Path    []string // Non-empty path segments, unescaped
+	ChatIDs []int64  // The Telegram chat IDs of a tgram URL
+}
+
+// ParseNotifyURL splits an Apprise-style notification URL, e.g. "gotifys://gotify.example.com/AbCdEf",
+// and checks that it has the parts its scheme needs. Errors never contain the URL, as it contains tokens.
+func ParseNotifyURL(raw string) (NotifyURL, error) {
+	u, err := splitNotifyURL(raw)
+	if err != nil {
+		return NotifyURL{}, err
+	}
+	invalid := fmt.Errorf("invalid %s URL, expected %s", u.Scheme, notifyURLFormats[u.Scheme])
+	if u.Host == "" || (u.User != "" && u.Scheme != "pover") {
+		return NotifyURL{}, invalid
+	}
+	var ok bool
+	switch u.Scheme {
+	case "discord":
+		ok = len(u.Path) == 1
+	case "gotify", "gotifys", "tgram":
+		ok = len(u.Path) > 0
+	case "json", "jsons":
+		ok = true
+	case "ntfy", "ntfys":
+		ok = len(u.Path) <= 1
+	case "pover":
+		ok = u.User != ""
+	case "slack":
+		ok = len(u.Path) == 2
+	}
+	if !ok {
+		return NotifyURL{}, invalid
+	}
+	if u.Scheme == "tgram" {
+		u.ChatIDs = make([]int64, len(u.Path))
+		for i, p := range u.Path {
+			if u.ChatIDs[i], err = strconv.ParseInt(p, 10, 64); err != nil {
+				return NotifyURL{}, errors.New("invalid tgram URL: chat IDs must be numeric")
+			}
+		}
+	}
+	return u, nil
+}
+
+// splitNotifyURL splits a notification URL into scheme, user, host and path segments.
+func splitNotifyURL(raw string) (NotifyURL, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(raw), "://")
+	if !ok {
+		return NotifyURL{}, errors.New("invalid notification URL, expected scheme://...")
+	}
+	u := NotifyURL{Scheme: strings.ToLower(scheme)}
+	if _, ok := notifyURLFormats[u.Scheme]; !ok {
+		return NotifyURL{}, fmt.Errorf("unsupported notification URL scheme %q (supported: %s)", scheme, strings.Join(slices.Sorted(maps.Keys(notifyURLFormats)), ", "))
+	}
+	if strings.Contains(rest, "?") {
+		return NotifyURL{}, fmt.Errorf("invalid %s URL: parameters are not supported", u.Scheme)
+	}
+	authority, path, _ := strings.Cut(rest, "/")
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		u.User, authority = authority[:i], authority[i+1:]
+	}
+	var err error
+	if u.User, err = url.PathUnescape(u.User); err != nil {
+		return NotifyURL{}, fmt.Errorf("invalid %s URL: bad escape", u.Scheme)
+	}
+	u.Host = authority
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if segment, err = url.PathUnescape(segment); err != nil {
+			return NotifyURL{}, fmt.Errorf("invalid %s URL: bad escape", u.Scheme)
+		}
+		u.Path = append(u.Path, segment)
+	}
+	return u, nil
+}
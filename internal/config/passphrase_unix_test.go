@@ -0,0 +1,36 @@
+//go:build unix
+
+package config
+
+import (
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/secret"
+)
+
+func TestLoadConfig_KeyPassphraseFD(t *testing.T) {
+	// A raw pipe, so no *os.File of the test owns the descriptor easy-dca reads and closes
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	syscall.Write(fds[1], []byte("correct horse battery staple\n"))
+	syscall.Close(fds[1])
+
+	t.Setenv("EASY_DCA_KEY_FILE", writeKeyFile(t, secret.KeyPair{PublicKey: "fd-public", PrivateKey: "fd-private"}, "correct horse battery staple"))
+	t.Setenv("EASY_DCA_KEY_PASSPHRASE_FD", strconv.Itoa(fds[0]))
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "10.0")
+
+	// The descriptor can be read once; later loads such as reloads use the passphrase read first
+	for range 2 {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if cfg.PrivateKey != "fd-private" {
+			t.Errorf("expected the key of the key file, got %q", cfg.PrivateKey)
+		}
+	}
+}
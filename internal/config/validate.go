@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+// decimalKeys and boolKeys are the variables that LoadConfig silently replaces with their
+// default when their value does not parse. Validate reports them instead.
+var (
+	decimalKeys = []string{
+		"EASY_DCA_PRICE_FACTOR",
+		"EASY_DCA_MONTHLY_FIAT_SPENDING",
+		"EASY_DCA_FIAT_AMOUNT_PER_BUY",
+	}
+	boolKeys = []string{
+		"EASY_DCA_DRY_RUN",
+		"EASY_DCA_AUTO_ADJUST_MIN_ORDER",
+		"EASY_DCA_DISPLAY_SATS",
+		"EASY_DCA_DASHBOARD",
+		"EASY_DCA_CHECK_PERMISSIONS",
+	}
+)
+
+// Validate checks the configuration of every plan like LoadPlans and returns all problems found,
+// prefixed with the plan name. It is stricter than LoadPlans: it also checks that the API keys are
+// well-formed base64 and reports values that LoadConfig would replace with their default.
+func (s *Source) Validate() []error {
+	if len(s.plans) == 0 {
+		_, errs := s.validate()
+		return errs
+	}
+	var errs []error
+	cfgs := make([]Config, 0, len(s.plans))
+	for _, p := range s.plans {
+		cfg, planErrs := p.validate()
+		for _, err := range planErrs {
+			errs = append(errs, fmt.Errorf("plan %s: %w", p.plan, err))
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	if len(errs) == 0 {
+		if err := validatePlans(cfgs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validate loads the configuration and returns it with every problem found.
+func (s *Source) validate() (Config, []error) {
+	cfg, err := s.LoadConfig()
+	errs := prefixErrors("", err)
+	if err := checkBase64("EASY_DCA_PUBLIC_KEY", cfg.PublicKey); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkBase64("EASY_DCA_PRIVATE_KEY", cfg.PrivateKey); err != nil {
+		errs = append(errs, err)
+	}
+	for _, key := range decimalKeys {
+		if value := s.Get(key); value != "" {
+			if _, err := order.ParseDecimal(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid decimal for %s: %q", key, value))
+			}
+		}
+	}
+	for _, key := range boolKeys {
+		if value := s.Get(key); value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid boolean for %s: %q (use true or false)", key, value))
+			}
+		}
+	}
+	if _, err := ParseLogLevel(s.Get("EASY_DCA_LOG_LEVEL")); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errs
+}
+
+// checkBase64 checks that an API key is well-formed base64, as Kraken issues them.
+// A missing key is reported by LoadConfig.
+func checkBase64(name, key string) error {
+	if key == "" {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(key); err != nil {
+		return fmt.Errorf("%s (or %s_PATH) is not valid base64: %w", name, name, err)
+	}
+	return nil
+}
+
+// prefixErrors splits an error joined by errors.Join into its errors and prefixes each with prefix.
+func prefixErrors(prefix string, err error) []error {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	prefixed := make([]error, len(errs))
+	for i, err := range errs {
+		prefixed[i] = fmt.Errorf("%s%w", prefix, err)
+	}
+	return prefixed
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_ReportsAllProblems(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EASY_DCA_PUBLIC_KEY", "cHVi")
+	t.Setenv("EASY_DCA_PRIVATE_KEY", "")
+	t.Setenv("EASY_DCA_PAIR", "BTC/XYZ")
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "10")
+	t.Setenv("EASY_DCA_PRICE_FACTOR", "0.5")
+	t.Setenv("EASY_DCA_CRON", "0 99 * * *")
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"No PRIVATE_KEY found", "unsupported trading pair: BTC/XYZ", "priceFactor must be at least 0.95", "invalid cron expression"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EASY_DCA_PUBLIC_KEY", "cHVi")
+	t.Setenv("EASY_DCA_PRIVATE_KEY", "cHJpdg==")
+	t.Setenv("EASY_DCA_FIAT_AMOUNT_PER_BUY", "10")
+
+	src, err := NewSource("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := src.Validate(); len(errs) != 0 {
+		t.Fatalf("expected a valid configuration, got %v", errs)
+	}
+
+	t.Setenv("EASY_DCA_PRIVATE_KEY", "not base64!")
+	t.Setenv("EASY_DCA_PRICE_FACTOR", "0,997")
+	t.Setenv("EASY_DCA_DRY_RUN", "flase")
+	t.Setenv("EASY_DCA_LOG_LEVEL", "verbose")
+	t.Setenv("EASY_DCA_ORDER_EXPIRE", "5")
+	errs := src.Validate()
+	want := []string{
+		"invalid duration for EASY_DCA_ORDER_EXPIRE",
+		"EASY_DCA_PRIVATE_KEY (or EASY_DCA_PRIVATE_KEY_PATH) is not valid base64",
+		`invalid decimal for EASY_DCA_PRICE_FACTOR: "0,997"`,
+		`invalid boolean for EASY_DCA_DRY_RUN: "flase"`,
+		"unsupported EASY_DCA_LOG_LEVEL: verbose",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("problem %d: expected %q, got %q", i, want[i], err)
+		}
+	}
+}
+
+func TestValidate_Plans(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EASY_DCA_STATE_FILE", "")
+	path := writeConfigFile(t, "config.yaml", `
+keys: {public_key: cHVi, private_key: cHJpdg==}
+amount: {per_buy: 10}
+plans:
+  - name: good
+  - name: bad
+    pair: BTC/XYZ
+    order: {price_factor: "1.5"}
+`)
+	src, err := NewSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := src.Validate()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 problems, got %v", errs)
+	}
+	for _, err := range errs {
+		if !strings.HasPrefix(err.Error(), "plan bad: ") {
+			t.Errorf("expected the problem to name plan bad, got %q", err)
+		}
+	}
+
+	// LoadPlans reports the same problems
+	_, err = src.LoadPlans()
+	if err == nil || strings.Count(err.Error(), "plan bad: ") != 2 {
+		t.Errorf("expected both problems of plan bad, got %v", err)
+	}
+}
+
+func TestConfig_NextRuns(t *testing.T) {
+	from := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := Config{SchedulerMode: "cron", CronExpr: "0 8 * * 1"}
+	runs := cfg.NextRuns(from, 3)
+	want := []time.Time{
+		time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC),
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("expected %d runs, got %v", len(want), runs)
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("run %d: expected %v, got %v", i, want[i], runs[i])
+		}
+	}
+
+	cfg.SchedulerMode = "systemd"
+	if runs := cfg.NextRuns(from, 3); runs != nil {
+		t.Errorf("expected no runs in systemd mode, got %v", runs)
+	}
+}
@@ -0,0 +1,179 @@
+// Package control provides the authenticated HTTP API to control a running cron scheduler:
+// trigger a run, pause and resume the schedule, skip the next run and query the status.
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+// Prefix is the path under which the control API is served.
+const Prefix = "/api/control/"
+
+// Scheduler is the scheduler controlled by the API, implemented by scheduler.CronScheduler.
+type Scheduler interface {
+	RunNow() (scheduler.RunResult, error)
+	Pause() error
+	Resume() error
+	SkipNext() error
+	Status() scheduler.Status
+}
+
+var _ Scheduler = (*scheduler.CronScheduler)(nil)
+
+// Options configures the control API.
+type Options struct {
+	Config    config.Config  // Configuration of the controlled scheduler
+	Token     string         // Bearer token required by every request
+	Scheduler Scheduler      // Scheduler to control
+	History   *history.Store // Records actions and provides the last run (nil if disabled)
+}
+
+// api serves the control endpoints.
+type api struct {
+	opts Options
+}
+
+// New returns the control API handler. Every request must carry the token in an
+// "Authorization: Bearer <token>" header.
+func New(opts Options) http.Handler {
+	a := &api{opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"status", a.status)
+	mux.HandleFunc("POST "+Prefix+"run", a.run)
+	mux.HandleFunc("POST "+Prefix+"pause", a.action("pause", opts.Scheduler.Pause))
+	mux.HandleFunc("POST "+Prefix+"resume", a.action("resume", opts.Scheduler.Resume))
+	mux.HandleFunc("POST "+Prefix+"skip-next", a.action("skip-next", opts.Scheduler.SkipNext))
+	return a.authenticate(mux)
+}
+
+// authenticate rejects requests without the bearer token.
+func (a *api) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.opts.Token)) != 1 {
+			slog.Warn("Rejected unauthenticated control API request", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="easy-dca"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// status serves the scheduler status and the last run.
+func (a *api) status(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{
+		"pair":           a.opts.Config.Pair.String(),
+		"dry_run":        a.opts.Config.DryRun,
+		"scheduler_mode": a.opts.Config.SchedulerMode,
+		"cron":           a.opts.Config.CronExpr,
+		"schedule":       a.opts.Scheduler.Status(),
+	}
+	if a.opts.History != nil {
+		records, err := a.opts.History.All()
+		if err != nil {
+			slog.Error("Failed to read run history", "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to read run history")
+			return
+		}
+		for i := len(records) - 1; i >= 0; i-- {
+			if records[i].Action == "" {
+				resp["last_run"] = records[i]
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// action returns a handler that performs an action, records it in the history and responds with the new status.
+func (a *api) action(name string, fn func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.perform(w, r, name, fn()) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"action": name, "schedule": a.opts.Scheduler.Status()})
+	}
+}
+
+// run starts a run and responds with its outcome, or with 202 Accepted if it continues in the background.
+// A run that skipped its buy responds with the skip reason.
+func (a *api) run(w http.ResponseWriter, r *http.Request) {
+	result, err := a.opts.Scheduler.RunNow()
+	if !a.perform(w, r, "run", err) {
+		return
+	}
+	resp := map[string]any{"action": "run", "outcome": result.Outcome(), "schedule": a.opts.Scheduler.Status()}
+	switch {
+	case result.Err != nil:
+		resp["message"] = result.Err.Error()
+	case result.SkipReason != "":
+		resp["message"] = result.SkipReason
+	}
+	status := http.StatusOK
+	if !result.Done {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+// perform records an action and responds with the error if it failed. It reports whether the action succeeded.
+func (a *api) perform(w http.ResponseWriter, r *http.Request, name string, err error) bool {
+	a.record(name, r, err)
+	if errors.Is(err, scheduler.ErrRunInProgress) {
+		writeError(w, http.StatusConflict, err.Error())
+		return false
+	}
+	if err != nil {
+		slog.Error("Control action failed", "action", name, "error", err)
+		writeError(w, http.StatusInternalServerError, "action failed: "+err.Error())
+		return false
+	}
+	slog.Info("Control action performed", "action", name, "remote_addr", r.RemoteAddr)
+	return true
+}
+
+// record appends an action to the history, if configured.
+func (a *api) record(name string, r *http.Request, err error) {
+	if a.opts.History == nil {
+		return
+	}
+	now := time.Now().UTC()
+	rec := history.Record{
+		Action:   name,
+		Started:  now,
+		Finished: now,
+		Pair:     a.opts.Config.Pair.String(),
+		DryRun:   a.opts.Config.DryRun,
+		Outcome:  history.OutcomeSuccess,
+		Message:  "requested via the control API from " + r.RemoteAddr,
+	}
+	if err != nil {
+		rec.Outcome = history.OutcomeError
+		rec.Message += ": " + err.Error()
+	}
+	if err := a.opts.History.Append(rec); err != nil {
+		slog.Error("Failed to record control action", "action", name, "error", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,194 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+const testToken = "0123456789abcdef"
+
+// fakeScheduler records the actions performed on it.
+type fakeScheduler struct {
+	status    scheduler.Status
+	runs      int
+	runErr    error
+	runResult scheduler.RunResult
+	actions   []string
+}
+
+func (s *fakeScheduler) RunNow() (scheduler.RunResult, error) {
+	s.actions = append(s.actions, "run")
+	if s.runErr != nil {
+		return scheduler.RunResult{}, s.runErr
+	}
+	s.runs++
+	return s.runResult, nil
+}
+
+func (s *fakeScheduler) Pause() error {
+	s.status.Paused = true
+	s.actions = append(s.actions, "pause")
+	return nil
+}
+
+func (s *fakeScheduler) Resume() error {
+	s.status.Paused = false
+	s.actions = append(s.actions, "resume")
+	return nil
+}
+
+func (s *fakeScheduler) SkipNext() error {
+	s.status.SkipNext = true
+	s.actions = append(s.actions, "skip-next")
+	return nil
+}
+
+func (s *fakeScheduler) Status() scheduler.Status {
+	return s.status
+}
+
+func newTestAPI(t *testing.T) (http.Handler, *fakeScheduler, *history.Store) {
+	t.Helper()
+	pair, err := config.NewTradingPair("BTC/EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched := &fakeScheduler{}
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	h := New(Options{
+		Config:    config.Config{Pair: pair, SchedulerMode: "cron", CronExpr: "0 8 * * *"},
+		Token:     testToken,
+		Scheduler: sched,
+		History:   store,
+	})
+	return h, sched, store
+}
+
+func do(h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthentication(t *testing.T) {
+	h, sched, _ := newTestAPI(t)
+	for _, token := range []string{"", "wrong-token-0000"} {
+		rec := do(h, http.MethodPost, Prefix+"pause", token)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("token %q: expected 401 with a challenge, got %d", token, rec.Code)
+		}
+	}
+	if len(sched.actions) != 0 {
+		t.Errorf("expected no actions without a valid token, got %v", sched.actions)
+	}
+}
+
+func TestActions(t *testing.T) {
+	h, sched, store := newTestAPI(t)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"pause", http.StatusOK},
+		{"skip-next", http.StatusOK},
+		{"resume", http.StatusOK},
+		{"run", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		rec := do(h, http.MethodPost, Prefix+tt.path, testToken)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.path, tt.want, rec.Code, rec.Body)
+		}
+	}
+	if sched.status.Paused || !sched.status.SkipNext || sched.runs != 1 {
+		t.Errorf("unexpected scheduler state after actions: %+v, %d runs", sched.status, sched.runs)
+	}
+
+	records, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0].Action != "pause" || records[3].Action != "run" || records[3].Outcome != history.OutcomeSuccess {
+		t.Errorf("expected the 4 actions to be recorded in order, got %+v", records)
+	}
+}
+
+func TestRunInProgress(t *testing.T) {
+	h, sched, store := newTestAPI(t)
+	sched.runErr = scheduler.ErrRunInProgress
+
+	if rec := do(h, http.MethodPost, Prefix+"run", testToken); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 while a run is in progress, got %d", rec.Code)
+	}
+	records, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Outcome != history.OutcomeError {
+		t.Errorf("expected the failed action to be recorded, got %+v", records)
+	}
+}
+
+func TestRunSkipped(t *testing.T) {
+	h, sched, _ := newTestAPI(t)
+	sched.runResult = scheduler.RunResult{Done: true, SkipReason: "spread too wide"}
+
+	rec := do(h, http.MethodPost, Prefix+"run", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a finished run, got %d", rec.Code)
+	}
+	var resp struct {
+		Outcome string `json:"outcome"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Outcome != "skipped" || resp.Message != "spread too wide" {
+		t.Errorf("expected the skip reason in the response, got %+v", resp)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	h, sched, store := newTestAPI(t)
+	sched.status.Paused = true
+	for _, rec := range []history.Record{
+		{RunID: "run-1", Outcome: history.OutcomeSuccess},
+		{Action: "pause", Outcome: history.OutcomeSuccess},
+	} {
+		if err := store.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := do(h, http.MethodGet, Prefix+"status", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var status struct {
+		Schedule scheduler.Status `json:"schedule"`
+		LastRun  history.Record   `json:"last_run"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Schedule.Paused || status.LastRun.RunID != "run-1" {
+		t.Errorf("expected a paused schedule and run-1 as the last run, got %+v", status)
+	}
+
+	if rec := do(h, http.MethodGet, Prefix+"pause", testToken); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected actions to require POST, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,239 @@
+// Package dashboard serves the read-only web dashboard: the configuration summary, the schedule,
+// recent runs, the cost basis and open orders. Static assets are embedded in the binary.
+package dashboard
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// recentRuns is the default number of runs listed by /api/runs.
+const recentRuns = 30
+
+// nextRuns is the number of upcoming runs listed by /api/schedule.
+const nextRuns = 5
+
+// openOrdersTTL limits how often open orders are fetched from Kraken, whose private API is rate limited.
+const openOrdersTTL = time.Minute
+
+// Schedule is implemented by schedulers that know their upcoming runs.
+type Schedule interface {
+	NextRuns(n int) []time.Time
+}
+
+// statusReporter is implemented by schedulers that can be paused via the control API.
+type statusReporter interface {
+	Status() scheduler.Status
+}
+
+// Options configures the dashboard.
+type Options struct {
+	Config     config.Config                      // Configuration shown on the dashboard
+	History    *history.Store                     // Run history (nil if disabled)
+	Schedule   Schedule                           // Upcoming runs (nil if runs are not scheduled by easy-dca)
+	OpenOrders func() ([]kraken.OrderInfo, error) // Fetches the open orders of the account (nil disables)
+}
+
+// dashboard serves the dashboard pages and their data.
+type dashboard struct {
+	opts Options
+
+	mu         sync.Mutex // Guards the open orders cache
+	orders     []kraken.OrderInfo
+	ordersErr  error
+	ordersTime time.Time
+}
+
+// New returns the dashboard handler. It only answers GET and HEAD requests, so it cannot
+// change anything; other methods are rejected with 405 Method Not Allowed.
+func New(opts Options) http.Handler {
+	d := &dashboard{opts: opts}
+	assets, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", http.FileServerFS(assets))
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(assets)))
+	mux.HandleFunc("GET /api/config", d.config)
+	mux.HandleFunc("GET /api/schedule", d.schedule)
+	mux.HandleFunc("GET /api/runs", d.runs)
+	mux.HandleFunc("GET /api/cost-basis", d.costBasis)
+	mux.HandleFunc("GET /api/orders", d.openOrders)
+	return mux
+}
+
+// BasicAuth requires HTTP basic authentication with the given credentials before calling next.
+func BasicAuth(next http.Handler, user, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		// Compare both values in constant time, so neither leaks through response timing
+		userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		passwordOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		if !ok || !userOK || !passwordOK {
+			w.Header().Set("WWW-Authenticate", `Basic realm="easy-dca", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// config serves the configuration summary that is logged at startup.
+func (d *dashboard) config(w http.ResponseWriter, r *http.Request) {
+	cfg := d.opts.Config
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pair":           cfg.Pair.String(),
+		"fiat_currency":  cfg.Pair.GetFiatCurrency(),
+		"btc_unit":       cfg.GetBTCUnit(),
+		"display_sats":   cfg.DisplaySats,
+		"dry_run":        cfg.DryRun,
+		"scheduler_mode": cfg.SchedulerMode,
+		"cron":           cfg.CronExpr,
+		"summary":        config.Summary(cfg),
+	})
+}
+
+// schedule serves the upcoming runs and whether they are paused or skipped.
+func (d *dashboard) schedule(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"next_runs": []time.Time{}, "paused": false, "skip_next": false}
+	if d.opts.Schedule != nil {
+		resp["next_runs"] = append([]time.Time{}, d.opts.Schedule.NextRuns(nextRuns)...)
+	}
+	if s, ok := d.opts.Schedule.(statusReporter); ok {
+		status := s.Status()
+		resp["paused"], resp["skip_next"] = status.Paused, status.SkipNext
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runs serves the most recent runs, newest first. The limit query parameter overrides the default count.
+func (d *dashboard) runs(w http.ResponseWriter, r *http.Request) {
+	limit := recentRuns
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	if d.opts.History == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"enabled": false, "runs": []history.Record{}})
+		return
+	}
+	records, err := d.opts.History.Recent(limit)
+	if err != nil {
+		slog.Error("Failed to read run history", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to read run history")
+		return
+	}
+	if records == nil {
+		records = []history.Record{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"enabled": true, "runs": records})
+}
+
+// costBasis serves the running cost basis of the filled orders of the configured pair.
+func (d *dashboard) costBasis(w http.ResponseWriter, r *http.Request) {
+	if d.opts.History == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"enabled": false, "points": []history.CostBasisPoint{}})
+		return
+	}
+	fills, err := d.opts.History.Fills()
+	if err != nil {
+		slog.Error("Failed to read run history", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to read run history")
+		return
+	}
+	points := history.CostBasis(fills, d.opts.Config.Pair.String())
+	if points == nil {
+		points = []history.CostBasisPoint{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"enabled": true, "points": points})
+}
+
+// openOrder is an open order as served by /api/orders.
+type openOrder struct {
+	Txid        string    `json:"txid"`
+	Opened      time.Time `json:"opened"`
+	Side        string    `json:"side"`
+	Type        string    `json:"type"`
+	Price       string    `json:"price"`
+	Volume      string    `json:"volume"`
+	Executed    string    `json:"executed"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+}
+
+// openOrders serves the open orders of the configured pair.
+func (d *dashboard) openOrders(w http.ResponseWriter, r *http.Request) {
+	if d.opts.OpenOrders == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"enabled": false, "orders": []openOrder{}})
+		return
+	}
+	infos, fetched, err := d.cachedOpenOrders()
+	if err != nil {
+		slog.Warn("Failed to fetch open orders for the dashboard", "error", err, "error_class", kraken.ErrorClass(err))
+		writeError(w, http.StatusBadGateway, "failed to fetch open orders from Kraken: "+kraken.ErrorClass(err))
+		return
+	}
+	orders := []openOrder{}
+	pair := d.opts.Config.Pair.KrakenName()
+	for _, info := range infos {
+		if info.Descr.Pair != pair {
+			continue
+		}
+		sec := int64(info.OpenTm)
+		orders = append(orders, openOrder{
+			Txid:        info.Txid,
+			Opened:      time.Unix(sec, int64((info.OpenTm-float64(sec))*1e9)).UTC(),
+			Side:        info.Descr.Type,
+			Type:        info.Descr.OrderType,
+			Price:       info.Descr.Price,
+			Volume:      info.Vol,
+			Executed:    info.VolExec,
+			Status:      info.Status,
+			Description: info.Descr.Order,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"enabled": true, "orders": orders, "fetched": fetched})
+}
+
+// cachedOpenOrders returns the open orders, fetching them at most once per openOrdersTTL.
+func (d *dashboard) cachedOpenOrders() ([]kraken.OrderInfo, time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ordersTime.IsZero() || time.Since(d.ordersTime) >= openOrdersTTL {
+		d.orders, d.ordersErr = d.opts.OpenOrders()
+		d.ordersTime = time.Now().UTC()
+	}
+	return d.orders, d.ordersTime, d.ordersErr
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
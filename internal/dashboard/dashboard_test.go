@@ -0,0 +1,233 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+func testConfig(t *testing.T) config.Config {
+	t.Helper()
+	pair, err := config.NewTradingPair("BTC/EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Config{
+		PublicKey:        "public-key-value",
+		PrivateKey:       "private-key-value",
+		Pair:             pair,
+		DryRun:           true,
+		PriceFactor:      order.MustParseDecimal("0.998"),
+		FiatAmountPerBuy: order.MustParseDecimal("10"),
+		SchedulerMode:    "cron",
+		CronExpr:         "0 8 * * *",
+	}
+}
+
+func get(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+}
+
+func TestDashboardServesAssets(t *testing.T) {
+	h := New(Options{Config: testConfig(t)})
+	for _, path := range []string{"/", "/static/app.js", "/static/style.css"} {
+		rec := get(t, h, http.MethodGet, path)
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s: expected 200 with a body, got %d", path, rec.Code)
+		}
+	}
+	if rec := get(t, h, http.MethodGet, "/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown path, got %d", rec.Code)
+	}
+}
+
+func TestDashboardIsReadOnly(t *testing.T) {
+	h := New(Options{Config: testConfig(t)})
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		if rec := get(t, h, method, "/api/runs"); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", method, rec.Code)
+		}
+	}
+}
+
+func TestDashboardConfigHidesKeys(t *testing.T) {
+	h := New(Options{Config: testConfig(t)})
+	rec := get(t, h, http.MethodGet, "/api/config")
+	body := rec.Body.String()
+	if strings.Contains(body, "public-key-value") || strings.Contains(body, "private-key-value") {
+		t.Fatalf("config summary leaks API keys: %s", body)
+	}
+
+	var cfg struct {
+		Pair    string `json:"pair"`
+		DryRun  bool   `json:"dry_run"`
+		Summary []struct {
+			Message string                `json:"message"`
+			Fields  []config.SummaryField `json:"fields"`
+		} `json:"summary"`
+	}
+	decode(t, rec, &cfg)
+	if cfg.Pair != "BTC/EUR" || !cfg.DryRun || len(cfg.Summary) == 0 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Summary[0].Message == "" || len(cfg.Summary[0].Fields) == 0 {
+		t.Errorf("expected summary lines with fields, got %+v", cfg.Summary)
+	}
+}
+
+func TestDashboardRunsAndCostBasis(t *testing.T) {
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i, price := range []string{"40000", "60000"} {
+		err := store.Append(history.Record{
+			RunID:    "run",
+			Started:  start.AddDate(0, 0, i),
+			Finished: start.AddDate(0, 0, i),
+			Pair:     "BTC/EUR",
+			Outcome:  history.OutcomeSuccess,
+			Order:    &history.Order{Type: "post-only", Txid: price, Price: order.MustParseDecimal(price), Volume: order.MustParseDecimal("0.001")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only the fills count towards the cost basis: the 60000 order filled half, the 40000 order in full
+	for _, fill := range []history.Fill{
+		{Pair: "BTC/EUR", Txid: "40000", Volume: order.MustParseDecimal("0.001"), Cost: order.MustParseDecimal("40"), Price: order.MustParseDecimal("40000")},
+		{Pair: "BTC/EUR", Txid: "60000", Volume: order.MustParseDecimal("0.0005"), Cost: order.MustParseDecimal("30"), Price: order.MustParseDecimal("60000")},
+	} {
+		if err := store.Append(history.Record{Fill: &fill}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Append(history.Record{Started: start.AddDate(0, 0, 2), Pair: "BTC/EUR", Outcome: history.OutcomeSkipped, Message: "price cap"}); err != nil {
+		t.Fatal(err)
+	}
+	h := New(Options{Config: testConfig(t), History: store})
+
+	var runs struct {
+		Runs []history.Record `json:"runs"`
+	}
+	decode(t, get(t, h, http.MethodGet, "/api/runs?limit=2"), &runs)
+	if len(runs.Runs) != 2 || runs.Runs[0].Message != "price cap" {
+		t.Errorf("expected the 2 latest runs, newest first, got %+v", runs.Runs)
+	}
+	if rec := get(t, h, http.MethodGet, "/api/runs?limit=abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", rec.Code)
+	}
+
+	var basis struct {
+		Points []history.CostBasisPoint `json:"points"`
+	}
+	decode(t, get(t, h, http.MethodGet, "/api/cost-basis"), &basis)
+	if len(basis.Points) != 2 || basis.Points[1].TotalFiat != order.MustParseDecimal("70") || basis.Points[1].TotalVolume != order.MustParseDecimal("0.0015") {
+		t.Errorf("expected 70 EUR for 0.0015 BTC after 2 fills, got %+v", basis.Points)
+	}
+}
+
+func TestDashboardWithoutHistory(t *testing.T) {
+	h := New(Options{Config: testConfig(t)})
+	var runs struct {
+		Enabled bool             `json:"enabled"`
+		Runs    []history.Record `json:"runs"`
+	}
+	decode(t, get(t, h, http.MethodGet, "/api/runs"), &runs)
+	if runs.Enabled || runs.Runs == nil {
+		t.Errorf("expected history to be reported as disabled with an empty list, got %+v", runs)
+	}
+}
+
+func TestDashboardSchedule(t *testing.T) {
+	next := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+	h := New(Options{Config: testConfig(t), Schedule: fakeSchedule{next}})
+	var schedule struct {
+		NextRuns []time.Time `json:"next_runs"`
+	}
+	decode(t, get(t, h, http.MethodGet, "/api/schedule"), &schedule)
+	if len(schedule.NextRuns) != 1 || !schedule.NextRuns[0].Equal(next) {
+		t.Errorf("unexpected next runs: %v", schedule.NextRuns)
+	}
+}
+
+type fakeSchedule []time.Time
+
+func (s fakeSchedule) NextRuns(n int) []time.Time { return s }
+
+func TestDashboardOpenOrders(t *testing.T) {
+	calls := 0
+	fetch := func() ([]kraken.OrderInfo, error) {
+		calls++
+		eur := kraken.OrderInfo{Txid: "O-EUR", Status: "open", OpenTm: 1735718400.5, Vol: "0.001", VolExec: "0"}
+		eur.Descr.Pair = "XBTEUR"
+		eur.Descr.Order = "buy 0.001 XBTEUR @ limit 60000"
+		usd := kraken.OrderInfo{Txid: "O-USD", Status: "open"}
+		usd.Descr.Pair = "XBTUSD"
+		return []kraken.OrderInfo{eur, usd}, nil
+	}
+	h := New(Options{Config: testConfig(t), OpenOrders: fetch})
+
+	var orders struct {
+		Orders []openOrder `json:"orders"`
+	}
+	decode(t, get(t, h, http.MethodGet, "/api/orders"), &orders)
+	if len(orders.Orders) != 1 || orders.Orders[0].Txid != "O-EUR" {
+		t.Fatalf("expected only the BTC/EUR order, got %+v", orders.Orders)
+	}
+	if want := time.Unix(1735718400, 5e8).UTC(); !orders.Orders[0].Opened.Equal(want) {
+		t.Errorf("expected opened %v, got %v", want, orders.Orders[0].Opened)
+	}
+
+	get(t, h, http.MethodGet, "/api/orders")
+	if calls != 1 {
+		t.Errorf("expected open orders to be cached, fetched %d times", calls)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	h := BasicAuth(New(Options{Config: testConfig(t)}), "family", "secret")
+
+	if rec := get(t, h, http.MethodGet, "/"); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected 401 with a challenge without credentials, got %d", rec.Code)
+	}
+
+	for _, tc := range []struct {
+		user, password string
+		want           int
+	}{
+		{"family", "wrong", http.StatusUnauthorized},
+		{"other", "secret", http.StatusUnauthorized},
+		{"family", "secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+		req.SetBasicAuth(tc.user, tc.password)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			body, _ := io.ReadAll(rec.Body)
+			t.Errorf("%s/%s: expected %d, got %d: %s", tc.user, tc.password, tc.want, rec.Code, body)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// easy-dca dashboard: fetches the read-only JSON endpoints and renders them.
+// All values are inserted as text, never as HTML.
+"use strict";
+
+const REFRESH_MS = 60 * 1000;
+let settings = { fiat: "", sats: false };
+
+function el(tag, props, ...children) {
+  const node = document.createElement(tag);
+  Object.assign(node, props || {});
+  for (const child of children) {
+    node.append(child instanceof Node ? child : String(child));
+  }
+  return node;
+}
+
+function svg(tag, attrs, text) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    node.setAttribute(key, value);
+  }
+  if (text !== undefined) {
+    node.textContent = text;
+  }
+  return node;
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: { Accept: "application/json" } });
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    throw new Error(body.error || resp.status + " " + resp.statusText);
+  }
+  return body;
+}
+
+function fmtTime(value) {
+  return new Date(value).toLocaleString();
+}
+
+function fmtFiat(value) {
+  return Number(value).toLocaleString(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 }) + " " + settings.fiat;
+}
+
+function fmtBTC(value) {
+  if (settings.sats) {
+    return Math.round(Number(value) * 1e8).toLocaleString() + " sats";
+  }
+  return Number(value).toFixed(8) + " BTC";
+}
+
+function emptyRow(table, columns, text) {
+  table.replaceChildren(el("tr", {}, el("td", { colSpan: columns, className: "empty" }, text)));
+}
+
+async function loadConfig() {
+  const cfg = await getJSON("api/config");
+  settings = { fiat: cfg.fiat_currency, sats: cfg.display_sats };
+  document.getElementById("pair").textContent = cfg.pair;
+  const mode = document.getElementById("mode");
+  mode.textContent = cfg.dry_run ? "DRY RUN" : "LIVE";
+  mode.className = "badge " + (cfg.dry_run ? "dry" : "live");
+
+  const list = document.getElementById("config");
+  list.replaceChildren();
+  for (const line of cfg.summary) {
+    list.append(el("dt", {}, line.message));
+    const fields = (line.fields || []).map((f) => f.key + ": " + f.value).join(", ");
+    if (fields) {
+      list.append(el("dd", {}, fields));
+    }
+  }
+}
+
+async function loadSchedule() {
+  const data = await getJSON("api/schedule");
+  const list = document.getElementById("next-runs");
+  if (data.next_runs.length === 0) {
+    list.replaceChildren(el("li", { className: "muted" }, "Runs are not scheduled by easy-dca (manual or systemd mode)."));
+    return;
+  }
+  list.replaceChildren(...data.next_runs.map((t, i) => {
+    let note = "";
+    if (data.paused) {
+      note = " (paused)";
+    } else if (i === 0 && data.skip_next) {
+      note = " (will be skipped)";
+    }
+    return el("li", { className: note ? "muted" : "" }, fmtTime(t) + note);
+  }));
+}
+
+async function loadRuns() {
+  const data = await getJSON("api/runs");
+  const table = document.getElementById("runs");
+  if (!data.enabled) {
+    emptyRow(table, 4, "Run history is disabled (EASY_DCA_HISTORY_FILE).");
+    return;
+  }
+  if (data.runs.length === 0) {
+    emptyRow(table, 4, "No runs yet.");
+    return;
+  }
+  table.replaceChildren(...data.runs.map((run) => {
+    if (run.action) {
+      return el("tr", {},
+        el("td", {}, fmtTime(run.started)),
+        el("td", { className: "outcome-action" }, run.action),
+        el("td", {}, ""),
+        el("td", { className: run.outcome === "error" ? "error" : "muted" }, run.message || ""));
+    }
+    let orderText = "";
+    if (run.order) {
+      orderText = fmtBTC(run.order.volume) + " @ " + fmtFiat(run.order.price) + " (" + run.order.type + (run.dry_run ? ", dry run" : "") + ")";
+    }
+    return el("tr", {},
+      el("td", {}, fmtTime(run.started)),
+      el("td", { className: "outcome-" + run.outcome }, run.outcome),
+      el("td", {}, orderText),
+      el("td", {}, run.message || (run.order && run.order.txid ? el("span", { className: "mono" }, run.order.txid) : "")));
+  }));
+}
+
+async function loadCostBasis() {
+  const data = await getJSON("api/cost-basis");
+  const chart = document.getElementById("chart");
+  const totals = document.getElementById("totals");
+  if (!data.enabled || data.points.length === 0) {
+    chart.replaceChildren(el("p", { className: "muted" },
+      data.enabled ? "No filled orders yet." : "Run history is disabled (EASY_DCA_HISTORY_FILE)."));
+    totals.replaceChildren();
+    return;
+  }
+  const last = data.points[data.points.length - 1];
+  const card = (label, value) => el("div", { className: "card" }, el("div", { className: "label" }, label), el("div", { className: "value" }, value));
+  totals.replaceChildren(
+    card("Invested", fmtFiat(last.total_fiat)),
+    card("Bought", fmtBTC(last.total_volume)),
+    card("Average price", fmtFiat(last.average_price)),
+    card("Orders", data.points.length));
+  chart.replaceChildren(drawChart(data.points));
+}
+
+// drawChart plots the average price paid over time as a line and each order price as a dot.
+function drawChart(points) {
+  const width = 900, height = 300, pad = { top: 20, right: 20, bottom: 30, left: 80 };
+  const times = points.map((p) => new Date(p.time).getTime());
+  const values = points.flatMap((p) => [Number(p.price), Number(p.average_price)]);
+  let minT = Math.min(...times), maxT = Math.max(...times);
+  let minV = Math.min(...values), maxV = Math.max(...values);
+  if (minT === maxT) { minT -= 86400000; maxT += 86400000; }
+  if (minV === maxV) { minV *= 0.95; maxV *= 1.05; }
+  const margin = (maxV - minV) * 0.05;
+  minV -= margin;
+  maxV += margin;
+
+  const x = (t) => pad.left + (t - minT) / (maxT - minT) * (width - pad.left - pad.right);
+  const y = (v) => height - pad.bottom - (v - minV) / (maxV - minV) * (height - pad.top - pad.bottom);
+
+  const root = svg("svg", { viewBox: "0 0 " + width + " " + height, role: "img", "aria-label": "Cost basis chart" });
+  root.append(svg("line", { class: "axis", x1: pad.left, y1: height - pad.bottom, x2: width - pad.right, y2: height - pad.bottom }));
+  root.append(svg("line", { class: "axis", x1: pad.left, y1: pad.top, x2: pad.left, y2: height - pad.bottom }));
+  for (let i = 0; i <= 4; i++) {
+    const v = minV + (maxV - minV) * i / 4;
+    root.append(svg("text", { class: "tick", x: pad.left - 6, y: y(v) + 4, "text-anchor": "end" }, Math.round(v).toLocaleString()));
+  }
+  root.append(svg("text", { class: "tick", x: pad.left, y: height - 8 }, new Date(minT).toLocaleDateString()));
+  root.append(svg("text", { class: "tick", x: width - pad.right, y: height - 8, "text-anchor": "end" }, new Date(maxT).toLocaleDateString()));
+
+  const line = points.map((p, i) => (i === 0 ? "M" : "L") + x(times[i]).toFixed(1) + "," + y(Number(p.average_price)).toFixed(1)).join(" ");
+  root.append(svg("path", { class: "average", d: line }));
+  points.forEach((p, i) => {
+    const dot = svg("circle", { class: "order", cx: x(times[i]).toFixed(1), cy: y(Number(p.price)).toFixed(1), r: 3 });
+    dot.append(svg("title", {}, fmtTime(p.time) + ": " + fmtFiat(p.price)));
+    root.append(dot);
+  });
+  root.append(svg("text", { class: "legend", x: pad.left + 8, y: pad.top }, "— average price   ● order price (" + settings.fiat + ")"));
+  return root;
+}
+
+async function loadOrders() {
+  const table = document.getElementById("orders");
+  let data;
+  try {
+    data = await getJSON("api/orders");
+  } catch (err) {
+    emptyRow(table, 5, "Could not load open orders: " + err.message);
+    return;
+  }
+  if (!data.enabled) {
+    emptyRow(table, 5, "Open orders are not available.");
+    return;
+  }
+  if (data.orders.length === 0) {
+    emptyRow(table, 5, "No open orders.");
+    return;
+  }
+  table.replaceChildren(...data.orders.map((o) => el("tr", {},
+    el("td", {}, fmtTime(o.opened)),
+    el("td", {}, o.description),
+    el("td", {}, o.executed + " / " + o.volume),
+    el("td", {}, o.status),
+    el("td", { className: "mono" }, o.txid))));
+}
+
+async function refresh() {
+  const results = await Promise.allSettled([
+    loadConfig().then(() => Promise.all([loadRuns(), loadCostBasis()])),
+    loadSchedule(),
+    loadOrders(),
+  ]);
+  const failed = results.filter((r) => r.status === "rejected");
+  const updated = document.getElementById("updated");
+  updated.className = failed.length ? "error" : "";
+  updated.textContent = failed.length
+    ? "Update failed: " + failed[0].reason.message
+    : "Last updated " + new Date().toLocaleTimeString() + ".";
+}
+
+refresh();
+setInterval(refresh, REFRESH_MS);
@@ -0,0 +1,152 @@
+package dca
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/order"
+	"github.com/mayrf/easy-dca/internal/state"
+)
+
+// getOHLC fetches OHLC candles; replaced in tests.
+var getOHLC = kraken.GetOHLC
+
+// checkMarketConditions runs the configured market-condition guards against the order book.
+// Returns a non-empty reason if the buy should be skipped, or an error if a guard could not be evaluated.
+func (r *Runner) checkMarketConditions(book order.OrderBook) (string, error) {
+	if r.cfg.MaxSpreadBps.Sign() > 0 {
+		spread, err := book.SpreadBps()
+		if err != nil {
+			return "", fmt.Errorf("failed to compute spread: %w", err)
+		}
+		if spread.GreaterThan(r.cfg.MaxSpreadBps) {
+			return fmt.Sprintf("spread of %s bps is wider than the maximum of %s bps", spread.StringFixed(2), r.cfg.MaxSpreadBps), nil
+		}
+	}
+
+	if r.cfg.MaxPrice.Sign() > 0 {
+		ask, err := book.Best(order.Asks)
+		if err != nil {
+			return "", err
+		}
+		if ask.Price.GreaterThan(r.cfg.MaxPrice) {
+			return fmt.Sprintf("ask of %s %s is above the price cap of %s %s",
+				ask.Price.StringFixed(2), r.cfg.Pair.GetFiatCurrency(), r.cfg.MaxPrice.StringFixed(2), r.cfg.Pair.GetFiatCurrency()), nil
+		}
+	}
+
+	if r.cfg.MaxPriceChangePct.Sign() > 0 {
+		since := time.Now().Add(-r.cfg.PriceChangeWindow)
+		var candles []kraken.Candle
+		err := r.withRetry("fetch OHLC", func() error {
+			var err error
+			// Kraken returns candles after since; step back one candle to include the one in progress at since
+			candles, err = getOHLC(r.cfg.Pair.String(), 1, since.Add(-time.Minute).Unix())
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch price history: %w", err)
+		}
+		change, low, high, ok := priceChangePct(candles, since)
+		if !ok {
+			return "", fmt.Errorf("no price history for the last %s", r.cfg.PriceChangeWindow)
+		}
+		r.log.Info("Price range", "window", r.cfg.PriceChangeWindow.String(), "low", low, "high", high, "change_pct", change.StringFixed(2))
+		if change.GreaterThan(r.cfg.MaxPriceChangePct) {
+			return fmt.Sprintf("price moved %s%% within %s (%s - %s), more than the maximum of %s%%",
+				change.StringFixed(2), r.cfg.PriceChangeWindow, low.StringFixed(2), high.StringFixed(2), r.cfg.MaxPriceChangePct), nil
+		}
+	}
+
+	return "", nil
+}
+
+// priceChangePct returns the high-low range of the candles overlapping [since, now) as a percentage of the low.
+// ok is false if no candle overlaps the window.
+func priceChangePct(candles []kraken.Candle, since time.Time) (change, low, high order.Decimal, ok bool) {
+	for _, c := range candles {
+		// A 1-minute candle overlaps the window if it ends after since
+		if c.Time+60 <= since.Unix() {
+			continue
+		}
+		if !ok || c.Low.LessThan(low) {
+			low = c.Low
+		}
+		if !ok || c.High.GreaterThan(high) {
+			high = c.High
+		}
+		ok = true
+	}
+	if !ok || low.Sign() <= 0 {
+		return order.Zero, low, high, false
+	}
+	change = high.Sub(low).Div(low, order.DecimalPlaces, order.RoundHalfEven).Mul(order.NewDecimalFromInt(100))
+	return change, low, high, true
+}
+
+// fiatForBuy returns the fiat amount to spend on this buy, including fiat carried forward from skipped buys.
+func (r *Runner) fiatForBuy() (order.Decimal, error) {
+	fiat := r.cfg.FiatPerBuy()
+	if r.cfg.SkippedBudget != config.SkippedBudgetCarry || r.state == nil {
+		return fiat, nil
+	}
+	st, err := r.state.Load()
+	if err != nil {
+		return order.Zero, fmt.Errorf("failed to load state: %w", err)
+	}
+	if st.CarriedFiat.Sign() > 0 {
+		r.log.Info("Adding fiat carried forward from skipped buys", "carried_amount", st.CarriedFiat.StringFixed(2), "currency", r.cfg.Pair.GetFiatCurrency(), "skipped_buys", st.SkippedBuys)
+		fiat = fiat.Add(st.CarriedFiat)
+	}
+	return fiat, nil
+}
+
+// skipBuy skips this buy for the given reason, applies the skipped budget policy and sends a notification.
+func (r *Runner) skipBuy(reason string) error {
+	r.skipReason = reason
+	fiat := r.cfg.FiatPerBuy()
+	currency := r.cfg.Pair.GetFiatCurrency()
+
+	budget := fmt.Sprintf("%s %s forfeited", fiat.StringFixed(2), currency)
+	if r.cfg.SkippedBudget == config.SkippedBudgetCarry && r.state != nil {
+		if r.cfg.DryRun {
+			budget = fmt.Sprintf("%s %s would be carried forward (dry run, state not changed)", fiat.StringFixed(2), currency)
+		} else {
+			var carried order.Decimal
+			err := r.state.Update(func(st *state.State) error {
+				st.CarriedFiat = st.CarriedFiat.Add(fiat)
+				st.SkippedBuys++
+				carried = st.CarriedFiat
+				return nil
+			})
+			if err != nil {
+				r.log.Error("Failed to carry forward skipped fiat", "reason", reason, "error", err)
+				r.notify("DCA Error", fmt.Sprintf("Buy skipped (%s), but failed to carry forward %s %s: %v", reason, fiat.StringFixed(2), currency, err))
+				return fmt.Errorf("failed to carry forward skipped fiat: %w", err)
+			}
+			budget = fmt.Sprintf("%s %s carried forward (%s %s total)", fiat.StringFixed(2), currency, carried.StringFixed(2), currency)
+		}
+	}
+	r.log.Warn("Skipping buy", "reason", reason, "budget", budget)
+	r.notify("DCA Skipped", fmt.Sprintf("Buy skipped: %s | %s", reason, budget))
+	return nil
+}
+
+// clearCarriedFiat resets the carried-forward fiat after it was spent by a live order.
+func (r *Runner) clearCarriedFiat() {
+	if r.cfg.SkippedBudget != config.SkippedBudgetCarry || r.state == nil || r.cfg.DryRun {
+		return
+	}
+	err := r.state.Update(func(st *state.State) error {
+		st.CarriedFiat = order.Zero
+		st.SkippedBuys = 0
+		return nil
+	})
+	if err != nil {
+		// The order is placed; a stale carry would only overspend on the next buy, so make it visible
+		r.log.Error("Failed to reset carried-forward fiat", "error", err)
+		r.notify("DCA Alert", fmt.Sprintf("Order placed, but failed to reset carried-forward fiat in %s: %v", r.cfg.StateFile, err))
+	}
+}
@@ -0,0 +1,175 @@
+package dca
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/order"
+	"github.com/mayrf/easy-dca/internal/state"
+)
+
+type recordingNotifier struct {
+	subjects []string
+	messages []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, subject, message string) error {
+	n.subjects = append(n.subjects, subject)
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func guardTestRunner(t *testing.T, cfg config.Config) (*Runner, *recordingNotifier) {
+	t.Helper()
+	pair, err := config.NewTradingPair("BTC/EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Pair = pair
+	cfg.FiatAmountPerBuy = order.MustParseDecimal("10")
+	if cfg.SkippedBudget == "" {
+		cfg.SkippedBudget = config.SkippedBudgetForfeit
+	}
+	if cfg.PriceChangeWindow == 0 {
+		cfg.PriceChangeWindow = time.Hour
+	}
+	n := &recordingNotifier{}
+	return NewRunner(cfg, n), n
+}
+
+func guardTestBook() order.OrderBook {
+	return order.OrderBook{
+		Asks: []order.Order{{Price: order.MustParseDecimal("60010"), Volume: order.MustParseDecimal("1")}},
+		Bids: []order.Order{{Price: order.MustParseDecimal("59990"), Volume: order.MustParseDecimal("1")}},
+	}
+}
+
+func TestCheckMarketConditions_Spread(t *testing.T) {
+	// The spread of the test book is 20 / 60000 = 3.33 bps
+	r, _ := guardTestRunner(t, config.Config{MaxSpreadBps: order.MustParseDecimal("5")})
+	if reason, err := r.checkMarketConditions(guardTestBook()); err != nil || reason != "" {
+		t.Errorf("expected no skip, got %q, %v", reason, err)
+	}
+	r, _ = guardTestRunner(t, config.Config{MaxSpreadBps: order.MustParseDecimal("3")})
+	reason, err := r.checkMarketConditions(guardTestBook())
+	if err != nil || !strings.Contains(reason, "spread of 3.33 bps") {
+		t.Errorf("expected a spread skip, got %q, %v", reason, err)
+	}
+}
+
+func TestCheckMarketConditions_MaxPrice(t *testing.T) {
+	r, _ := guardTestRunner(t, config.Config{MaxPrice: order.MustParseDecimal("60000")})
+	reason, err := r.checkMarketConditions(guardTestBook())
+	if err != nil || !strings.Contains(reason, "above the price cap of 60000.00 EUR") {
+		t.Errorf("expected a price cap skip, got %q, %v", reason, err)
+	}
+}
+
+func TestCheckMarketConditions_PriceChange(t *testing.T) {
+	now := time.Now().Unix() / 60 * 60
+	defer func(orig func(string, int, int64) ([]kraken.Candle, error)) { getOHLC = orig }(getOHLC)
+	getOHLC = func(pair string, interval int, since int64) ([]kraken.Candle, error) {
+		return []kraken.Candle{
+			// Outside of the window
+			{Time: now - 7200, Low: order.MustParseDecimal("50000"), High: order.MustParseDecimal("50000")},
+			{Time: now - 1800, Low: order.MustParseDecimal("60000"), High: order.MustParseDecimal("61000")},
+			{Time: now, Low: order.MustParseDecimal("61500"), High: order.MustParseDecimal("62400")},
+		}, nil
+	}
+
+	r, _ := guardTestRunner(t, config.Config{MaxPriceChangePct: order.MustParseDecimal("5")})
+	if reason, err := r.checkMarketConditions(guardTestBook()); err != nil || reason != "" {
+		t.Errorf("expected no skip for a 4%% move, got %q, %v", reason, err)
+	}
+	r, _ = guardTestRunner(t, config.Config{MaxPriceChangePct: order.MustParseDecimal("3.5")})
+	reason, err := r.checkMarketConditions(guardTestBook())
+	if err != nil || !strings.Contains(reason, "price moved 4.00%") {
+		t.Errorf("expected a price change skip, got %q, %v", reason, err)
+	}
+}
+
+func TestPriceChangePct_NoCandles(t *testing.T) {
+	if _, _, _, ok := priceChangePct(nil, time.Now()); ok {
+		t.Error("expected ok to be false without candles")
+	}
+}
+
+func TestSkipBuy_Forfeit(t *testing.T) {
+	r, n := guardTestRunner(t, config.Config{})
+	if err := r.skipBuy("spread too wide"); err != nil {
+		t.Fatalf("skipBuy returned error: %v", err)
+	}
+	if len(n.subjects) != 1 || n.subjects[0] != "DCA Skipped" {
+		t.Fatalf("expected a DCA Skipped notification, got %v", n.subjects)
+	}
+	if !strings.Contains(n.messages[0], "spread too wide") || !strings.Contains(n.messages[0], "10.00 EUR forfeited") {
+		t.Errorf("unexpected notification: %s", n.messages[0])
+	}
+}
+
+func TestSkipBuy_CarryForward(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	r, n := guardTestRunner(t, config.Config{SkippedBudget: config.SkippedBudgetCarry, StateFile: path})
+	for i := 0; i < 2; i++ {
+		if err := r.skipBuy("price cap"); err != nil {
+			t.Fatalf("skipBuy returned error: %v", err)
+		}
+	}
+	if !strings.Contains(n.messages[1], "10.00 EUR carried forward (20.00 EUR total)") {
+		t.Errorf("unexpected notification: %s", n.messages[1])
+	}
+
+	fiat, err := r.fiatForBuy()
+	if err != nil {
+		t.Fatalf("fiatForBuy returned error: %v", err)
+	}
+	if fiat != order.MustParseDecimal("30") {
+		t.Errorf("expected 30 EUR including carried fiat, got %s", fiat)
+	}
+
+	r.clearCarriedFiat()
+	st, err := state.NewStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.CarriedFiat.IsZero() || st.SkippedBuys != 0 {
+		t.Errorf("expected carried fiat to be reset, got %+v", st)
+	}
+}
+
+func TestSkipBuy_CarryForwardDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	r, n := guardTestRunner(t, config.Config{SkippedBudget: config.SkippedBudgetCarry, StateFile: path, DryRun: true})
+	if err := r.skipBuy("price cap"); err != nil {
+		t.Fatalf("skipBuy returned error: %v", err)
+	}
+	if !strings.Contains(n.messages[0], "would be carried forward") {
+		t.Errorf("unexpected notification: %s", n.messages[0])
+	}
+	st, _ := state.NewStore(path).Load()
+	if !st.CarriedFiat.IsZero() {
+		t.Errorf("dry run must not change state, got %s carried", st.CarriedFiat)
+	}
+}
+
+func TestRecordHistory_Skipped(t *testing.T) {
+	r, _ := guardTestRunner(t, config.Config{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")})
+	if err := r.skipBuy("price cap"); err != nil {
+		t.Fatalf("skipBuy returned error: %v", err)
+	}
+	r.recordHistory("run-1", time.Now(), nil)
+
+	records, err := r.history.All()
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	if len(records) != 1 || records[0].Outcome != history.OutcomeSkipped || records[0].Message != "price cap" || records[0].RunID != "run-1" {
+		t.Errorf("expected one skipped run, got %+v", records)
+	}
+}
@@ -0,0 +1,128 @@
+package dca
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/kraken"
+)
+
+// probePermissions infers the permissions of an API key; replaced in tests.
+var probePermissions = kraken.ProbePermissions
+
+// permissionNeed is an API key permission needed by a feature of the configuration.
+type permissionNeed struct {
+	permission kraken.Permission
+	feature    string // Feature needing the permission
+	dryRun     bool   // If true, the permission is also needed in dry run mode
+}
+
+// neededPermissions returns the API key permissions the configuration needs for live trading.
+func neededPermissions(cfg config.Config) []permissionNeed {
+	needs := []permissionNeed{{kraken.PermissionCreateOrders, "placing and validating orders", true}}
+	if cfg.CronExpr != "" || cfg.OrderSlotInterval > 0 {
+		needs = append(needs,
+			permissionNeed{kraken.PermissionQueryOpenOrders, "duplicate order protection", false},
+			permissionNeed{kraken.PermissionQueryClosedOrders, "duplicate order protection", false})
+	}
+	if cfg.OrderType == config.OrderTypeLimitMarket {
+		needs = append(needs,
+			permissionNeed{kraken.PermissionQueryOpenOrders, "market fallback", false},
+			permissionNeed{kraken.PermissionQueryClosedOrders, "market fallback", false},
+			permissionNeed{kraken.PermissionCancelOrders, "market fallback", false})
+	}
+	if cfg.HistoryFile != "" {
+		// Fills of earlier orders are queried for the cost basis
+		needs = append(needs,
+			permissionNeed{kraken.PermissionQueryOpenOrders, "recording order fills", false},
+			permissionNeed{kraken.PermissionQueryClosedOrders, "recording order fills", false})
+	}
+	if cfg.Dashboard {
+		needs = append(needs, permissionNeed{kraken.PermissionQueryOpenOrders, "open orders on the dashboard", true})
+	}
+	return needs
+}
+
+// permissionCheckOrder returns the order validated to check the Create & Modify Orders permission:
+// a post-only buy of the minimum volume at the lowest possible price, which could never fill.
+func permissionCheckOrder(cfg config.Config) kraken.OrderRequest {
+	return kraken.OrderRequest{
+		Pair:      cfg.Pair.String(),
+		OrderType: kraken.OrderTypeLimit,
+		Price:     cfg.Pair.PriceTick(),
+		Volume:    cfg.Pair.MinVolume(),
+		PostOnly:  true,
+		Validate:  true,
+	}
+}
+
+// CheckPermissions checks before the first run that the API key can do what the configuration needs.
+// It returns an error if Kraken rejects the key, or if the key lacks a permission needed in the current
+// mode; permissions only live trading needs are logged as warnings in dry run mode. Permissions that no
+// enabled feature needs are logged as warnings too, Withdraw Funds most prominently.
+// If Kraken cannot be reached, the check is skipped with a warning so an outage does not prevent startup.
+func CheckPermissions(cfg config.Config) error {
+	log := cfg.Logger()
+	perms, err := probePermissions(permissionCheckOrder(cfg), cfg.PublicKey, cfg.PrivateKey)
+	switch {
+	case errors.Is(err, kraken.ErrInvalidKey):
+		return fmt.Errorf("Kraken rejected the API key (EAPI:Invalid key); check EASY_DCA_PUBLIC_KEY and that the key still exists: %w", err)
+	case errors.Is(err, kraken.ErrInvalidSignature):
+		return fmt.Errorf("Kraken rejected the request signature (EAPI:Invalid signature); check that EASY_DCA_PRIVATE_KEY belongs to EASY_DCA_PUBLIC_KEY: %w", err)
+	case err != nil:
+		log.Warn("Could not check the API key permissions, continuing", errorAttrs(err)...)
+		return nil
+	}
+
+	// A permission may be needed by several features, in the current mode or only for live trading
+	needed := map[kraken.Permission]bool{}
+	neededNow := map[kraken.Permission]bool{}
+	features := map[kraken.Permission][]string{}
+	var ordered []kraken.Permission
+	for _, n := range neededPermissions(cfg) {
+		if !needed[n.permission] {
+			ordered = append(ordered, n.permission)
+		}
+		needed[n.permission] = true
+		neededNow[n.permission] = neededNow[n.permission] || n.dryRun || !cfg.DryRun
+		if !slices.Contains(features[n.permission], n.feature) {
+			features[n.permission] = append(features[n.permission], n.feature)
+		}
+	}
+	var missing []string
+	for _, p := range ordered {
+		feature := strings.Join(features[p], ", ")
+		switch {
+		case perms[p]:
+		case neededNow[p]:
+			missing = append(missing, fmt.Sprintf("%s (%s)", p, feature))
+		default:
+			log.Warn("The API key lacks a permission needed for live trading", "permission", p, "feature", feature)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the API key lacks permissions needed by this configuration (EGeneral:Permission denied): %s; enable them in the API key settings on Kraken",
+			strings.Join(missing, ", "))
+	}
+
+	var granted []string
+	for _, p := range slices.Sorted(maps.Keys(perms)) {
+		if !perms[p] {
+			continue
+		}
+		granted = append(granted, string(p))
+		switch {
+		case needed[p]:
+		case p == kraken.PermissionWithdrawFunds:
+			log.Warn("The API key can withdraw funds, which easy-dca never needs: anyone who obtains the key could withdraw your funds. Remove the Withdraw Funds permission from the key", "permission", p)
+		default:
+			log.Warn("The API key has a permission no enabled feature needs; consider removing it", "permission", p)
+		}
+	}
+	log.Info("API key permissions checked", "permissions", strings.Join(granted, ", "))
+	return nil
+}
@@ -0,0 +1,117 @@
+package dca
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/kraken"
+)
+
+// fakePermissions replaces the permission probe for the duration of the test.
+func fakePermissions(t *testing.T, perms kraken.Permissions, err error) *kraken.OrderRequest {
+	t.Helper()
+	var probed kraken.OrderRequest
+	orig := probePermissions
+	t.Cleanup(func() { probePermissions = orig })
+	probePermissions = func(req kraken.OrderRequest, publicKey, privateKey string) (kraken.Permissions, error) {
+		probed = req
+		return perms, err
+	}
+	return &probed
+}
+
+func permissionTestConfig(t *testing.T, dryRun bool) config.Config {
+	t.Helper()
+	pair, err := config.NewTradingPair("BTC/EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Config{Pair: pair, DryRun: dryRun, CronExpr: "0 8 * * *", OrderType: config.OrderTypePostOnly}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	probed := fakePermissions(t, kraken.Permissions{
+		kraken.PermissionCreateOrders:      true,
+		kraken.PermissionQueryOpenOrders:   true,
+		kraken.PermissionQueryClosedOrders: true,
+		kraken.PermissionWithdrawFunds:     true,
+	}, nil)
+
+	cfg := permissionTestConfig(t, false)
+	if err := CheckPermissions(cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !probed.Validate || !probed.PostOnly || probed.Price != cfg.Pair.PriceTick() {
+		t.Errorf("expected a post-only validate-only order at the lowest price, got %+v", *probed)
+	}
+}
+
+func TestCheckPermissions_Missing(t *testing.T) {
+	fakePermissions(t, kraken.Permissions{
+		kraken.PermissionCreateOrders:      true,
+		kraken.PermissionQueryOpenOrders:   true,
+		kraken.PermissionQueryClosedOrders: false,
+	}, nil)
+
+	err := CheckPermissions(permissionTestConfig(t, false))
+	if err == nil || !strings.Contains(err.Error(), "Query Closed Orders & Trades (duplicate order protection)") {
+		t.Errorf("expected the missing permission to be named, got %v", err)
+	}
+
+	// In dry run mode, duplicate order protection does not query orders
+	if err := CheckPermissions(permissionTestConfig(t, true)); err != nil {
+		t.Errorf("expected no error in dry run mode, got %v", err)
+	}
+}
+
+func TestCheckPermissions_FillsAndFallback(t *testing.T) {
+	fakePermissions(t, kraken.Permissions{kraken.PermissionCreateOrders: true}, nil)
+
+	// Recording fills queries earlier orders, even without a schedule
+	cfg := permissionTestConfig(t, false)
+	cfg.CronExpr = ""
+	cfg.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	err := CheckPermissions(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Query Open Orders & Trades (recording order fills)") ||
+		!strings.Contains(err.Error(), "Query Closed Orders & Trades (recording order fills)") {
+		t.Errorf("expected the query permissions to be needed for recording fills, got %v", err)
+	}
+
+	// The market fallback cancels the limit order before buying the rest at market
+	cfg.HistoryFile = ""
+	cfg.OrderType = config.OrderTypeLimitMarket
+	err = CheckPermissions(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Cancel & Close Orders (market fallback)") {
+		t.Errorf("expected the cancel permission to be needed for the market fallback, got %v", err)
+	}
+}
+
+func TestCheckPermissions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{"invalid key", kraken.ErrInvalidKey, "EAPI:Invalid key"},
+		{"invalid signature", kraken.ErrInvalidSignature, "EAPI:Invalid signature"},
+		{"unreachable", errors.New("connection refused"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePermissions(t, nil, tt.err)
+			err := CheckPermissions(permissionTestConfig(t, false))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected the check to be skipped, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -3,132 +3,759 @@ package dca
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
 	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/lock"
+	"github.com/mayrf/easy-dca/internal/metrics"
 	"github.com/mayrf/easy-dca/internal/notifications"
+	"github.com/mayrf/easy-dca/internal/order"
+	"github.com/mayrf/easy-dca/internal/state"
 )
 
+// orderBookDepth is the number of price levels fetched per side to estimate fills against.
+const orderBookDepth = 100
+
+// retryDelay is how long to wait before retrying a request that failed with a temporary Kraken error.
+var retryDelay = 5 * time.Second
+
 // Runner implements the DCARunner interface and contains the core DCA logic.
 type Runner struct {
-	cfg       config.Config
-	notifier  notifications.Notifier
+	cfg      config.Config
+	notifier notifications.Notifier
+	locker   lock.Locker
+	state    *state.Store
+	history  *history.Store
+
+	runMu      sync.Mutex     // Serializes runs within the process
+	log        *slog.Logger   // Logger of the current run (plan, run_id, pair, dry_run)
+	skipReason string         // Set when the current run skipped its buy
+	placed     *history.Order // Order placed (or validated) by the current run
 }
 
 // NewRunner creates a new DCA runner with the given configuration and notifier.
+// If a lock file is configured, runs hold an instance lock so that only one run is active at a time;
+// with the history lock backend, the lock is a lease in the history file instead.
+// If a state file is configured, state such as carried-forward fiat is persisted there.
+// If a history file is configured, every run is recorded there.
 func NewRunner(cfg config.Config, notifier notifications.Notifier) *Runner {
+	var store *state.Store
+	if cfg.StateFile != "" {
+		store = state.NewStore(cfg.StateFile)
+	}
+	var runs *history.Store
+	if cfg.HistoryFile != "" {
+		runs = history.NewStore(cfg.HistoryFile)
+	}
+	var locker lock.Locker
+	switch {
+	case cfg.LockBackend == config.LockBackendHistory && runs != nil:
+		locker = runs.Locker(cfg.Plan)
+	case cfg.LockFile != "":
+		locker = lock.NewFileLocker(cfg.LockFile)
+	}
+	metrics.InitPlan(cfg.Plan)
 	return &Runner{
 		cfg:      cfg,
 		notifier: notifier,
+		locker:   locker,
+		state:    store,
+		history:  runs,
+		log:      cfg.Logger().With("pair", cfg.Pair.String(), "dry_run", cfg.DryRun),
 	}
 }
 
 // RunDCA performs one DCA cycle and sends a notification if configured.
-func (r *Runner) RunDCA() error {
-	log.Printf("Fetching orders for %s", r.cfg.Pair.String())
-	response, err := kraken.GetOrderBook(r.cfg.Pair.String(), 10)
-	if err != nil {
-		log.Printf("Failed to fetch order book: %v", err)
-		if r.notifier != nil {
-			if err := r.notifier.Notify(context.Background(), "DCA Error", fmt.Sprintf("Failed to fetch order book: %v", err)); err != nil {
-				log.Printf("Failed to send notification: %v", err)
+// The run is skipped if another run holds the instance lock. Cancelling ctx, e.g. on shutdown,
+// ends the wait for a market fallback, which the next run then completes.
+func (r *Runner) RunDCA(ctx context.Context) error {
+	_, err := r.run(ctx, time.Time{})
+	return err
+}
+
+// RunManual performs one DCA cycle requested outside the schedule, e.g. via the control API or Telegram.
+// Its order gets a client order id of its own, so it buys even if the current schedule slot already did.
+// It returns why the run skipped its buy, if it did.
+func (r *Runner) RunManual(ctx context.Context, requested time.Time) (string, error) {
+	return r.run(ctx, requested)
+}
+
+// run performs one DCA cycle and records its outcome. requested is the request time of a manual run,
+// zero for scheduled runs.
+func (r *Runner) run(ctx context.Context, requested time.Time) (string, error) {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	runID := newRunID()
+	r.log = r.cfg.Logger().With("run_id", runID, "pair", r.cfg.Pair.String(), "dry_run", r.cfg.DryRun)
+	r.skipReason, r.placed = "", nil
+
+	started := time.Now()
+	err := r.runLocked(ctx, requested)
+	r.recordOutcome(err)
+	r.recordHistory(runID, started, err)
+	return r.skipReason, err
+}
+
+// runLocked acquires the instance lock, if configured, and performs one DCA cycle.
+func (r *Runner) runLocked(ctx context.Context, requested time.Time) error {
+	if r.locker != nil {
+		release, err := r.locker.TryLock()
+		if errors.Is(err, lock.ErrLocked) {
+			r.log.Warn("Skipping DCA run: another run is active", "error", err)
+			r.notify("DCA Skipped", fmt.Sprintf("Run skipped because another run is active: %v", err))
+			r.skipReason = "another run is active"
+			return nil
+		}
+		if err != nil {
+			r.log.Error("Failed to acquire instance lock", "error", err)
+			r.notify("DCA Error", fmt.Sprintf("Failed to acquire instance lock: %v", err))
+			return fmt.Errorf("failed to acquire instance lock: %w", err)
+		}
+		defer func() {
+			if err := release(); err != nil {
+				r.log.Error("Failed to release instance lock", "error", err)
 			}
+		}()
+	}
+
+	return r.runDCA(ctx, requested)
+}
+
+// runDCA performs one DCA cycle while holding the instance lock.
+func (r *Runner) runDCA(ctx context.Context, requested time.Time) error {
+	r.resumeMarketFallback(ctx)
+	r.recordFills()
+
+	// Tag the order with a client order id derived from the schedule slot, so a slot
+	// that already produced an order (e.g. before a crash or restart) is not bought twice.
+	// A manual run is not tied to a slot; it buys in addition to the slot's order
+	var clOrdID string
+	switch {
+	case !requested.IsZero():
+		clOrdID = manualOrderID(r.cfg.Pair.String(), requested)
+		r.log.Info("Manual run", "requested", requested.Format(time.RFC3339), "cl_ord_id", clOrdID)
+	case r.cfg.CronExpr != "" || r.cfg.OrderSlotInterval > 0:
+		slot, err := scheduleSlot(r.cfg.CronExpr, r.cfg.OrderSlotInterval, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to determine schedule slot: %w", err)
 		}
+		clOrdID = clientOrderID(r.cfg.Pair.String(), slot)
+		r.log.Info("Schedule slot", "slot", slot.Format(time.RFC3339), "cl_ord_id", clOrdID)
+
+		if !r.cfg.DryRun {
+			existing, err := kraken.FindOrderByClientID(clOrdID, r.cfg.PublicKey, r.cfg.PrivateKey)
+			if err != nil {
+				r.log.Error("Failed to check for an existing order", errorAttrs(err)...)
+				r.notify(errorSubject(err), fmt.Sprintf("Failed to check for an existing order, not placing a new one: %v", err))
+				return fmt.Errorf("failed to check for existing order: %w", err)
+			}
+			if existing != nil {
+				r.log.Info("Order already exists for this slot, skipping", "txid", existing.Txid, "status", existing.Status, "order", existing.Descr.Order)
+				r.notify("DCA Skipped", fmt.Sprintf("Order for slot %s already placed | TXID: %s (%s)", slot.Format(time.RFC3339), existing.Txid, existing.Status))
+				r.skipReason = fmt.Sprintf("order for this slot already placed (%s)", existing.Txid)
+				return nil
+			}
+		}
+	}
+
+	r.log.Info("Fetching order book")
+	var response kraken.OrderBookResponse
+	err := r.withRetry("fetch order book", func() error {
+		var err error
+		response, err = kraken.GetOrderBook(r.cfg.Pair.String(), orderBookDepth)
+		return err
+	})
+	if err != nil {
+		r.log.Error("Failed to fetch order book", errorAttrs(err)...)
+		r.notify(errorSubject(err), fmt.Sprintf("Failed to fetch order book: %v", err))
 		return fmt.Errorf("failed to fetch order book: %w", err)
 	}
-	
+
 	orderBook := response.Result[r.cfg.Pair.String()]
-	log.Printf("Best Ask: Price=%.2f, Volume=%.3f\n",
-		orderBook.Asks[0].Price, orderBook.Asks[0].Volume)
-
-	log.Printf("Best Bid: Price=%.2f, Volume=%.3f\n",
-		orderBook.Bids[0].Price, orderBook.Bids[0].Volume)
-
-	buyPrice := r.cfg.PriceFactor * float32(orderBook.Asks[0].Price)
-	
-	// Calculate fiat amount to spend based on configuration
-	var fiatAmountToSpend float32
-	if r.cfg.FiatAmountPerBuy > 0 {
-		fiatAmountToSpend = r.cfg.FiatAmountPerBuy
-	} else {
-		fiatAmountToSpend = r.cfg.MonthlyFiatSpending / float32(r.cfg.BuysPerMonth)
+	bestAsk, err := orderBook.Best(order.Asks)
+	if err == nil {
+		_, err = orderBook.Best(order.Bids)
+	}
+	if err != nil {
+		r.log.Error("Order book is unusable", "error", err)
+		r.notify("DCA Error", fmt.Sprintf("Order book for %s is unusable: %v", r.cfg.Pair.String(), err))
+		return fmt.Errorf("unusable order book: %w", err)
 	}
-	
-	btcQuantityToBuy := fiatAmountToSpend / buyPrice
-	
+	midPrice, _ := orderBook.MidPrice()
+	spreadBps, _ := orderBook.SpreadBps()
+	r.log.Info("Order book",
+		"best_ask", bestAsk.Price, "best_ask_volume", bestAsk.Volume,
+		"best_bid", orderBook.Bids[0].Price, "best_bid_volume", orderBook.Bids[0].Volume,
+		"mid_price", midPrice, "spread_bps", spreadBps)
+
+	// Refuse to buy in abnormal market conditions
+	skipReason, err := r.checkMarketConditions(orderBook)
+	if err != nil {
+		r.log.Error("Failed to check market conditions", errorAttrs(err)...)
+		r.notify(errorSubject(err), fmt.Sprintf("Failed to check market conditions, not buying: %v", err))
+		return fmt.Errorf("failed to check market conditions: %w", err)
+	}
+	if skipReason != "" {
+		return r.skipBuy(skipReason)
+	}
+
+	// Calculate fiat amount to spend based on configuration and carried-forward budget
+	fiatAmountToSpend, err := r.fiatForBuy()
+	if err != nil {
+		r.log.Error("Failed to determine fiat amount", "error", err)
+		r.notify("DCA Error", fmt.Sprintf("Failed to determine fiat amount: %v", err))
+		return err
+	}
+
+	buyPrice, reason := r.choosePrice(orderBook, fiatAmountToSpend)
+	r.log.Info("Chosen price", "price", buyPrice, "reason", reason)
+
+	// Volumes are rounded down to the pair's lot size so we never spend more than configured
+	btcQuantityToBuy := fiatAmountToSpend.Div(buyPrice, order.DecimalPlaces, order.RoundDown).RoundToStep(r.cfg.Pair.LotSize(), order.RoundDown)
+
 	// Check if order size is close to minimum (within 10% of minimum)
-	const (
-		minBtcSize = 0.00005
-		warningThreshold = 0.000055 // 10% above minimum
-	)
-	
-	if btcQuantityToBuy < warningThreshold {
-		log.Printf("Warning: Order size %s %s is close to minimum (%.5f BTC)", r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), minBtcSize)
-	}
-	
-	if btcQuantityToBuy < minBtcSize {
+	minBtcSize := r.cfg.Pair.MinVolume()
+	warningThreshold := minBtcSize.Mul(order.MustParseDecimal("1.1"))
+	if btcQuantityToBuy.LessThan(warningThreshold) {
+		r.log.Warn("Order size is close to minimum", "volume", btcQuantityToBuy, "min_volume", minBtcSize)
+	}
+
+	if btcQuantityToBuy.LessThan(minBtcSize) {
 		if r.cfg.AutoAdjustMinOrder {
-			log.Printf("Order volume of %s %s is too small. Minimum is %.5f BTC", r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), minBtcSize)
-			log.Printf("Auto-adjusting order volume to %.5f BTC", minBtcSize)
+			r.log.Warn("Order volume is below minimum, auto-adjusting to the minimum", "volume", btcQuantityToBuy, "min_volume", minBtcSize)
 			btcQuantityToBuy = minBtcSize
 			// Recalculate the actual fiat amount that will be spent
-			actualFiatAmount := btcQuantityToBuy * buyPrice
-			log.Printf("Note: This will actually spend %.2f %s instead of the configured %.2f %s", 
-				actualFiatAmount, r.cfg.Pair.GetFiatCurrency(), 
-				fiatAmountToSpend, r.cfg.Pair.GetFiatCurrency())
+			actualFiatAmount := btcQuantityToBuy.Mul(buyPrice)
+			r.log.Warn("Auto-adjusted order spends more than configured",
+				"amount", actualFiatAmount.StringFixed(2), "configured_amount", fiatAmountToSpend.StringFixed(2), "currency", r.cfg.Pair.GetFiatCurrency())
 		} else {
-			log.Printf("Order volume of %s %s is below minimum (%.5f BTC) and auto-adjustment is disabled", r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), minBtcSize)
-			log.Printf("Order will likely fail, but cron job will continue running")
+			r.log.Warn("Order volume is below minimum and auto-adjustment is disabled; the order will likely fail, but the scheduler keeps running",
+				"volume", btcQuantityToBuy, "min_volume", minBtcSize)
 		}
 	}
-	
-	log.Printf("Ordering price factor: %.4f, Ordering Price: %.2f", r.cfg.PriceFactor, buyPrice)
-	log.Printf("Ordering %s %s at a price of %.2f for a total of %.2f %s", 
-		r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice, btcQuantityToBuy*buyPrice, r.cfg.Pair.GetFiatCurrencyName())
+
+	msg := "Placing order"
 	if r.cfg.DryRun {
-		log.Printf("Dry run mode: order will only be validated, not executed.")
+		msg = "Validating order (dry run, not executed)"
 	}
-	
-	orderResponse, err := kraken.AddOrder(r.cfg.Pair.String(), float32(buyPrice), float32(btcQuantityToBuy), r.cfg.PublicKey, r.cfg.PrivateKey, r.cfg.DryRun)
-	if err != nil {
-		log.Printf("Failed to add order: %v", err)
-		if r.notifier != nil {
-			if err := r.notifier.Notify(context.Background(), "DCA Error", fmt.Sprintf("Failed to add order: %v", err)); err != nil {
-				log.Printf("Failed to send notification: %v", err)
+	r.log.Info(msg, "order_type", r.cfg.OrderType, "price", buyPrice, "volume", btcQuantityToBuy,
+		"amount", btcQuantityToBuy.Mul(buyPrice).StringFixed(2), "currency", r.cfg.Pair.GetFiatCurrency())
+
+	orderRequest := r.orderRequest(buyPrice, btcQuantityToBuy, clOrdID)
+	orderResponse, err := r.addOrder("add order", orderRequest)
+	if errors.Is(err, kraken.ErrPostOnlyWouldTake) {
+		if r.cfg.OrderType == config.OrderTypeLimitMarket {
+			// The ask moved below our limit price; the fallback would buy at market anyway
+			r.log.Info("Post-only order would have taken liquidity, buying at market instead", errorAttrs(err)...)
+			txid, err := r.placeMarketFallback(btcQuantityToBuy, clOrdID)
+			if err != nil {
+				return err
 			}
+			r.recordOrder(config.OrderTypeMarket, buyPrice, btcQuantityToBuy, txid)
+			r.clearCarriedFiat()
+			return nil
 		}
+		// The ask moved below our limit price; skip this run rather than paying taker fees
+		r.log.Info("Post-only order would have taken liquidity", errorAttrs(err)...)
+		return r.skipBuy(fmt.Sprintf("price %s %s would have matched immediately as a taker order", buyPrice.StringFixed(2), r.cfg.Pair.GetFiatCurrency()))
+	}
+	if err != nil {
+		r.log.Error("Failed to add order", errorAttrs(err)...)
+		r.notify(errorSubject(err), fmt.Sprintf("Failed to add order: %v", err))
 		return fmt.Errorf("failed to add order: %w", err)
 	}
-	
+
 	// Log the formatted order response
-	log.Print(kraken.FormatOrderResponse(orderResponse, r.cfg.DryRun))
-	
+	r.logOrderResponse(orderResponse)
+	var txid string
+	if len(orderResponse.Result.Txid) > 0 {
+		txid = orderResponse.Result.Txid[0]
+	}
+	r.recordOrder(r.cfg.OrderType, buyPrice, btcQuantityToBuy, txid)
+	r.clearCarriedFiat()
+
 	// Create notification message with order details
-	var msg string
 	if r.cfg.DryRun {
-		msg = fmt.Sprintf("DRY RUN: Validated order for %s %s at %.2f %s (total %.2f %s)", 
-			r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice, r.cfg.Pair.GetFiatCurrency(), 
-			fiatAmountToSpend, r.cfg.Pair.GetFiatCurrency())
+		msg = fmt.Sprintf("DRY RUN: Validated order for %s %s at %s %s (total %s %s)",
+			r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice.StringFixed(2), r.cfg.Pair.GetFiatCurrency(),
+			fiatAmountToSpend.StringFixed(2), r.cfg.Pair.GetFiatCurrency())
 	} else {
 		if len(orderResponse.Result.Txid) > 0 {
-			msg = fmt.Sprintf("LIVE ORDER: Placed order for %s %s at %.2f %s (total %.2f %s) | TXID: %s", 
-				r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice, r.cfg.Pair.GetFiatCurrency(), 
-				fiatAmountToSpend, r.cfg.Pair.GetFiatCurrency(), orderResponse.Result.Txid[0])
+			msg = fmt.Sprintf("LIVE ORDER: Placed order for %s %s at %s %s (total %s %s) | TXID: %s",
+				r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice.StringFixed(2), r.cfg.Pair.GetFiatCurrency(),
+				fiatAmountToSpend.StringFixed(2), r.cfg.Pair.GetFiatCurrency(), orderResponse.Result.Txid[0])
 		} else {
-			msg = fmt.Sprintf("LIVE ORDER: Placed order for %s %s at %.2f %s (total %.2f %s)", 
-				r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice, r.cfg.Pair.GetFiatCurrency(), 
-				fiatAmountToSpend, r.cfg.Pair.GetFiatCurrency())
+			msg = fmt.Sprintf("LIVE ORDER: Placed order for %s %s at %s %s (total %s %s)",
+				r.cfg.FormatBTC(btcQuantityToBuy), r.cfg.GetBTCUnit(), buyPrice.StringFixed(2), r.cfg.Pair.GetFiatCurrency(),
+				fiatAmountToSpend.StringFixed(2), r.cfg.Pair.GetFiatCurrency())
 		}
 	}
-	
-	if r.notifier != nil {
-		err := r.notifier.Notify(context.Background(), "DCA Success", msg)
-		if err != nil {
-			log.Printf("Failed to send notification: %v", err)
+
+	r.notifyOrder(msg, notifications.OrderReport{
+		Plan:     r.cfg.Plan,
+		Pair:     r.cfg.Pair.String(),
+		DryRun:   r.cfg.DryRun,
+		Type:     r.cfg.OrderType,
+		Volume:   r.cfg.FormatBTC(btcQuantityToBuy),
+		Unit:     r.cfg.GetBTCUnit(),
+		Price:    buyPrice.StringFixed(2),
+		Total:    fiatAmountToSpend.StringFixed(2),
+		Currency: r.cfg.Pair.GetFiatCurrency(),
+		Pricing:  reason,
+		Txid:     txid,
+	})
+
+	if r.cfg.OrderType == config.OrderTypeLimitMarket {
+		if r.cfg.DryRun || len(orderResponse.Result.Txid) == 0 {
+			r.log.Info("Dry run mode: unfilled volume would be bought at market", "market_fallback_after", r.cfg.MarketFallbackAfter.String())
+			return nil
+		}
+		return r.awaitMarketFallback(ctx, orderResponse.Result.Txid[0], clOrdID)
+	}
+
+	return nil
+}
+
+// choosePrice returns the price used to size the order and a short explanation of how it was chosen.
+// Limit orders are priced at price factor × best ask, rounded down to the pair's tick. Market orders
+// are sized by the VWAP of filling the fiat amount against the fetched asks.
+func (r *Runner) choosePrice(book order.OrderBook, fiat order.Decimal) (order.Decimal, string) {
+	ask := book.Asks[0].Price
+	fill, err := book.FillQuote(order.Asks, fiat)
+	if err != nil {
+		// Not enough depth fetched; assume the remainder fills no better than the last level seen
+		r.log.Warn("Order book is not deep enough to estimate the fill", "error", err)
+		fill.VWAP = fill.WorstPrice
+	}
+	if fill.Levels == 0 {
+		fill.VWAP, fill.WorstPrice = ask, ask
+	}
+	if r.cfg.OrderType == config.OrderTypeMarket {
+		return fill.VWAP, fmt.Sprintf("estimated market fill across %d level(s), VWAP %s (%s bps above best ask %s, worst level %s)",
+			fill.Levels, fill.VWAP.StringFixed(2), fill.SlippageBps.StringFixed(2), ask.StringFixed(2), fill.WorstPrice.StringFixed(2))
+	}
+
+	// Limit prices are rounded down to the pair's tick so we never bid above price factor × ask
+	price := ask.Mul(r.cfg.PriceFactor).RoundToStep(r.cfg.Pair.PriceTick(), order.RoundDown)
+	reason := fmt.Sprintf("price factor %s × best ask %s, rounded down to tick %s",
+		r.cfg.PriceFactor.StringFixed(4), ask.StringFixed(2), r.cfg.Pair.PriceTick())
+	if mid, err := book.MidPrice(); err == nil && mid.Sign() > 0 {
+		belowMid := mid.Sub(price).Div(mid, order.DecimalPlaces, order.RoundHalfEven).Mul(order.NewDecimalFromInt(10000))
+		reason += fmt.Sprintf(", %s bps below mid %s", belowMid.StringFixed(2), mid.StringFixed(2))
+	}
+	if fill.Levels > 0 {
+		reason += fmt.Sprintf("; buying at market instead would cost ~%s bps slippage", fill.SlippageBps.StringFixed(2))
+	}
+	return price, reason
+}
+
+// orderRequest builds the Kraken order for the configured order type.
+func (r *Runner) orderRequest(price, volume order.Decimal, clOrdID string) kraken.OrderRequest {
+	req := kraken.OrderRequest{
+		Pair:        r.cfg.Pair.String(),
+		OrderType:   kraken.OrderTypeLimit,
+		Price:       price,
+		Volume:      volume,
+		TimeInForce: r.cfg.TimeInForce,
+		ClOrdID:     clOrdID,
+		Validate:    r.cfg.DryRun,
+	}
+	if r.cfg.OrderExpire > 0 {
+		req.ExpireTm = fmt.Sprintf("+%d", int64(r.cfg.OrderExpire.Seconds()))
+	}
+	switch r.cfg.OrderType {
+	case config.OrderTypeMarket:
+		req.OrderType = kraken.OrderTypeMarket
+	case config.OrderTypeLimit:
+	case config.OrderTypeLimitMarket:
+		// Let Kraken expire the limit order when the market fallback kicks in
+		req.PostOnly = true
+		req.TimeInForce = "GTD"
+		req.ExpireTm = fmt.Sprintf("+%d", int64(r.cfg.MarketFallbackAfter.Seconds()))
+	default:
+		req.PostOnly = true
+	}
+	return req
+}
+
+// awaitMarketFallback waits for the limit order to fill and buys any unfilled volume at market once
+// the fallback timeout has passed. The fallback is recorded in the state file first, so if ctx is
+// cancelled or the process dies during the wait, the next run completes it.
+func (r *Runner) awaitMarketFallback(ctx context.Context, txid string, clOrdID string) error {
+	pending := state.PendingFallback{Txid: txid, ClOrdID: clOrdID, Due: time.Now().Add(r.cfg.MarketFallbackAfter).UTC()}
+	if r.state != nil {
+		if err := r.state.Update(func(st *state.State) error {
+			st.PendingFallback = &pending
+			return nil
+		}); err != nil {
+			r.log.Error("Failed to record the pending market fallback; it is lost if the wait is interrupted", "txid", txid, "error", err)
+		}
+	}
+	return r.completeMarketFallback(ctx, pending)
+}
+
+// resumeMarketFallback completes the market fallback of an earlier run that was interrupted,
+// e.g. by a restart. Failures are reported and the fallback stays pending for the next run.
+func (r *Runner) resumeMarketFallback(ctx context.Context) {
+	if r.state == nil || r.cfg.DryRun {
+		return
+	}
+	st, err := r.state.Load()
+	if err != nil {
+		r.log.Error("Failed to load state, not checking for a pending market fallback", "error", err)
+		return
+	}
+	if st.PendingFallback == nil {
+		return
+	}
+	r.log.Info("Resuming the market fallback of an earlier run", "txid", st.PendingFallback.Txid, "due", st.PendingFallback.Due.Format(time.RFC3339))
+	r.completeMarketFallback(ctx, *st.PendingFallback)
+}
+
+// completeMarketFallback waits until the fallback is due, cancels the limit order if it is still open
+// and buys the unfilled volume at market. The pending fallback is cleared once it is handled.
+func (r *Runner) completeMarketFallback(ctx context.Context, pending state.PendingFallback) error {
+	txid := pending.Txid
+	if wait := time.Until(pending.Due); wait > 0 {
+		r.log.Info("Waiting for the order to fill before falling back to a market order", "txid", txid, "market_fallback_after", wait.Round(time.Second).String())
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			if r.state == nil {
+				r.log.Warn("Stopped waiting for the market fallback; without a state file the unfilled volume will not be bought", "txid", txid)
+				r.notify("DCA Alert", fmt.Sprintf("Stopped waiting for order %s to fill; its unfilled volume will not be bought at market", txid))
+				return nil
+			}
+			r.log.Warn("Stopped waiting for the market fallback; the next run completes it", "txid", txid, "due", pending.Due.Format(time.RFC3339))
+			return nil
+		}
+	}
+
+	info, err := kraken.QueryOrder(txid, r.cfg.PublicKey, r.cfg.PrivateKey)
+	if err == nil && (info.Status == "open" || info.Status == "pending") {
+		// Kraken has not expired the order yet; cancel it so the remainder is not bought twice
+		if err = kraken.CancelOrder(txid, r.cfg.PublicKey, r.cfg.PrivateKey); err == nil {
+			info, err = kraken.QueryOrder(txid, r.cfg.PublicKey, r.cfg.PrivateKey)
 		}
 	}
-	
+	if err != nil {
+		r.log.Error("Failed to check limit order for market fallback", append([]any{"txid", txid}, errorAttrs(err)...)...)
+		r.notify(errorSubject(err), fmt.Sprintf("Failed to check limit order %s, no market fallback placed: %v", txid, err))
+		return fmt.Errorf("failed to check limit order for market fallback: %w", err)
+	}
+	r.recordFill(info)
+
+	vol, err := order.ParseDecimal(info.Vol)
+	if err != nil {
+		return fmt.Errorf("invalid volume %q for order %s: %w", info.Vol, txid, err)
+	}
+	volExec, err := order.ParseDecimal(info.VolExec)
+	if err != nil {
+		return fmt.Errorf("invalid executed volume %q for order %s: %w", info.VolExec, txid, err)
+	}
+	remaining := vol.Sub(volExec)
+	if remaining.LessThan(r.cfg.Pair.MinVolume()) {
+		r.log.Info("No market fallback needed", "txid", txid, "status", info.Status, "volume", vol, "volume_executed", volExec)
+		r.clearPendingFallback()
+		return nil
+	}
+
+	r.log.Info("Buying the unfilled remainder at market", "txid", txid, "status", info.Status, "volume", remaining)
+	marketTxid, err := r.placeMarketFallback(remaining, pending.ClOrdID)
+	if err != nil {
+		return err
+	}
+	r.clearPendingFallback()
+	r.recordMarketFallback(txid, marketTxid, info.Descr.Price, remaining)
 	return nil
-} 
\ No newline at end of file
+}
+
+// recordMarketFallback appends the market order that bought the unfilled remainder of a limit order
+// to the history, so its fill is recorded like that of the orders placed by runs.
+func (r *Runner) recordMarketFallback(limitTxid, txid, limitPrice string, volume order.Decimal) {
+	if r.history == nil || txid == "" {
+		return
+	}
+	// The market price is not known until the order fills; the limit price is the estimate
+	price, _ := order.ParseDecimal(limitPrice)
+	now := time.Now().UTC()
+	if err := r.history.Append(history.Record{
+		Action:   "market_fallback",
+		Plan:     r.cfg.Plan,
+		Started:  now,
+		Finished: now,
+		Pair:     r.cfg.Pair.String(),
+		Outcome:  history.OutcomeSuccess,
+		Message:  fmt.Sprintf("unfilled remainder of order %s", limitTxid),
+		Order:    &history.Order{Type: config.OrderTypeMarket, Txid: txid, Price: price, Volume: volume},
+	}); err != nil {
+		r.log.Error("Failed to record market fallback in the run history", "error", err)
+	}
+}
+
+// recordFills records the fills of this plan's earlier live orders that have closed since, so the cost
+// basis follows what Kraken executed rather than what was placed. Orders still open are checked again
+// on the next run; failures are logged and retried then.
+func (r *Runner) recordFills() {
+	if r.history == nil || r.cfg.DryRun {
+		return
+	}
+	unfilled, err := r.history.Unfilled()
+	if err != nil {
+		r.log.Error("Failed to read run history, not recording order fills", "error", err)
+		return
+	}
+	for _, rec := range unfilled {
+		if rec.Plan != r.cfg.Plan || rec.Pair != r.cfg.Pair.String() {
+			continue
+		}
+		info, err := kraken.QueryOrder(rec.Order.Txid, r.cfg.PublicKey, r.cfg.PrivateKey)
+		if err != nil {
+			r.log.Warn("Failed to query order for its fill", append([]any{"txid", rec.Order.Txid}, errorAttrs(err)...)...)
+			continue
+		}
+		r.recordFill(info)
+	}
+}
+
+// recordFill appends the fill of the order to the history once the order is closed, canceled or expired.
+func (r *Runner) recordFill(info *kraken.OrderInfo) {
+	if r.history == nil || r.cfg.DryRun {
+		return
+	}
+	switch info.Status {
+	case "closed", "canceled", "expired":
+	default:
+		return
+	}
+	fill := history.Fill{Time: time.Now().UTC(), Plan: r.cfg.Plan, Pair: r.cfg.Pair.String(), Txid: info.Txid, Status: info.Status}
+	if info.CloseTm > 0 {
+		fill.Time = time.Unix(0, int64(info.CloseTm*float64(time.Second))).UTC()
+	}
+	volume, errVol := order.ParseDecimal(info.VolExec)
+	cost, errCost := order.ParseDecimal(info.Cost)
+	price, errPrice := order.ParseDecimal(info.Price)
+	if err := errors.Join(errVol, errCost, errPrice); err != nil {
+		r.log.Error("Invalid fill of order", "txid", info.Txid, "error", err)
+		return
+	}
+	fill.Volume, fill.Cost, fill.Price = volume, cost, price
+	if err := r.history.Append(history.Record{Fill: &fill}); err != nil {
+		r.log.Error("Failed to record order fill in the run history", "txid", info.Txid, "error", err)
+		return
+	}
+	r.log.Info("Recorded order fill", "txid", info.Txid, "status", info.Status, "volume_executed", fill.Volume, "cost", fill.Cost)
+}
+
+// clearPendingFallback removes the pending market fallback from the state file.
+func (r *Runner) clearPendingFallback() {
+	if r.state == nil {
+		return
+	}
+	if err := r.state.Update(func(st *state.State) error {
+		st.PendingFallback = nil
+		return nil
+	}); err != nil {
+		r.log.Error("Failed to clear the pending market fallback", "error", err)
+	}
+}
+
+// placeMarketFallback buys the given volume with a market order and returns its transaction ID.
+func (r *Runner) placeMarketFallback(volume order.Decimal, clOrdID string) (string, error) {
+	req := kraken.OrderRequest{
+		Pair:      r.cfg.Pair.String(),
+		OrderType: kraken.OrderTypeMarket,
+		Volume:    volume,
+		ClOrdID:   clOrdID,
+		Validate:  r.cfg.DryRun,
+	}
+	response, err := r.addOrder("add market order", req)
+	if err != nil {
+		r.log.Error("Failed to add market fallback order", errorAttrs(err)...)
+		r.notify(errorSubject(err), fmt.Sprintf("Failed to add market fallback order: %v", err))
+		return "", fmt.Errorf("failed to add market fallback order: %w", err)
+	}
+
+	r.logOrderResponse(response)
+	var txid string
+	if !r.cfg.DryRun && len(response.Result.Txid) > 0 {
+		txid = response.Result.Txid[0]
+	}
+	msg := fmt.Sprintf("MARKET FALLBACK: Bought %s %s at market", r.cfg.FormatBTC(volume), r.cfg.GetBTCUnit())
+	if r.cfg.DryRun {
+		msg = fmt.Sprintf("DRY RUN: Validated market order for %s %s", r.cfg.FormatBTC(volume), r.cfg.GetBTCUnit())
+	} else if txid != "" {
+		msg += " | TXID: " + txid
+	}
+	report := notifications.OrderReport{
+		Plan:     r.cfg.Plan,
+		Pair:     r.cfg.Pair.String(),
+		DryRun:   r.cfg.DryRun,
+		Type:     config.OrderTypeMarket,
+		Volume:   r.cfg.FormatBTC(volume),
+		Unit:     r.cfg.GetBTCUnit(),
+		Currency: r.cfg.Pair.GetFiatCurrency(),
+		Txid:     txid,
+	}
+	r.notifyOrder(msg, report)
+	return txid, nil
+}
+
+// notify sends a notification if a notifier is configured, logging delivery failures.
+func (r *Runner) notify(subject, message string) {
+	if r.notifier == nil {
+		return
+	}
+	if err := r.notifier.Notify(context.Background(), subject, message); err != nil {
+		r.log.Error("Failed to send notification", "subject", subject, "error", err)
+	}
+}
+
+// notifyOrder sends the success notification of an order, passing its details to notifiers that format them.
+func (r *Runner) notifyOrder(message string, report notifications.OrderReport) {
+	rn, ok := r.notifier.(notifications.ReportNotifier)
+	if !ok {
+		r.notify("DCA Success", message)
+		return
+	}
+	if err := rn.NotifyReport(context.Background(), "DCA Success", message, report); err != nil {
+		r.log.Error("Failed to send notification", "subject", "DCA Success", "error", err)
+	}
+}
+
+// errorSubject returns the notification subject for a failed run.
+// Errors that need manual intervention (funding, key permissions, account restrictions) are raised as alerts.
+func errorSubject(err error) string {
+	switch {
+	case errors.Is(err, kraken.ErrInsufficientFunds),
+		errors.Is(err, kraken.ErrPermissionDenied),
+		errors.Is(err, kraken.ErrAccountRestricted),
+		errors.Is(err, kraken.ErrInvalidKey),
+		errors.Is(err, kraken.ErrInvalidSignature):
+		return "DCA Alert"
+	default:
+		return "DCA Error"
+	}
+}
+
+// withRetry runs fn and retries it once if it fails with a temporary Kraken error.
+func (r *Runner) withRetry(op string, fn func() error) error {
+	return r.retryIf(op, kraken.IsTemporary, fn)
+}
+
+// addOrder places the order, retrying it once only if Kraken rejected it for a rate limit. An order
+// whose request failed otherwise may have been accepted, so it is not sent again.
+func (r *Runner) addOrder(op string, req kraken.OrderRequest) (*kraken.AddOrderResponse, error) {
+	var response *kraken.AddOrderResponse
+	err := r.retryIf(op, kraken.IsOrderRetryable, func() error {
+		var err error
+		response, err = kraken.AddOrder(req, r.cfg.PublicKey, r.cfg.PrivateKey)
+		return err
+	})
+	if kraken.IsTemporary(err) && !kraken.IsOrderRetryable(err) {
+		r.log.Warn("Not retrying the order, Kraken may have accepted it", append([]any{"operation", op, "cl_ord_id", req.ClOrdID}, errorAttrs(err)...)...)
+	}
+	return response, err
+}
+
+// retryIf runs fn and retries it once if it fails with an error for which retryable returns true.
+func (r *Runner) retryIf(op string, retryable func(error) bool, fn func() error) error {
+	err := fn()
+	if err == nil || !retryable(err) {
+		return err
+	}
+	r.log.Warn("Temporary error, retrying", append([]any{"operation", op, "retry_in", retryDelay.String()}, errorAttrs(err)...)...)
+	time.Sleep(retryDelay)
+	return fn()
+}
+
+// recordOutcome updates the run metrics with the outcome of a run.
+func (r *Runner) recordOutcome(err error) {
+	switch {
+	case err != nil:
+		metrics.RunsTotal.Inc(r.cfg.Plan, metrics.OutcomeError)
+		metrics.ConsecutiveFailures.Add(1, r.cfg.Plan)
+	case r.skipReason != "":
+		metrics.RunsTotal.Inc(r.cfg.Plan, metrics.OutcomeSkipped)
+	default:
+		metrics.RunsTotal.Inc(r.cfg.Plan, metrics.OutcomeSuccess)
+		metrics.ConsecutiveFailures.Set(0, r.cfg.Plan)
+		metrics.LastSuccessTimestamp.SetTime(time.Now(), r.cfg.Plan)
+	}
+}
+
+// recordHistory appends the current run to the history, if configured.
+func (r *Runner) recordHistory(runID string, started time.Time, err error) {
+	if r.history == nil {
+		return
+	}
+	rec := history.Record{
+		RunID:    runID,
+		Plan:     r.cfg.Plan,
+		Started:  started.UTC(),
+		Finished: time.Now().UTC(),
+		Pair:     r.cfg.Pair.String(),
+		DryRun:   r.cfg.DryRun,
+		Outcome:  history.OutcomeSuccess,
+		Order:    r.placed,
+	}
+	switch {
+	case err != nil:
+		rec.Outcome, rec.Message = history.OutcomeError, err.Error()
+	case r.skipReason != "":
+		rec.Outcome, rec.Message = history.OutcomeSkipped, r.skipReason
+	}
+	if err := r.history.Append(rec); err != nil {
+		r.log.Error("Failed to record run history", "error", err)
+	}
+}
+
+// recordOrder remembers the order placed (or validated) by the run for the history,
+// and updates the order metrics if it is a live order.
+func (r *Runner) recordOrder(orderType string, price, volume order.Decimal, txid string) {
+	r.placed = &history.Order{Type: orderType, Txid: txid, Price: price, Volume: volume}
+	if r.cfg.DryRun {
+		return
+	}
+	currency := r.cfg.Pair.GetFiatCurrency()
+	metrics.FiatPlacedTotal.Add(volume.Mul(price).Float64(), r.cfg.Plan, currency)
+	metrics.BTCPlacedTotal.Add(volume.Float64(), r.cfg.Plan)
+	metrics.LastOrderPrice.Set(price.Float64(), r.cfg.Plan, currency)
+}
+
+// logOrderResponse logs the order placed (or validated) by Kraken.
+func (r *Runner) logOrderResponse(response *kraken.AddOrderResponse) {
+	attrs := []any{"order", response.Result.Descr.Order}
+	if len(response.Result.Txid) > 0 {
+		attrs = append(attrs, "txid", response.Result.Txid[0])
+	}
+	if r.cfg.DryRun {
+		r.log.Info("Order validated", attrs...)
+	} else {
+		r.log.Info("Order placed", attrs...)
+	}
+}
+
+// errorAttrs returns the log attributes of an error, including its Kraken error class.
+func errorAttrs(err error) []any {
+	return []any{"error", err, "error_class", kraken.ErrorClass(err)}
+}
+
+// newRunID returns a random identifier that ties together the log events of one run.
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,351 @@
+package dca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/kraken"
+	"github.com/mayrf/easy-dca/internal/order"
+	"github.com/mayrf/easy-dca/internal/state"
+)
+
+// krakenCall is a request received by the fake Kraken server.
+type krakenCall struct {
+	Path string
+	Body map[string]any
+}
+
+// fakeKraken points the Kraken API at a server answering requests with respond, and returns
+// the requests it received.
+func fakeKraken(t *testing.T, respond func(call krakenCall) string) func() []krakenCall {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []krakenCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := krakenCall{Path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&call.Body)
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+		w.Write([]byte(respond(call)))
+	}))
+	t.Cleanup(srv.Close)
+	orig := kraken.Environment
+	kraken.Environment = srv.URL
+	t.Cleanup(func() { kraken.Environment = orig })
+	return func() []krakenCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]krakenCall(nil), calls...)
+	}
+}
+
+// paths returns the paths of calls in order.
+func paths(calls []krakenCall) []string {
+	var p []string
+	for _, c := range calls {
+		p = append(p, c.Path)
+	}
+	return p
+}
+
+func fallbackTestRunner(t *testing.T, after time.Duration, stateFile string) (*Runner, *recordingNotifier) {
+	t.Helper()
+	return guardTestRunner(t, config.Config{
+		OrderType:           config.OrderTypeLimitMarket,
+		MarketFallbackAfter: after,
+		StateFile:           stateFile,
+		HistoryFile:         filepath.Join(t.TempDir(), "history.jsonl"),
+		PublicKey:           "key",
+		PrivateKey:          "c2VjcmV0",
+	})
+}
+
+func TestOrderRequest(t *testing.T) {
+	price, volume := order.MustParseDecimal("60000"), order.MustParseDecimal("0.001")
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		orderType string
+		postOnly  bool
+		tif       string
+		expire    string
+	}{
+		{"post-only", config.Config{OrderType: config.OrderTypePostOnly}, kraken.OrderTypeLimit, true, "", ""},
+		{"limit with expiry", config.Config{OrderType: config.OrderTypeLimit, TimeInForce: "GTD", OrderExpire: 10 * time.Minute}, kraken.OrderTypeLimit, false, "GTD", "+600"},
+		{"market", config.Config{OrderType: config.OrderTypeMarket}, kraken.OrderTypeMarket, false, "", ""},
+		{"limit-market", config.Config{OrderType: config.OrderTypeLimitMarket, MarketFallbackAfter: time.Hour}, kraken.OrderTypeLimit, true, "GTD", "+3600"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := guardTestRunner(t, tt.cfg)
+			req := r.orderRequest(price, volume, "cl-1")
+			if req.OrderType != tt.orderType || req.PostOnly != tt.postOnly || req.TimeInForce != tt.tif || req.ExpireTm != tt.expire {
+				t.Errorf("unexpected order request %+v", req)
+			}
+			if req.Pair != "BTC/EUR" || req.Price.Cmp(price) != 0 || req.Volume.Cmp(volume) != 0 || req.ClOrdID != "cl-1" || req.Validate {
+				t.Errorf("order request does not carry the order: %+v", req)
+			}
+		})
+	}
+}
+
+func TestAwaitMarketFallback_BuysRemainder(t *testing.T) {
+	canceled := false
+	calls := fakeKraken(t, func(call krakenCall) string {
+		switch call.Path {
+		case "/0/private/QueryOrders":
+			status := "open"
+			if canceled {
+				status = "canceled"
+			}
+			return `{"error":[],"result":{"OTX-1":{"status":"` + status + `","vol":"0.00100000","vol_exec":"0.00040000","cost":"24.00000","price":"60000.0"}}}`
+		case "/0/private/CancelOrder":
+			canceled = true
+			return `{"error":[],"result":{"count":1}}`
+		case "/0/private/AddOrder":
+			return `{"error":[],"result":{"txid":["OTX-2"],"descr":{"order":"buy 0.0006 XBTEUR @ market"}}}`
+		}
+		t.Errorf("unexpected request to %s", call.Path)
+		return `{"error":["EGeneral:Unknown method"]}`
+	})
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	r, _ := fallbackTestRunner(t, 0, stateFile)
+
+	if err := r.awaitMarketFallback(context.Background(), "OTX-1", "cl-1"); err != nil {
+		t.Fatalf("awaitMarketFallback returned error: %v", err)
+	}
+	got := calls()
+	want := []string{"/0/private/QueryOrders", "/0/private/CancelOrder", "/0/private/QueryOrders", "/0/private/AddOrder"}
+	if len(got) != len(want) {
+		t.Fatalf("expected requests %v, got %v", want, paths(got))
+	}
+	for i := range want {
+		if got[i].Path != want[i] {
+			t.Fatalf("expected requests %v, got %v", want, paths(got))
+		}
+	}
+	add := got[3].Body
+	if add["ordertype"] != "market" || add["volume"] != "0.0006" || add["cl_ord_id"] != "cl-1" {
+		t.Errorf("expected a market order for the remaining 0.0006, got %v", add)
+	}
+	if st, _ := state.NewStore(stateFile).Load(); st.PendingFallback != nil {
+		t.Errorf("expected the pending fallback to be cleared, got %+v", st.PendingFallback)
+	}
+	if fills, _ := r.history.Fills(); len(fills) != 1 || fills[0].Txid != "OTX-1" || fills[0].Volume != order.MustParseDecimal("0.0004") {
+		t.Errorf("expected the partial fill of OTX-1 to be recorded, got %+v", fills)
+	}
+	if unfilled, _ := r.history.Unfilled(); len(unfilled) != 1 || unfilled[0].Action != "market_fallback" || unfilled[0].Order.Txid != "OTX-2" {
+		t.Errorf("expected the market order OTX-2 to await its fill, got %+v", unfilled)
+	}
+}
+
+func TestAwaitMarketFallback_Filled(t *testing.T) {
+	calls := fakeKraken(t, func(call krakenCall) string {
+		if call.Path != "/0/private/QueryOrders" {
+			t.Errorf("unexpected request to %s", call.Path)
+		}
+		return `{"error":[],"result":{"OTX-1":{"status":"closed","vol":"0.00100000","vol_exec":"0.00100000","cost":"60.00000","price":"60000.0"}}}`
+	})
+	r, _ := fallbackTestRunner(t, 0, "")
+	if err := r.awaitMarketFallback(context.Background(), "OTX-1", "cl-1"); err != nil {
+		t.Fatalf("awaitMarketFallback returned error: %v", err)
+	}
+	if got := calls(); len(got) != 1 {
+		t.Errorf("expected only the order query, got %v", paths(got))
+	}
+}
+
+func TestAwaitMarketFallback_ResumedAfterShutdown(t *testing.T) {
+	calls := fakeKraken(t, func(call krakenCall) string {
+		switch call.Path {
+		case "/0/private/QueryOrders":
+			return `{"error":[],"result":{"OTX-1":{"status":"expired","vol":"0.00100000","vol_exec":"0","cost":"0","price":"0"}}}`
+		case "/0/private/AddOrder":
+			return `{"error":[],"result":{"txid":["OTX-2"]}}`
+		}
+		t.Errorf("unexpected request to %s", call.Path)
+		return `{"error":["EGeneral:Unknown method"]}`
+	})
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	r, _ := fallbackTestRunner(t, time.Hour, stateFile)
+
+	// Shutting down during the wait leaves the fallback pending
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.awaitMarketFallback(ctx, "OTX-1", "cl-1"); err != nil {
+		t.Fatalf("awaitMarketFallback returned error: %v", err)
+	}
+	if got := calls(); len(got) != 0 {
+		t.Fatalf("expected no requests after shutdown, got %v", paths(got))
+	}
+	store := state.NewStore(stateFile)
+	st, _ := store.Load()
+	if st.PendingFallback == nil || st.PendingFallback.Txid != "OTX-1" || st.PendingFallback.ClOrdID != "cl-1" {
+		t.Fatalf("expected a pending fallback for OTX-1, got %+v", st.PendingFallback)
+	}
+
+	// The next run, here after the fallback is due, buys the unfilled volume
+	store.Update(func(st *state.State) error {
+		st.PendingFallback.Due = time.Now().Add(-time.Minute)
+		return nil
+	})
+	r, _ = fallbackTestRunner(t, time.Hour, stateFile)
+	r.resumeMarketFallback(context.Background())
+	got := calls()
+	if len(got) != 2 || got[1].Path != "/0/private/AddOrder" || got[1].Body["volume"] != "0.001" {
+		t.Fatalf("expected a market order for the full volume, got %+v", got)
+	}
+	if st, _ := store.Load(); st.PendingFallback != nil {
+		t.Errorf("expected the pending fallback to be cleared, got %+v", st.PendingFallback)
+	}
+}
+
+func TestRecordFills(t *testing.T) {
+	calls := fakeKraken(t, func(call krakenCall) string {
+		switch call.Body["txid"] {
+		case "OTX-1":
+			return `{"error":[],"result":{"OTX-1":{"status":"closed","closetm":1735718400.5,"vol":"0.00100000","vol_exec":"0.00100000","cost":"60.00000","price":"60000.0"}}}`
+		case "OTX-2":
+			return `{"error":[],"result":{"OTX-2":{"status":"open","vol":"0.00100000","vol_exec":"0.00000000","cost":"0.00000","price":"0.0"}}}`
+		}
+		t.Errorf("unexpected request %+v", call)
+		return `{"error":["EGeneral:Unknown method"]}`
+	})
+	r, _ := fallbackTestRunner(t, 0, "")
+	d := order.MustParseDecimal
+	for _, rec := range []history.Record{
+		{RunID: "a", Pair: "BTC/EUR", Outcome: history.OutcomeSuccess, Order: &history.Order{Txid: "OTX-1", Price: d("60000"), Volume: d("0.001")}},
+		{RunID: "b", Pair: "BTC/EUR", Outcome: history.OutcomeSuccess, Order: &history.Order{Txid: "OTX-2", Price: d("60000"), Volume: d("0.001")}},
+		{RunID: "c", Plan: "other", Pair: "BTC/EUR", Outcome: history.OutcomeSuccess, Order: &history.Order{Txid: "OTX-3", Price: d("60000"), Volume: d("0.001")}},
+	} {
+		if err := r.history.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r.recordFills()
+	if got := calls(); len(got) != 2 {
+		t.Fatalf("expected the orders of this plan to be queried, got %+v", got)
+	}
+	fills, _ := r.history.Fills()
+	if len(fills) != 1 || fills[0].Txid != "OTX-1" || fills[0].Cost != d("60") || fills[0].Price != d("60000") {
+		t.Fatalf("expected the fill of OTX-1, got %+v", fills)
+	}
+	if want := time.Date(2025, 1, 1, 8, 0, 0, 500_000_000, time.UTC); !fills[0].Time.Equal(want) {
+		t.Errorf("expected the close time %s, got %s", want, fills[0].Time)
+	}
+
+	// The open order is checked again on the next run; the recorded fill is not
+	r.recordFills()
+	if got := calls(); len(got) != 3 || got[2].Body["txid"] != "OTX-2" {
+		t.Errorf("expected only OTX-2 to be queried again, got %+v", got)
+	}
+}
+
+func TestRunManual_AfterScheduledRun(t *testing.T) {
+	var placed []string
+	var mu sync.Mutex
+	calls := fakeKraken(t, func(call krakenCall) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch call.Path {
+		case "/0/public/Depth":
+			return `{"error":[],"result":{"BTC/EUR":{"asks":[["60010.0","1.0",1]],"bids":[["59990.0","1.0",1]]}}}`
+		case "/0/private/OpenOrders":
+			open := map[string]any{}
+			for i, id := range placed {
+				open[fmt.Sprintf("OTX-%d", i+1)] = map[string]any{"cl_ord_id": id, "status": "open"}
+			}
+			resp, _ := json.Marshal(map[string]any{"error": []string{}, "result": map[string]any{"open": open}})
+			return string(resp)
+		case "/0/private/ClosedOrders":
+			return `{"error":[],"result":{"closed":{},"count":0}}`
+		case "/0/private/AddOrder":
+			placed = append(placed, call.Body["cl_ord_id"].(string))
+			return fmt.Sprintf(`{"error":[],"result":{"txid":["OTX-%d"]}}`, len(placed))
+		}
+		t.Errorf("unexpected request to %s", call.Path)
+		return `{"error":["EGeneral:Unknown method"]}`
+	})
+	r, _ := guardTestRunner(t, config.Config{
+		OrderType:   config.OrderTypeLimit,
+		PriceFactor: order.MustParseDecimal("0.999"),
+		CronExpr:    "0 8 * * *",
+		PublicKey:   "key",
+		PrivateKey:  "c2VjcmV0",
+	})
+
+	if err := r.RunDCA(context.Background()); err != nil {
+		t.Fatalf("scheduled run returned error: %v", err)
+	}
+	if err := r.RunDCA(context.Background()); err != nil || r.skipReason == "" {
+		t.Fatalf("expected a second run in the slot to be skipped, got error %v", err)
+	}
+
+	// A manual run is not tied to the slot, so it buys although the slot already did
+	skipReason, err := r.RunManual(context.Background(), time.Now())
+	if err != nil || skipReason != "" {
+		t.Fatalf("expected the manual run to buy, got skip reason %q and error %v", skipReason, err)
+	}
+	if len(placed) != 2 || placed[0] == placed[1] {
+		t.Fatalf("expected two orders with distinct client order ids, got %v (requests %v)", placed, paths(calls()))
+	}
+}
+
+func TestRunDCA_AddOrderRetry(t *testing.T) {
+	orig := retryDelay
+	retryDelay = 0
+	t.Cleanup(func() { retryDelay = orig })
+
+	tests := []struct {
+		code   string
+		orders int
+		err    bool
+	}{
+		{"EAPI:Rate limit exceeded", 2, false},
+		{"EService:Unavailable", 1, true},
+		{"EService:Busy", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			orders := 0
+			calls := fakeKraken(t, func(call krakenCall) string {
+				switch call.Path {
+				case "/0/public/Depth":
+					return `{"error":[],"result":{"BTC/EUR":{"asks":[["60010.0","1.0",1]],"bids":[["59990.0","1.0",1]]}}}`
+				case "/0/private/AddOrder":
+					if orders++; orders == 1 {
+						return `{"error":["` + tt.code + `"]}`
+					}
+					return `{"error":[],"result":{"txid":["OTX-1"]}}`
+				}
+				t.Errorf("unexpected request to %s", call.Path)
+				return `{"error":["EGeneral:Unknown method"]}`
+			})
+			r, _ := guardTestRunner(t, config.Config{
+				OrderType:   config.OrderTypeLimit,
+				PriceFactor: order.MustParseDecimal("0.999"),
+				PublicKey:   "key",
+				PrivateKey:  "c2VjcmV0",
+			})
+
+			err := r.RunDCA(context.Background())
+			if (err != nil) != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+			if orders != tt.orders {
+				t.Errorf("expected %d AddOrder requests, got %d (requests %v)", tt.orders, orders, paths(calls()))
+			}
+		})
+	}
+}
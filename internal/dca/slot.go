@@ -0,0 +1,60 @@
+package dca
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleSlot returns the start of the schedule slot that now falls into.
+// With a cron expression this is the most recent scheduled time at or before now;
+// otherwise now is truncated to the given interval (in UTC).
+// Runs that fall into the same slot share a client order id, so a slot buys at most once.
+func scheduleSlot(cronExpr string, interval time.Duration, now time.Time) (time.Time, error) {
+	if cronExpr == "" {
+		if interval <= 0 {
+			return now.UTC(), nil
+		}
+		return now.UTC().Truncate(interval), nil
+	}
+
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	schedule, err := parser.Parse(cronExpr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	// cron schedules have no Prev, so walk forward from a month back (the longest supported interval)
+	var slot time.Time
+	for next := schedule.Next(now.AddDate(0, 0, -32)); !next.After(now); next = schedule.Next(next) {
+		slot = next
+	}
+	if slot.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q has no run in the last month", cronExpr)
+	}
+	return slot.UTC(), nil
+}
+
+// clientOrderID derives a deterministic client order id for a trading pair and schedule slot.
+// The id is formatted as a UUID, one of the formats Kraken accepts for cl_ord_id.
+func clientOrderID(pair string, slot time.Time) string {
+	return nameUUID("easy-dca|" + pair + "|" + slot.UTC().Format(time.RFC3339))
+}
+
+// manualOrderID derives the client order id of a manual run requested at the given time.
+// Manual runs are not tied to a schedule slot, so their ids have a namespace of their own
+// and never collide with the id of the current slot.
+func manualOrderID(pair string, requested time.Time) string {
+	return nameUUID("easy-dca|manual|" + pair + "|" + requested.UTC().Format(time.RFC3339Nano))
+}
+
+// nameUUID returns a name-based UUID for name.
+func nameUUID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	b := sum[:16]
+	b[6] = (b[6] & 0x0f) | 0x50 // version 5 (name-based)
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,62 @@
+package dca
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestScheduleSlot_Cron(t *testing.T) {
+	now := time.Date(2025, 3, 12, 10, 17, 42, 0, time.Local)
+	slot, err := scheduleSlot("30 9 * * *", 0, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2025, 3, 12, 9, 30, 0, 0, time.Local).UTC()
+	if !slot.Equal(want) {
+		t.Errorf("scheduleSlot = %v; want %v", slot, want)
+	}
+
+	// A run triggered exactly at its scheduled time belongs to that slot
+	slot, err = scheduleSlot("30 9 * * *", 0, want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slot.Equal(want) {
+		t.Errorf("scheduleSlot at slot start = %v; want %v", slot, want)
+	}
+}
+
+func TestScheduleSlot_Interval(t *testing.T) {
+	now := time.Date(2025, 3, 12, 10, 17, 42, 0, time.UTC)
+	slot, err := scheduleSlot("", 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC); !slot.Equal(want) {
+		t.Errorf("scheduleSlot = %v; want %v", slot, want)
+	}
+}
+
+func TestScheduleSlot_InvalidCron(t *testing.T) {
+	if _, err := scheduleSlot("not a cron", 0, time.Now()); err == nil {
+		t.Error("expected error for invalid cron expression")
+	}
+}
+
+func TestClientOrderID(t *testing.T) {
+	slot := time.Date(2025, 3, 12, 9, 30, 0, 0, time.UTC)
+	id := clientOrderID("BTC/EUR", slot)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("clientOrderID = %q; want a version 5 UUID", id)
+	}
+	if again := clientOrderID("BTC/EUR", slot.In(time.FixedZone("CET", 3600))); again != id {
+		t.Errorf("clientOrderID is not deterministic: %q != %q", again, id)
+	}
+	if other := clientOrderID("BTC/EUR", slot.Add(24*time.Hour)); other == id {
+		t.Errorf("different slots produced the same client order id %q", id)
+	}
+	if other := clientOrderID("BTC/CHF", slot); other == id {
+		t.Errorf("different pairs produced the same client order id %q", id)
+	}
+}
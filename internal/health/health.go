@@ -0,0 +1,140 @@
+// Package health provides liveness and readiness checks served over HTTP and a client to probe them.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds how long a single check may take when serving a request.
+const checkTimeout = 5 * time.Second
+
+// Check reports whether one aspect of the application is healthy. It returns nil if it is.
+type Check func(ctx context.Context) error
+
+// Checker runs a set of named checks.
+type Checker struct {
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+}
+
+// NewChecker creates an empty checker. A checker without checks always passes.
+func NewChecker() *Checker {
+	return &Checker{checks: map[string]Check{}}
+}
+
+// Add registers a named check. Checks run in the order they were added.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.checks[name]; !exists {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Result is the outcome of running all checks.
+type Result struct {
+	Status string            `json:"status"` // "ok" or "fail"
+	Checks map[string]string `json:"checks"` // "ok" or the error of each check
+}
+
+// OK reports whether all checks passed.
+func (r Result) OK() bool { return r.Status == "ok" }
+
+// Run runs all checks and returns their results.
+func (c *Checker) Run(ctx context.Context) Result {
+	c.mu.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make(map[string]Check, len(c.checks))
+	for k, v := range c.checks {
+		checks[k] = v
+	}
+	c.mu.Unlock()
+
+	res := Result{Status: "ok", Checks: make(map[string]string, len(names))}
+	for _, name := range names {
+		if err := checks[name](ctx); err != nil {
+			res.Status = "fail"
+			res.Checks[name] = err.Error()
+		} else {
+			res.Checks[name] = "ok"
+		}
+	}
+	return res
+}
+
+// Handler returns an HTTP handler that runs the checks and responds with
+// 200 OK if all pass or 503 Service Unavailable otherwise, with the results as JSON.
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+		res := c.Run(ctx)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !res.OK() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+// Cached wraps a check so that its result is reused for ttl, protecting slow or
+// rate-limited dependencies from frequent probes.
+func Cached(ttl time.Duration, check Check) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		last    error
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return last
+		}
+		last = check(ctx)
+		checked = time.Now()
+		return last
+	}
+}
+
+// Probe requests a health endpoint and returns an error unless it responds with 200 OK.
+func Probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s %s", url, resp.Status, body)
+	}
+	return nil
+}
+
+// LocalURL returns the URL of path on a server listening on addr, reached via the loopback
+// interface if addr does not name a specific host (e.g. ":9090" or "0.0.0.0:9090").
+func LocalURL(addr, path string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + path, nil
+}
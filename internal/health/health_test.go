@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerHandler(t *testing.T) {
+	c := NewChecker()
+	c.Add("scheduler", func(ctx context.Context) error { return nil })
+
+	srv := httptest.NewServer(c.Handler())
+	defer srv.Close()
+	if err := Probe(context.Background(), srv.URL); err != nil {
+		t.Fatalf("expected healthy, got %v", err)
+	}
+
+	c.Add("kraken", func(ctx context.Context) error { return errors.New("maintenance") })
+	err := Probe(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "maintenance") {
+		t.Fatalf("expected a 503 with the failing check, got %v", err)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "fail" || res.Checks["scheduler"] != "ok" || res.Checks["kraken"] != "maintenance" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(time.Hour, func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	})
+	for i := 0; i < 3; i++ {
+		if err := check(context.Background()); err == nil {
+			t.Fatal("expected the cached error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call within the ttl, got %d", calls)
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	if err := Probe(context.Background(), url); err == nil {
+		t.Error("expected an error for an unreachable server")
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	tests := map[string]string{
+		":9090":          "http://127.0.0.1:9090/healthz",
+		"0.0.0.0:9090":   "http://127.0.0.1:9090/healthz",
+		"[::]:9090":      "http://127.0.0.1:9090/healthz",
+		"10.0.0.5:8080":  "http://10.0.0.5:8080/healthz",
+		"localhost:9090": "http://localhost:9090/healthz",
+	}
+	for addr, want := range tests {
+		got, err := LocalURL(addr, "/healthz")
+		if err != nil || got != want {
+			t.Errorf("LocalURL(%q) = %q, %v; want %q", addr, got, err, want)
+		}
+	}
+	if _, err := LocalURL("9090", "/healthz"); err == nil {
+		t.Error("expected an error for an address without port separator")
+	}
+}
@@ -0,0 +1,245 @@
+// Package history records DCA runs, the orders they placed and how they filled, and control actions, for display in the dashboard.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/lock"
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+// Run outcomes, matching the outcome label of the runs metric.
+const (
+	OutcomeSuccess = "success"
+	OutcomeSkipped = "skipped"
+	OutcomeError   = "error"
+)
+
+// Record describes one DCA run, or a control action if Action is set.
+type Record struct {
+	RunID    string    `json:"run_id,omitempty"`  // Identifier of the run, as logged
+	Action   string    `json:"action,omitempty"`  // Control action, e.g. pause or run (empty for runs)
+	Plan     string    `json:"plan,omitempty"`    // Plan of the run (empty if the configuration defines no plans)
+	Started  time.Time `json:"started"`           // Start of the run
+	Finished time.Time `json:"finished"`          // End of the run
+	Pair     string    `json:"pair"`              // Trading pair, e.g. BTC/EUR
+	DryRun   bool      `json:"dry_run"`           // Whether the order was only validated
+	Outcome  string    `json:"outcome"`           // success, skipped or error
+	Message  string    `json:"message,omitempty"` // Skip reason, error message or who requested an action
+	Order    *Order    `json:"order,omitempty"`   // Order placed (or validated) by the run
+	Fill     *Fill     `json:"fill,omitempty"`    // Fill of an order, recorded once the order is closed; not returned by All
+	Lease    *Lease    `json:"lease,omitempty"`   // Instance lock lease; such records are internal and not returned by All
+}
+
+// Order describes an order placed by a run.
+type Order struct {
+	Type   string        `json:"type"`           // Order type, e.g. post-only or market
+	Txid   string        `json:"txid,omitempty"` // Kraken transaction ID (live orders only)
+	Price  order.Decimal `json:"price"`          // Limit price, or the estimated price of a market order
+	Volume order.Decimal `json:"volume"`         // BTC volume
+}
+
+// Cost returns the fiat value of the order.
+func (o Order) Cost() order.Decimal {
+	return o.Price.Mul(o.Volume)
+}
+
+// Fill describes how much of a live order was executed, as reported by Kraken once the order is closed.
+type Fill struct {
+	Time   time.Time     `json:"time"`           // When the order was closed
+	Plan   string        `json:"plan,omitempty"` // Plan of the order (empty if the configuration defines no plans)
+	Pair   string        `json:"pair"`           // Trading pair, e.g. BTC/EUR
+	Txid   string        `json:"txid"`           // Kraken transaction ID of the order
+	Status string        `json:"status"`         // Final status: closed, canceled or expired
+	Volume order.Decimal `json:"volume"`         // Executed BTC volume
+	Cost   order.Decimal `json:"cost"`           // Fiat spent on the executed volume
+	Price  order.Decimal `json:"price"`          // Average execution price
+}
+
+// Store appends records to a JSON Lines file, one record per line, oldest first.
+// The file is locked while it is read or written, so processes sharing it see complete records.
+type Store struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a new file-backed history store.
+func NewStore(path string) *Store {
+	return &Store{Path: path}
+}
+
+// Append adds a record to the end of the history.
+func (s *Store) Append(rec Record) error {
+	return s.withFile(os.O_WRONLY|os.O_APPEND|os.O_CREATE, func(f *os.File) error {
+		return s.write(f, rec)
+	})
+}
+
+// write appends rec to the locked history file f.
+func (s *Store) write(f *os.File, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return f.Sync()
+}
+
+// All returns all records of runs and control actions, oldest first. A missing file yields no records.
+func (s *Store) All() ([]Record, error) {
+	var records []Record
+	err := s.withFile(os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		all, err := s.read(f)
+		for _, rec := range all {
+			if rec.Lease == nil && rec.Fill == nil {
+				records = append(records, rec)
+			}
+		}
+		return err
+	})
+	return records, err
+}
+
+// Fills returns the fills of all orders, oldest first.
+func (s *Store) Fills() ([]Fill, error) {
+	var fills []Fill
+	err := s.withFile(os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		all, err := s.read(f)
+		for _, rec := range all {
+			if rec.Fill != nil {
+				fills = append(fills, *rec.Fill)
+			}
+		}
+		return err
+	})
+	return fills, err
+}
+
+// Unfilled returns the records of live orders whose fill has not been recorded yet, oldest first.
+func (s *Store) Unfilled() ([]Record, error) {
+	var unfilled []Record
+	err := s.withFile(os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		all, err := s.read(f)
+		if err != nil {
+			return err
+		}
+		filled := make(map[string]bool)
+		for _, rec := range all {
+			if rec.Fill != nil {
+				filled[rec.Fill.Txid] = true
+			}
+		}
+		for _, rec := range all {
+			if !rec.DryRun && rec.Outcome == OutcomeSuccess && rec.Order != nil && rec.Order.Txid != "" && !filled[rec.Order.Txid] {
+				unfilled = append(unfilled, rec)
+			}
+		}
+		return nil
+	})
+	return unfilled, err
+}
+
+// Recent returns up to n of the latest records, newest first.
+func (s *Store) Recent(n int) ([]Record, error) {
+	records, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	recent := make([]Record, len(records))
+	for i, rec := range records {
+		recent[len(records)-1-i] = rec
+	}
+	return recent, nil
+}
+
+// withFile opens and locks the history file for the duration of fn.
+func (s *Store) withFile(flag int, fn func(f *os.File) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The default history file is in a state directory that may not exist yet
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create history file directory: %w", err)
+	}
+	f, err := os.OpenFile(s.Path, flag, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	unlock, err := lock.Exclusive(f)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn(f)
+}
+
+// read decodes all records from f. A truncated last line, left by a crash during a write, is ignored.
+func (s *Store) read(f *os.File) ([]Record, error) {
+	var records []Record
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history file: %w", err)
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("invalid history file %s, line %d: %w", s.Path, line, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// CostBasisPoint is the running cost basis after a filled order.
+type CostBasisPoint struct {
+	Time         time.Time     `json:"time"`          // Time the order was closed
+	Price        order.Decimal `json:"price"`         // Average execution price of the order
+	TotalFiat    order.Decimal `json:"total_fiat"`    // Fiat spent on all fills so far
+	TotalVolume  order.Decimal `json:"total_volume"`  // BTC bought by all fills so far
+	AveragePrice order.Decimal `json:"average_price"` // Average price paid so far (TotalFiat / TotalVolume)
+}
+
+// CostBasis returns the running cost basis of the fills for a trading pair, by the volume and cost
+// Kraken executed. Orders that did not fill at all are left out.
+func CostBasis(fills []Fill, pair string) []CostBasisPoint {
+	var points []CostBasisPoint
+	fiat, volume := order.Zero, order.Zero
+	for _, fill := range fills {
+		if fill.Pair != pair || fill.Volume.Sign() <= 0 {
+			continue
+		}
+		fiat = fiat.Add(fill.Cost)
+		volume = volume.Add(fill.Volume)
+		points = append(points, CostBasisPoint{
+			Time:         fill.Time,
+			Price:        fill.Price,
+			TotalFiat:    fiat,
+			TotalVolume:  volume,
+			AveragePrice: fiat.Div(volume, order.DecimalPlaces, order.RoundHalfEven),
+		})
+	}
+	return points
+}
@@ -0,0 +1,183 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/lock"
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+func TestStoreAppendAndRecent(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i, outcome := range []string{OutcomeSuccess, OutcomeSkipped, OutcomeError} {
+		rec := Record{RunID: string(rune('a' + i)), Started: start.AddDate(0, 0, i), Pair: "BTC/EUR", Outcome: outcome}
+		if err := s.Append(rec); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	recent, err := s.Recent(2)
+	if err != nil {
+		t.Fatalf("Recent returned error: %v", err)
+	}
+	if len(recent) != 2 || recent[0].RunID != "c" || recent[1].RunID != "b" {
+		t.Fatalf("expected runs c, b (newest first), got %+v", recent)
+	}
+	if !recent[0].Started.Equal(start.AddDate(0, 0, 2)) || recent[0].Outcome != OutcomeError {
+		t.Errorf("record not round-tripped: %+v", recent[0])
+	}
+
+	all, err := NewStore(s.Path).All()
+	if err != nil {
+		t.Fatalf("All returned error: %v", err)
+	}
+	if len(all) != 3 || all[0].RunID != "a" {
+		t.Errorf("expected 3 runs oldest first, got %+v", all)
+	}
+}
+
+func TestStoreMissingFile(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	recent, err := s.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent returned error: %v", err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("expected no records, got %+v", recent)
+	}
+}
+
+func TestStoreInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("{\"run_id\":\"a\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path).All(); err == nil {
+		t.Error("expected an error for an invalid line, got nil")
+	}
+}
+
+func TestStoreIgnoresTruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("{\"run_id\":\"a\"}\n{\"run_id\":\"b\",\"sta"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	all, err := NewStore(path).All()
+	if err != nil {
+		t.Fatalf("All returned error: %v", err)
+	}
+	if len(all) != 1 || all[0].RunID != "a" {
+		t.Errorf("expected only the complete record, got %+v", all)
+	}
+}
+
+func TestCostBasis(t *testing.T) {
+	d := order.MustParseDecimal
+	fill := func(pair, volume, cost string) Fill {
+		return Fill{Pair: pair, Status: "closed", Volume: d(volume), Cost: d(cost), Price: d(cost).Div(d(volume), order.DecimalPlaces, order.RoundHalfEven)}
+	}
+	fills := []Fill{
+		fill("BTC/EUR", "0.001", "40"),
+		{Pair: "BTC/EUR", Status: "expired", Volume: d("0"), Cost: d("0"), Price: d("0")},
+		fill("BTC/USD", "1", "1"),
+		fill("BTC/EUR", "0.001", "60"),
+	}
+
+	points := CostBasis(fills, "BTC/EUR")
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %+v", points)
+	}
+	last := points[1]
+	if last.TotalFiat != d("100") || last.TotalVolume != d("0.002") || last.AveragePrice != d("50000") {
+		t.Errorf("expected 100 fiat for 0.002 BTC at 50000, got %s for %s at %s", last.TotalFiat, last.TotalVolume, last.AveragePrice)
+	}
+	if last.Price != d("60000") {
+		t.Errorf("expected fill price 60000, got %s", last.Price)
+	}
+}
+
+func TestStoreFills(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	d := order.MustParseDecimal
+	for _, rec := range []Record{
+		{RunID: "a", Pair: "BTC/EUR", Outcome: OutcomeSuccess, Order: &Order{Txid: "OTX-1", Price: d("40000"), Volume: d("0.001")}},
+		{RunID: "b", Pair: "BTC/EUR", Outcome: OutcomeSuccess, Order: &Order{Txid: "OTX-2", Price: d("60000"), Volume: d("0.001")}},
+		{RunID: "c", Pair: "BTC/EUR", Outcome: OutcomeSuccess, DryRun: true, Order: &Order{Price: d("60000"), Volume: d("0.001")}},
+		{RunID: "d", Pair: "BTC/EUR", Outcome: OutcomeSkipped},
+		{Fill: &Fill{Pair: "BTC/EUR", Txid: "OTX-1", Status: "closed", Volume: d("0.0005"), Cost: d("20"), Price: d("40000")}},
+	} {
+		if err := s.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unfilled, err := s.Unfilled()
+	if err != nil {
+		t.Fatalf("Unfilled returned error: %v", err)
+	}
+	if len(unfilled) != 1 || unfilled[0].Order.Txid != "OTX-2" {
+		t.Errorf("expected only OTX-2 to await its fill, got %+v", unfilled)
+	}
+	fills, err := s.Fills()
+	if err != nil {
+		t.Fatalf("Fills returned error: %v", err)
+	}
+	if len(fills) != 1 || fills[0].Txid != "OTX-1" || fills[0].Volume != d("0.0005") {
+		t.Errorf("expected the fill of OTX-1, got %+v", fills)
+	}
+	if all, _ := s.All(); len(all) != 4 {
+		t.Errorf("expected fill records to be hidden from the runs, got %+v", all)
+	}
+}
+
+func TestLocker(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	release, err := s.Locker("daily").TryLock()
+	if err != nil {
+		t.Fatalf("expected to acquire lease, got %v", err)
+	}
+
+	// Another store on the same file stands for another host sharing the history
+	other := NewStore(s.Path)
+	if _, err := other.Locker("daily").TryLock(); !errors.Is(err, lock.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	otherRelease, err := other.Locker("weekly").TryLock()
+	if err != nil {
+		t.Fatalf("expected the lease of another plan to be independent, got %v", err)
+	}
+	otherRelease()
+
+	if err := release(); err != nil {
+		t.Fatalf("failed to release lease: %v", err)
+	}
+	release, err = other.Locker("daily").TryLock()
+	if err != nil {
+		t.Fatalf("expected to acquire lease after release, got %v", err)
+	}
+	release()
+
+	if records, err := s.All(); err != nil || len(records) != 0 {
+		t.Errorf("expected lease records to be hidden, got %+v (error %v)", records, err)
+	}
+}
+
+func TestLocker_Expired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	expired := fmt.Sprintf(`{"started":"0001-01-01T00:00:00Z","finished":"0001-01-01T00:00:00Z","pair":"","dry_run":false,"outcome":"","lease":{"name":"","holder":"crashed pid=1","expires":%q}}`+"\n",
+		time.Now().Add(-time.Minute).Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(expired), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	release, err := NewStore(path).Locker("").TryLock()
+	if err != nil {
+		t.Fatalf("expected to take over an expired lease, got %v", err)
+	}
+	release()
+}
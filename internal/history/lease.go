@@ -0,0 +1,113 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/lock"
+)
+
+// Lease is an instance lock held in the history file, for processes on different hosts that share it.
+// The latest lease record of a name decides who holds the lock.
+type Lease struct {
+	Name    string    `json:"name"`    // Lock name, the plan (empty if the configuration defines no plans)
+	Holder  string    `json:"holder"`  // Holder of the lease, e.g. "host pid=42 id=..."
+	Expires time.Time `json:"expires"` // End of the lease; a released lease expires when it is written
+}
+
+// leaseTTL is the lifetime of a lease. Holders renew it at a third of that, so a crashed holder
+// blocks other runs for at most leaseTTL.
+var leaseTTL = 5 * time.Minute
+
+// Locker implements lock.Locker with leases recorded in the history file.
+type Locker struct {
+	store *Store
+	name  string
+}
+
+// Locker returns a Locker for the lock name, typically the plan.
+func (s *Store) Locker(name string) *Locker {
+	return &Locker{store: s, name: name}
+}
+
+// TryLock acquires the lease unless another holder's lease has not expired, and renews it until released.
+func (l *Locker) TryLock() (func() error, error) {
+	holder := newHolder()
+	if err := l.write(holder, func(current *Lease) error {
+		if current != nil && current.Holder != holder && time.Now().Before(current.Expires) {
+			return fmt.Errorf("%w (history lease %q held by %s until %s)", lock.ErrLocked, l.name, current.Holder, current.Expires.Format(time.RFC3339))
+		}
+		return nil
+	}, leaseTTL); err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := l.write(holder, l.held(holder), leaseTTL); err != nil {
+					slog.Error("Failed to renew history lease", "lock", l.name, "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		wg.Wait()
+		return l.write(holder, l.held(holder), 0)
+	}, nil
+}
+
+// held returns a check that fails unless holder holds the current lease.
+func (l *Locker) held(holder string) func(current *Lease) error {
+	return func(current *Lease) error {
+		if current == nil || current.Holder != holder {
+			return fmt.Errorf("history lease %q was taken over by another run", l.name)
+		}
+		return nil
+	}
+}
+
+// write appends a lease of holder ending after ttl, if check accepts the current lease.
+// The history file stays locked between reading the current lease and writing the new one.
+func (l *Locker) write(holder string, check func(current *Lease) error, ttl time.Duration) error {
+	return l.store.withFile(os.O_RDWR|os.O_APPEND|os.O_CREATE, func(f *os.File) error {
+		records, err := l.store.read(f)
+		if err != nil {
+			return err
+		}
+		var current *Lease
+		for _, rec := range records {
+			if rec.Lease != nil && rec.Lease.Name == l.name {
+				current = rec.Lease
+			}
+		}
+		if err := check(current); err != nil {
+			return err
+		}
+		return l.store.write(f, Record{Lease: &Lease{Name: l.name, Holder: holder, Expires: time.Now().Add(ttl)}})
+	})
+}
+
+// newHolder returns a description of the calling process that is unique per lease.
+func newHolder() string {
+	host, _ := os.Hostname()
+	id := make([]byte, 4)
+	rand.Read(id)
+	return fmt.Sprintf("%s pid=%d id=%s", host, os.Getpid(), hex.EncodeToString(id))
+}
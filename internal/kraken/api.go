@@ -17,12 +17,17 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
+
+	"github.com/mayrf/easy-dca/internal/metrics"
+	"github.com/mayrf/easy-dca/internal/order"
 )
 
 // Request represents an HTTP request to the Kraken API.
@@ -36,49 +41,87 @@ type Request struct {
 	Environment string
 }
 
-// AddOrder places a new limit buy order on Kraken.
+// Environment is the base URL of the Kraken REST API. Tests point it at a fake server.
+var Environment = "https://api.kraken.com"
+
+// Kraken order types supported by AddOrder.
+const (
+	OrderTypeLimit  = "limit"
+	OrderTypeMarket = "market"
+)
+
+// OrderRequest describes a buy order to place with AddOrder.
+type OrderRequest struct {
+	Pair        string        // Trading pair, e.g. BTC/EUR
+	OrderType   string        // OrderTypeLimit or OrderTypeMarket
+	Price       order.Decimal // Limit price, already rounded to the pair's price tick (ignored for market orders)
+	Volume      order.Decimal // Order volume in the base currency, already rounded to the pair's lot size
+	PostOnly    bool          // Only add liquidity (oflags=post); limit orders only
+	TimeInForce string        // "GTC", "IOC" or "GTD" (optional; limit orders only)
+	ExpireTm    string        // Expiry as a unix timestamp or "+<seconds>" (optional; limit orders only)
+	ClOrdID     string        // Client order id (cl_ord_id, optional)
+	Validate    bool          // Only validate the order, do not place it
+}
+
+// AddOrder places a new buy order on Kraken.
 // Returns the parsed response and an error if the request fails or the API returns an error.
-func AddOrder(pair string, price float32, volume float32, publicKey string, privateKey string, validate bool) (*AddOrderResponse, error) {
-	resp, err := request(&Request{
-		Method: "POST",
-		Path:   "/0/private/AddOrder",
-		Body: map[string]any{
-			"ordertype": "limit",
-			"type":      "buy",
-			"volume":    volume,
-			"pair":   pair,
-			"price":  trimFloat32ToOneDecimal(price),
-			"oflags": "post",
-			"validate": validate,
-		},
+// API errors are returned as *APIError and can be classified with errors.Is (e.g. ErrInsufficientFunds).
+func AddOrder(req OrderRequest, publicKey string, privateKey string) (*AddOrderResponse, error) {
+	orderType := req.OrderType
+	if orderType == "" {
+		orderType = OrderTypeLimit
+	}
+	body := map[string]any{
+		"ordertype": orderType,
+		"type":      "buy",
+		"volume":    req.Volume,
+		"pair":      req.Pair,
+		"validate":  req.Validate,
+	}
+	if orderType == OrderTypeLimit {
+		body["price"] = req.Price
+		if req.PostOnly {
+			body["oflags"] = "post"
+		}
+		if req.TimeInForce != "" {
+			body["timeinforce"] = req.TimeInForce
+		}
+		if req.ExpireTm != "" {
+			body["expiretm"] = req.ExpireTm
+		}
+	}
+	if req.ClOrdID != "" {
+		body["cl_ord_id"] = req.ClOrdID
+	}
+	var response AddOrderResponse
+	err := call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/AddOrder",
+		Body:        body,
 		PublicKey:   publicKey,
 		PrivateKey:  privateKey,
-		Environment: "https://api.kraken.com",
-	})
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
+		Environment: Environment,
+	}, &response)
 	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return &response, err
+		}
 		return nil, err
 	}
-	
-	var response AddOrderResponse
-	if err := json.Unmarshal(data, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	if len(response.Error) > 0 {
-		return &response, fmt.Errorf("API Error: %v", response.Error)
-	}
-	
+
 	return &response, nil
 }
 
 // GetOrderBook fetches the order book for a trading pair from Kraken.
 // Returns the order book response or an error.
 func GetOrderBook(pair string, count int) (OrderBookResponse, error) {
+	response, err := getOrderBook(pair, count)
+	observeError(err)
+	return response, err
+}
+
+func getOrderBook(pair string, count int) (OrderBookResponse, error) {
 	resp, err := request(&Request{
 		Method: "GET",
 		Path:   "/0/public/Depth",
@@ -86,7 +129,7 @@ func GetOrderBook(pair string, count int) (OrderBookResponse, error) {
 			"pair":  pair,
 			"count": count,
 		},
-		Environment: "https://api.kraken.com",
+		Environment: Environment,
 	})
 	if err != nil {
 		return OrderBookResponse{}, err
@@ -97,22 +140,203 @@ func GetOrderBook(pair string, count int) (OrderBookResponse, error) {
 	if err != nil {
 		return OrderBookResponse{}, err
 	}
+	traceResponse("/0/public/Depth", resp.StatusCode, jsonData)
 
 	var response OrderBookResponse
 	if err := json.Unmarshal([]byte(jsonData), &response); err != nil {
 		return OrderBookResponse{}, err
 	}
 
-	if len(response.Error) > 0 {
-		return response, fmt.Errorf("API Error: %v", response.Error)
+	if err := newAPIError(response.Error); err != nil {
+		return response, err
 	}
 
 	return response, nil
 }
 
-// trimFloat32ToOneDecimal rounds down a float32 to one decimal place.
-func trimFloat32ToOneDecimal(f float32) float32 {
-	return float32(math.Floor(float64(f)*10) / 10)
+// GetOHLC fetches OHLC candles of the given interval (in minutes) for a trading pair,
+// starting after the unix timestamp since (0 returns the most recent candles Kraken keeps).
+// The last candle is the current, still changing one.
+func GetOHLC(pair string, interval int, since int64) ([]Candle, error) {
+	query := map[string]any{
+		"pair":     pair,
+		"interval": interval,
+	}
+	if since > 0 {
+		query["since"] = since
+	}
+	var response OHLCResponse
+	if err := call(&Request{
+		Method:      "GET",
+		Path:        "/0/public/OHLC",
+		Query:       query,
+		Environment: Environment,
+	}, &response); err != nil {
+		return nil, err
+	}
+	for key, raw := range response.Result {
+		if key == "last" {
+			continue
+		}
+		var candles []Candle
+		if err := json.Unmarshal(raw, &candles); err != nil {
+			return nil, fmt.Errorf("failed to parse OHLC candles: %w", err)
+		}
+		return candles, nil
+	}
+	return nil, fmt.Errorf("no OHLC data for %s", pair)
+}
+
+// GetSystemStatus fetches the current Kraken system status:
+// "online", "maintenance", "cancel_only" or "post_only".
+func GetSystemStatus() (string, error) {
+	var response SystemStatusResponse
+	if err := call(&Request{
+		Method:      "GET",
+		Path:        "/0/public/SystemStatus",
+		Environment: Environment,
+	}, &response); err != nil {
+		return "", err
+	}
+	return response.Result.Status, nil
+}
+
+// FindOrderByClientID looks up an open or closed order with the given client order id.
+// Returns nil without error if no such order exists.
+func FindOrderByClientID(clOrdID string, publicKey string, privateKey string) (*OrderInfo, error) {
+	var open OpenOrdersResponse
+	if err := call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/OpenOrders",
+		Body:        map[string]any{"cl_ord_id": clOrdID},
+		PublicKey:   publicKey,
+		PrivateKey:  privateKey,
+		Environment: Environment,
+	}, &open); err != nil {
+		return nil, fmt.Errorf("query open orders: %w", err)
+	}
+	if info := findClientOrder(open.Result.Open, clOrdID); info != nil {
+		return info, nil
+	}
+
+	var closed ClosedOrdersResponse
+	if err := call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/ClosedOrders",
+		Body:        map[string]any{"cl_ord_id": clOrdID},
+		PublicKey:   publicKey,
+		PrivateKey:  privateKey,
+		Environment: Environment,
+	}, &closed); err != nil {
+		return nil, fmt.Errorf("query closed orders: %w", err)
+	}
+	return findClientOrder(closed.Result.Closed, clOrdID), nil
+}
+
+// GetOpenOrders returns the open orders of the account, oldest first.
+func GetOpenOrders(publicKey string, privateKey string) ([]OrderInfo, error) {
+	var response OpenOrdersResponse
+	if err := call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/OpenOrders",
+		Body:        map[string]any{},
+		PublicKey:   publicKey,
+		PrivateKey:  privateKey,
+		Environment: Environment,
+	}, &response); err != nil {
+		return nil, err
+	}
+	orders := make([]OrderInfo, 0, len(response.Result.Open))
+	for txid, info := range response.Result.Open {
+		info.Txid = txid
+		orders = append(orders, info)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OpenTm < orders[j].OpenTm })
+	return orders, nil
+}
+
+// QueryOrder fetches the current state of the order with the given transaction ID.
+func QueryOrder(txid string, publicKey string, privateKey string) (*OrderInfo, error) {
+	var response QueryOrdersResponse
+	if err := call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/QueryOrders",
+		Body:        map[string]any{"txid": txid},
+		PublicKey:   publicKey,
+		PrivateKey:  privateKey,
+		Environment: Environment,
+	}, &response); err != nil {
+		return nil, err
+	}
+	info, ok := response.Result[txid]
+	if !ok {
+		return nil, fmt.Errorf("order %s not found", txid)
+	}
+	info.Txid = txid
+	return &info, nil
+}
+
+// CancelOrder cancels the open order with the given transaction ID.
+func CancelOrder(txid string, publicKey string, privateKey string) error {
+	var response CancelOrderResponse
+	return call(&Request{
+		Method:      "POST",
+		Path:        "/0/private/CancelOrder",
+		Body:        map[string]any{"txid": txid},
+		PublicKey:   publicKey,
+		PrivateKey:  privateKey,
+		Environment: Environment,
+	}, &response)
+}
+
+// observeError counts a failed request by error class.
+func observeError(err error) {
+	if err != nil {
+		metrics.KrakenErrorsTotal.Inc(ErrorClass(err))
+	}
+}
+
+// findClientOrder returns the order with the given client order id from a txid-keyed order map.
+func findClientOrder(orders map[string]OrderInfo, clOrdID string) *OrderInfo {
+	for txid, info := range orders {
+		if info.ClOrdID == clOrdID {
+			info.Txid = txid
+			return &info
+		}
+	}
+	return nil
+}
+
+// call sends a request and decodes the JSON response into out, which must embed the Kraken error list.
+// API errors reported by Kraken are returned as *APIError. A private request rejected with
+// an invalid nonce is retried once with a fresh nonce; Kraken did not process it, so this is safe.
+func call(c *Request, out interface{ apiErrors() []string }) error {
+	err := send(c, out)
+	if errors.Is(err, ErrInvalidNonce) && len(c.PublicKey) > 0 {
+		slog.Warn("Kraken rejected the nonce, retrying once with a new nonce", "path", c.Path)
+		delete(c.Body, "nonce")
+		err = send(c, out)
+	}
+	observeError(err)
+	return err
+}
+
+// send performs a single request for call.
+func send(c *Request, out interface{ apiErrors() []string }) error {
+	resp, err := request(c)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	traceResponse(c.Path, resp.StatusCode, data)
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return newAPIError(out.apiErrors())
 }
 
 func request(c *Request) (*http.Response, error) {
@@ -135,7 +359,11 @@ func request(c *Request) (*http.Response, error) {
 		var ok bool
 		nonce, ok = bodyMap["nonce"]
 		if !ok {
-			nonce = getNonce()
+			var err error
+			nonce, err = getNonce()
+			if err != nil {
+				return nil, err
+			}
 			bodyMap["nonce"] = nonce
 		}
 	}
@@ -164,11 +392,11 @@ func request(c *Request) (*http.Response, error) {
 		headers.Set("API-Sign", signature)
 	}
 	request.Header = headers
-	return http.DefaultClient.Do(request)
-}
-
-func getNonce() string {
-	return fmt.Sprint(time.Now().UnixMilli())
+	traceRequest(c.Method, url, headers, bodyMap)
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(request)
+	metrics.KrakenRequestDuration.Observe(time.Since(start).Seconds(), c.Path)
+	return resp, err
 }
 
 func getSignature(privateKey string, data string, nonce string, path string) (string, error) {
@@ -183,6 +411,7 @@ func sign(privateKey string, message []byte) (string, error) {
 		return "", err
 	}
 	hmacHash := hmac.New(sha512.New, key)
+	clear(key) // hmac keeps its own copy of the key
 	hmacHash.Write(message)
 	return base64.StdEncoding.EncodeToString(hmacHash.Sum(nil)), nil
 }
@@ -205,25 +434,3 @@ func mapToURLValues(m map[string]any) (url.Values, error) {
 	}
 	return uv, nil
 }
-
-// FormatOrderResponse creates a nice log message from the AddOrder response
-func FormatOrderResponse(response *AddOrderResponse, isDryRun bool) string {
-	if response == nil {
-		return "No response received"
-	}
-	
-	mode := "DRY RUN"
-	if !isDryRun {
-		mode = "LIVE ORDER"
-	}
-	
-	orderDesc := response.Result.Descr.Order
-	
-	if len(response.Result.Txid) > 0 {
-		return fmt.Sprintf("[%s] Order placed successfully! Transaction ID: %s | %s", 
-			mode, response.Result.Txid[0], orderDesc)
-	} else {
-		return fmt.Sprintf("[%s] Order validated successfully! | %s", 
-			mode, orderDesc)
-	}
-} 
\ No newline at end of file
@@ -1,25 +1,115 @@
 package kraken
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
-func TestTrimFloat32ToOneDecimal(t *testing.T) {
+// fakeKraken points the API at a server answering each path with the given JSON response,
+// and records the decoded request bodies by path.
+func fakeKraken(t *testing.T, responses map[string]string) map[string]map[string]any {
+	t.Helper()
+	bodies := make(map[string]map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies[r.URL.Path] = body
+		w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	orig := Environment
+	Environment = srv.URL
+	t.Cleanup(func() { Environment = orig })
+	return bodies
+}
+
+func TestFindOrderByClientID_Open(t *testing.T) {
+	bodies := fakeKraken(t, map[string]string{
+		"/0/private/OpenOrders": `{"error":[],"result":{"open":{
+			"OABC-1":{"cl_ord_id":"other","status":"open"},
+			"OABC-2":{"cl_ord_id":"easy-dca-slot","status":"open","vol":"0.001"}}}}`,
+	})
+	info, err := FindOrderByClientID("easy-dca-slot", "key", "c2VjcmV0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info == nil || info.Txid != "OABC-2" || info.Status != "open" {
+		t.Fatalf("expected open order OABC-2, got %+v", info)
+	}
+	if got := bodies["/0/private/OpenOrders"]["cl_ord_id"]; got != "easy-dca-slot" {
+		t.Errorf("expected OpenOrders to filter by cl_ord_id, got %v", got)
+	}
+}
+
+func TestFindOrderByClientID_Closed(t *testing.T) {
+	fakeKraken(t, map[string]string{
+		"/0/private/OpenOrders":   `{"error":[],"result":{"open":{}}}`,
+		"/0/private/ClosedOrders": `{"error":[],"result":{"closed":{"OXYZ-1":{"cl_ord_id":"easy-dca-slot","status":"closed"}},"count":1}}`,
+	})
+	info, err := FindOrderByClientID("easy-dca-slot", "key", "c2VjcmV0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info == nil || info.Txid != "OXYZ-1" || info.Status != "closed" {
+		t.Fatalf("expected closed order OXYZ-1, got %+v", info)
+	}
+}
+
+func TestFindOrderByClientID_NotFound(t *testing.T) {
+	fakeKraken(t, map[string]string{
+		"/0/private/OpenOrders":   `{"error":[],"result":{"open":{}}}`,
+		"/0/private/ClosedOrders": `{"error":[],"result":{"closed":{},"count":0}}`,
+	})
+	info, err := FindOrderByClientID("easy-dca-slot", "key", "c2VjcmV0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info != nil {
+		t.Errorf("expected no order, got %+v", info)
+	}
+}
+
+func TestFindOrderByClientID_Error(t *testing.T) {
+	fakeKraken(t, map[string]string{
+		"/0/private/OpenOrders": `{"error":["EGeneral:Permission denied"]}`,
+	})
+	if _, err := FindOrderByClientID("easy-dca-slot", "key", "c2VjcmV0"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+}
+
+func TestAddOrder_Retryable(t *testing.T) {
 	tests := []struct {
-		input    float32
-		want     float32
+		code      string
+		retryable bool
 	}{
-		{1.234, 1.2},
-		{1.25, 1.2},
-		{1.26, 1.2},
-		{0.0, 0.0},
-		{-1.27, -1.3},
-		{2.99, 2.9},
-	}
-	for _, tc := range tests {
-		got := trimFloat32ToOneDecimal(tc.input)
-		if got != tc.want {
-			t.Errorf("trimFloat32ToOneDecimal(%v) = %v; want %v", tc.input, got, tc.want)
-		}
+		{"EAPI:Rate limit exceeded", true},
+		{"EOrder:Rate limit exceeded", true},
+		// Kraken may have accepted the order before failing the request
+		{"EService:Unavailable", false},
+		{"EService:Busy", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			fakeKraken(t, map[string]string{
+				"/0/private/AddOrder": `{"error":["` + tt.code + `"]}`,
+			})
+			_, err := AddOrder(OrderRequest{Pair: "BTC/EUR", ClOrdID: "easy-dca-slot"}, "key", "c2VjcmV0")
+			if !IsTemporary(err) {
+				t.Errorf("expected a temporary error, got %v", err)
+			}
+			if got := IsOrderRetryable(err); got != tt.retryable {
+				t.Errorf("IsOrderRetryable(%v) = %v; want %v", err, got, tt.retryable)
+			}
+		})
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,153 @@
+package kraken
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error classes returned by the Kraken API. An *APIError wraps every class
+// matching one of its codes, so callers can test them with errors.Is.
+var (
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInvalidNonce       = errors.New("invalid nonce")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrPostOnlyWouldTake  = errors.New("post-only order would take liquidity")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrAccountRestricted  = errors.New("account restricted")
+	ErrMarketClosed       = errors.New("market closed")
+	ErrInvalidKey         = errors.New("invalid API key")
+	ErrInvalidSignature   = errors.New("invalid signature")
+	ErrOrderMinimumNotMet = errors.New("order minimum not met")
+	ErrInvalidArguments   = errors.New("invalid arguments")
+	ErrUnknownPair        = errors.New("unknown asset pair")
+	ErrUnavailable        = errors.New("service unavailable")
+)
+
+// errorClasses maps lower-cased Kraken error codes ("<category>:<message>") to error classes.
+// Any "EService:Market in ... mode" code is classified as ErrMarketClosed by classifyCode.
+var errorClasses = map[string]error{
+	"eorder:insufficient funds":         ErrInsufficientFunds,
+	"eapi:invalid nonce":                ErrInvalidNonce,
+	"eapi:rate limit exceeded":          ErrRateLimited,
+	"eorder:rate limit exceeded":        ErrRateLimited,
+	"eorder:orders limit exceeded":      ErrRateLimited,
+	"egeneral:too many requests":        ErrRateLimited,
+	"egeneral:temporary lockout":        ErrRateLimited,
+	"eorder:post only order":            ErrPostOnlyWouldTake,
+	"egeneral:permission denied":        ErrPermissionDenied,
+	"eapi:feature disabled":             ErrPermissionDenied,
+	"eorder:trading agreement required": ErrAccountRestricted,
+	"eapi:invalid key":                  ErrInvalidKey,
+	"eapi:invalid signature":            ErrInvalidSignature,
+	"eorder:order minimum not met":      ErrOrderMinimumNotMet,
+	"eorder:cost minimum not met":       ErrOrderMinimumNotMet,
+	"egeneral:invalid arguments":        ErrInvalidArguments,
+	"equery:unknown asset pair":         ErrUnknownPair,
+	"eservice:unavailable":              ErrUnavailable,
+	"eservice:busy":                     ErrUnavailable,
+	"eorder:insufficient margin":        ErrInsufficientFunds,
+}
+
+// APIError is returned when the Kraken API responds with one or more error codes.
+type APIError struct {
+	Codes []string // Raw error codes as returned by Kraken, e.g. "EOrder:Insufficient funds"
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API Error: %v", e.Codes)
+}
+
+// Unwrap returns the error classes matching the API error codes.
+func (e *APIError) Unwrap() []error {
+	var classes []error
+	for _, code := range e.Codes {
+		if class := classifyCode(code); class != nil {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// newAPIError returns an *APIError for the given codes, or nil if there are none.
+func newAPIError(codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	return &APIError{Codes: codes}
+}
+
+// classifyCode returns the error class for a single Kraken error code, or nil if it is unknown.
+func classifyCode(code string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if class, ok := errorClasses[code]; ok {
+		return class
+	}
+	// Some codes carry extra detail, e.g. "EGeneral:Invalid arguments:volume"
+	if i := strings.Index(code, ":"); i >= 0 {
+		if j := strings.Index(code[i+1:], ":"); j >= 0 {
+			if class, ok := errorClasses[code[:i+1+j]]; ok {
+				return class
+			}
+		}
+	}
+	if strings.HasPrefix(code, "eservice:market in") {
+		return ErrMarketClosed
+	}
+	return nil
+}
+
+// ErrorClass returns a short, stable name for the class of err, suitable for logs and metrics.
+// It returns "unknown" for Kraken API errors of an unknown class and "other" for non-API errors.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, ErrInvalidNonce):
+		return "invalid_nonce"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrPostOnlyWouldTake):
+		return "post_only_would_take"
+	case errors.Is(err, ErrPermissionDenied):
+		return "permission_denied"
+	case errors.Is(err, ErrAccountRestricted):
+		return "account_restricted"
+	case errors.Is(err, ErrMarketClosed):
+		return "market_closed"
+	case errors.Is(err, ErrInvalidKey):
+		return "invalid_key"
+	case errors.Is(err, ErrInvalidSignature):
+		return "invalid_signature"
+	case errors.Is(err, ErrOrderMinimumNotMet):
+		return "order_minimum_not_met"
+	case errors.Is(err, ErrInvalidArguments):
+		return "invalid_arguments"
+	case errors.Is(err, ErrUnknownPair):
+		return "unknown_pair"
+	case errors.Is(err, ErrUnavailable):
+		return "unavailable"
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return "unknown"
+	}
+	return "other"
+}
+
+// IsTemporary reports whether err is a transient Kraken error that is worth retrying later.
+// Kraken may still have processed a request that failed with ErrUnavailable, so orders are
+// retried only if IsOrderRetryable.
+func IsTemporary(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// IsOrderRetryable reports whether an AddOrder request that failed with err can be sent again
+// without risking a second order. Rate limits reject the request before Kraken looks at the order;
+// with EService:Unavailable or EService:Busy the order may already have been accepted.
+func IsOrderRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
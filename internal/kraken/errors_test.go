@@ -0,0 +1,74 @@
+package kraken
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		code  string
+		want  error
+		class string
+	}{
+		{"EOrder:Insufficient funds", ErrInsufficientFunds, "insufficient_funds"},
+		{"EAPI:Invalid nonce", ErrInvalidNonce, "invalid_nonce"},
+		{"EAPI:Rate limit exceeded", ErrRateLimited, "rate_limited"},
+		{"EOrder:Post only order", ErrPostOnlyWouldTake, "post_only_would_take"},
+		{"EGeneral:Permission denied", ErrPermissionDenied, "permission_denied"},
+		{"EOrder:Trading agreement required", ErrAccountRestricted, "account_restricted"},
+		{"EService:Market in cancel_only mode", ErrMarketClosed, "market_closed"},
+		{"EService:Market in maintenance mode", ErrMarketClosed, "market_closed"},
+		{"EAPI:Invalid key", ErrInvalidKey, "invalid_key"},
+		{"EGeneral:Invalid arguments:volume", ErrInvalidArguments, "invalid_arguments"},
+		{"EQuery:Unknown asset pair", ErrUnknownPair, "unknown_pair"},
+	}
+	for _, tc := range tests {
+		err := newAPIError([]string{tc.code})
+		if !errors.Is(err, tc.want) {
+			t.Errorf("errors.Is(%q, %v) = false; want true", tc.code, tc.want)
+		}
+		if got := ErrorClass(err); got != tc.class {
+			t.Errorf("ErrorClass(%q) = %q; want %q", tc.code, got, tc.class)
+		}
+	}
+}
+
+func TestAPIErrorWrapped(t *testing.T) {
+	err := fmt.Errorf("failed to add order: %w", newAPIError([]string{"EGeneral:Unknown method", "EOrder:Insufficient funds"}))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected wrapped error to match ErrInsufficientFunds")
+	}
+	if errors.Is(err, ErrRateLimited) {
+		t.Errorf("did not expect wrapped error to match ErrRateLimited")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || len(apiErr.Codes) != 2 {
+		t.Errorf("expected *APIError with 2 codes, got %v", apiErr)
+	}
+	if got := err.Error(); got != "failed to add order: API Error: [EGeneral:Unknown method EOrder:Insufficient funds]" {
+		t.Errorf("unexpected error message: %s", got)
+	}
+}
+
+func TestErrorClassUnknown(t *testing.T) {
+	if got := ErrorClass(newAPIError([]string{"EGeneral:Unknown method"})); got != "unknown" {
+		t.Errorf("ErrorClass(unknown API error) = %q; want \"unknown\"", got)
+	}
+	if got := ErrorClass(errors.New("connection refused")); got != "other" {
+		t.Errorf("ErrorClass(non-API error) = %q; want \"other\"", got)
+	}
+	if newAPIError(nil) != nil {
+		t.Errorf("newAPIError(nil) should return nil")
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	if !IsTemporary(newAPIError([]string{"EAPI:Rate limit exceeded"})) {
+		t.Errorf("rate limit errors should be temporary")
+	}
+	if IsTemporary(newAPIError([]string{"EOrder:Insufficient funds"})) {
+		t.Errorf("insufficient funds errors should not be temporary")
+	}
+}
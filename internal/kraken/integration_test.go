@@ -74,37 +74,37 @@ func validateOrderBook(t *testing.T, pair string, orderBook order.OrderBook, exp
 
 	// Validate ask orders (should be sorted by price ascending)
 	for i, ask := range orderBook.Asks {
-		if ask.Price <= 0 {
-			t.Errorf("Ask %d for %s has invalid price: %f", i, pair, ask.Price)
+		if ask.Price.Sign() <= 0 {
+			t.Errorf("Ask %d for %s has invalid price: %s", i, pair, ask.Price)
 		}
-		if ask.Volume <= 0 {
-			t.Errorf("Ask %d for %s has invalid volume: %f", i, pair, ask.Volume)
+		if ask.Volume.Sign() <= 0 {
+			t.Errorf("Ask %d for %s has invalid volume: %s", i, pair, ask.Volume)
 		}
 		if ask.Timestamp <= 0 {
 			t.Errorf("Ask %d for %s has invalid timestamp: %f", i, pair, ask.Timestamp)
 		}
 
 		// Check that asks are sorted by price (ascending)
-		if i > 0 && ask.Price < orderBook.Asks[i-1].Price {
-			t.Errorf("Asks for %s are not sorted by price: %f < %f", pair, ask.Price, orderBook.Asks[i-1].Price)
+		if i > 0 && ask.Price.LessThan(orderBook.Asks[i-1].Price) {
+			t.Errorf("Asks for %s are not sorted by price: %s < %s", pair, ask.Price, orderBook.Asks[i-1].Price)
 		}
 	}
 
 	// Validate bid orders (should be sorted by price descending)
 	for i, bid := range orderBook.Bids {
-		if bid.Price <= 0 {
-			t.Errorf("Bid %d for %s has invalid price: %f", i, pair, bid.Price)
+		if bid.Price.Sign() <= 0 {
+			t.Errorf("Bid %d for %s has invalid price: %s", i, pair, bid.Price)
 		}
-		if bid.Volume <= 0 {
-			t.Errorf("Bid %d for %s has invalid volume: %f", i, pair, bid.Volume)
+		if bid.Volume.Sign() <= 0 {
+			t.Errorf("Bid %d for %s has invalid volume: %s", i, pair, bid.Volume)
 		}
 		if bid.Timestamp <= 0 {
 			t.Errorf("Bid %d for %s has invalid timestamp: %f", i, pair, bid.Timestamp)
 		}
 
 		// Check that bids are sorted by price (descending)
-		if i > 0 && bid.Price > orderBook.Bids[i-1].Price {
-			t.Errorf("Bids for %s are not sorted by price: %f > %f", pair, bid.Price, orderBook.Bids[i-1].Price)
+		if i > 0 && bid.Price.GreaterThan(orderBook.Bids[i-1].Price) {
+			t.Errorf("Bids for %s are not sorted by price: %s > %s", pair, bid.Price, orderBook.Bids[i-1].Price)
 		}
 	}
 
@@ -112,8 +112,8 @@ func validateOrderBook(t *testing.T, pair string, orderBook order.OrderBook, exp
 	if len(orderBook.Asks) > 0 && len(orderBook.Bids) > 0 {
 		bestAsk := orderBook.Asks[0].Price
 		bestBid := orderBook.Bids[0].Price
-		if bestAsk <= bestBid {
-			t.Errorf("Invalid bid-ask spread for %s: best ask (%f) <= best bid (%f)", pair, bestAsk, bestBid)
+		if !bestAsk.GreaterThan(bestBid) {
+			t.Errorf("Invalid bid-ask spread for %s: best ask (%s) <= best bid (%s)", pair, bestAsk, bestBid)
 		}
 	}
 
@@ -122,9 +122,9 @@ func validateOrderBook(t *testing.T, pair string, orderBook order.OrderBook, exp
 	if len(orderBook.Asks) > 0 && len(orderBook.Bids) > 0 {
 		bestAsk := orderBook.Asks[0].Price
 		bestBid := orderBook.Bids[0].Price
-		spread := bestAsk - bestBid
-		spreadPercent := (spread / bestAsk) * 100
-		t.Logf("Best ask: %f, Best bid: %f, Spread: %f (%f%%)", bestAsk, bestBid, spread, spreadPercent)
+		spread := bestAsk.Sub(bestBid)
+		spreadPercent := spread.Float64() / bestAsk.Float64() * 100
+		t.Logf("Best ask: %s, Best bid: %s, Spread: %s (%f%%)", bestAsk, bestBid, spread, spreadPercent)
 	}
 }
 
@@ -0,0 +1,132 @@
+package kraken
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/lock"
+)
+
+// NonceProvider generates nonces for private API calls.
+// Kraken rejects any nonce that is not larger than the last one seen for an API key.
+type NonceProvider interface {
+	// Next returns a nonce strictly greater than every nonce previously returned.
+	Next() (uint64, error)
+}
+
+// nonceProvider is the provider used by private API calls; see SetNonceProvider.
+var (
+	nonceMu       sync.Mutex
+	nonceProvider NonceProvider = NewMemoryNonceProvider()
+)
+
+// SetNonceProvider sets the nonce provider used for all private API calls.
+func SetNonceProvider(p NonceProvider) {
+	nonceMu.Lock()
+	defer nonceMu.Unlock()
+	nonceProvider = p
+}
+
+// getNonce returns the next nonce from the configured provider.
+func getNonce() (string, error) {
+	nonceMu.Lock()
+	p := nonceProvider
+	nonceMu.Unlock()
+	n, err := p.Next()
+	if err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return strconv.FormatUint(n, 10), nil
+}
+
+// nextNonce returns the current time in microseconds, or last+1 if the clock has not moved past last.
+func nextNonce(last uint64) uint64 {
+	now := uint64(time.Now().UnixMicro())
+	if now <= last {
+		return last + 1
+	}
+	return now
+}
+
+// MemoryNonceProvider generates strictly increasing microsecond nonces within a single process.
+type MemoryNonceProvider struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// NewMemoryNonceProvider creates a new in-process nonce provider.
+func NewMemoryNonceProvider() *MemoryNonceProvider {
+	return &MemoryNonceProvider{}
+}
+
+// Next returns the next nonce.
+func (p *MemoryNonceProvider) Next() (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = nextNonce(p.last)
+	return p.last, nil
+}
+
+// FileNonceProvider generates strictly increasing microsecond nonces persisted in a file.
+// The file is locked while a nonce is generated, so runs and processes sharing the file
+// (and therefore the API key) never reuse or go back on a nonce, even after a clock jump.
+type FileNonceProvider struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileNonceProvider creates a new file-backed nonce provider.
+func NewFileNonceProvider(path string) *FileNonceProvider {
+	return &FileNonceProvider{Path: path}
+}
+
+// Next returns the next nonce and stores it in the nonce file.
+func (p *FileNonceProvider) Next() (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The default nonce file is in a state directory that may not exist yet
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create nonce file directory: %w", err)
+	}
+	f, err := os.OpenFile(p.Path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open nonce file: %w", err)
+	}
+	defer f.Close()
+
+	unlock, err := lock.Exclusive(f)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	content, err := os.ReadFile(p.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read nonce file: %w", err)
+	}
+	var last uint64
+	if s := strings.TrimSpace(string(content)); s != "" {
+		last, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid nonce file %s: %w", p.Path, err)
+		}
+	}
+
+	next := nextNonce(last)
+	if err := f.Truncate(0); err != nil {
+		return 0, fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	if _, err := f.WriteAt([]byte(strconv.FormatUint(next, 10)+"\n"), 0); err != nil {
+		return 0, fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	return next, nil
+}
@@ -0,0 +1,101 @@
+package kraken
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceProvider_StrictlyIncreasing(t *testing.T) {
+	p := NewMemoryNonceProvider()
+	var last uint64
+	for i := 0; i < 1000; i++ {
+		n, err := p.Next()
+		if err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+		if n <= last {
+			t.Fatalf("nonce %d is not greater than previous nonce %d", n, last)
+		}
+		last = n
+	}
+}
+
+func TestFileNonceProvider_Persistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+
+	// A nonce far in the future simulates a clock that jumped backwards since the last run
+	future := uint64(time.Now().Add(time.Hour).UnixMicro())
+	if err := os.WriteFile(path, []byte(strconv.FormatUint(future, 10)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewFileNonceProvider(path).Next()
+	if err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if n != future+1 {
+		t.Errorf("Next() = %d; want %d", n, future+1)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(content)); got != strconv.FormatUint(n, 10) {
+		t.Errorf("nonce file contains %q; want %d", got, n)
+	}
+}
+
+func TestFileNonceProvider_CreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "easy-dca", "nonce")
+	if _, err := NewFileNonceProvider(path).Next(); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected nonce file to be created: %v", err)
+	}
+}
+
+func TestFileNonceProvider_SharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+	providers := []NonceProvider{NewFileNonceProvider(path), NewFileNonceProvider(path)}
+
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for _, p := range providers {
+		wg.Add(1)
+		go func(p NonceProvider) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				n, err := p.Next()
+				if err != nil {
+					t.Errorf("Next() failed: %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[n] {
+					t.Errorf("nonce %d was returned twice", n)
+				}
+				seen[n] = true
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+func TestFileNonceProvider_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+	if err := os.WriteFile(path, []byte("not a number"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileNonceProvider(path).Next(); err == nil {
+		t.Error("expected error for invalid nonce file")
+	}
+}
@@ -0,0 +1,89 @@
+package kraken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Permission is a permission of a Kraken API key, named as on the Kraken API key settings page.
+type Permission string
+
+// Permissions inferred by ProbePermissions.
+const (
+	PermissionQueryFunds        Permission = "Query Funds"
+	PermissionWithdrawFunds     Permission = "Withdraw Funds"
+	PermissionQueryOpenOrders   Permission = "Query Open Orders & Trades"
+	PermissionQueryClosedOrders Permission = "Query Closed Orders & Trades"
+	PermissionCreateOrders      Permission = "Create & Modify Orders"
+	PermissionCancelOrders      Permission = "Cancel & Close Orders"
+)
+
+// Permissions maps each probed permission to whether the API key has it.
+type Permissions map[Permission]bool
+
+// probeResponse is the response of a probing request, whose result is not used.
+type probeResponse struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (r *probeResponse) apiErrors() []string { return r.Error }
+
+// ProbePermissions infers the permissions of an API key with private requests that change nothing:
+// Balance, OpenOrders, ClosedOrders, WithdrawMethods, CancelOrder without an order id and AddOrder with
+// validate set, so Kraken only validates the order. A request rejected with EGeneral:Permission denied means the key lacks the
+// permission; any other answer, including other API errors, means Kraken accepted it.
+// Returns an error if Kraken rejects the key itself (ErrInvalidKey, ErrInvalidSignature) or a request fails.
+func ProbePermissions(validateOrder OrderRequest, publicKey string, privateKey string) (Permissions, error) {
+	validateOrder.Validate = true
+	return probePermissions(func(path string, body map[string]any) error {
+		if path == "/0/private/AddOrder" {
+			_, err := AddOrder(validateOrder, publicKey, privateKey)
+			return err
+		}
+		var response probeResponse
+		return call(&Request{
+			Method:      "POST",
+			Path:        path,
+			Body:        body,
+			PublicKey:   publicKey,
+			PrivateKey:  privateKey,
+			Environment: Environment,
+		}, &response)
+	})
+}
+
+// probePermissions performs the requests of ProbePermissions with send.
+func probePermissions(send func(path string, body map[string]any) error) (Permissions, error) {
+	probes := []struct {
+		permission Permission
+		path       string
+	}{
+		{PermissionQueryFunds, "/0/private/Balance"},
+		{PermissionQueryOpenOrders, "/0/private/OpenOrders"},
+		{PermissionQueryClosedOrders, "/0/private/ClosedOrders"},
+		{PermissionWithdrawFunds, "/0/private/WithdrawMethods"},
+		{PermissionCreateOrders, "/0/private/AddOrder"},
+		{PermissionCancelOrders, "/0/private/CancelOrder"},
+	}
+	perms := make(Permissions, len(probes))
+	for _, p := range probes {
+		err := send(p.path, map[string]any{})
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			perms[p.permission] = true
+		case errors.Is(err, ErrInvalidKey), errors.Is(err, ErrInvalidSignature):
+			return nil, err
+		case errors.Is(err, ErrPermissionDenied):
+			perms[p.permission] = false
+		case errors.As(err, &apiErr):
+			// Kraken checks permissions first, so any other API error means the permission was granted
+			perms[p.permission] = true
+		default:
+			return nil, fmt.Errorf("probe %s: %w", p.path, err)
+		}
+	}
+	return perms, nil
+}
@@ -0,0 +1,59 @@
+package kraken
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProbePermissions(t *testing.T) {
+	answers := map[string]error{
+		"/0/private/Balance":         newAPIError([]string{"EGeneral:Permission denied"}),
+		"/0/private/OpenOrders":      nil,
+		"/0/private/ClosedOrders":    nil,
+		"/0/private/WithdrawMethods": nil,
+		"/0/private/AddOrder":        newAPIError([]string{"EOrder:Insufficient funds"}),
+		"/0/private/CancelOrder":     newAPIError([]string{"EGeneral:Invalid arguments"}),
+	}
+	perms, err := probePermissions(func(path string, body map[string]any) error {
+		return answers[path]
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := Permissions{
+		PermissionQueryFunds:        false,
+		PermissionQueryOpenOrders:   true,
+		PermissionQueryClosedOrders: true,
+		PermissionWithdrawFunds:     true,
+		PermissionCreateOrders:      true, // Rejected after the permission check
+		PermissionCancelOrders:      true, // Rejected for the missing order id after the permission check
+	}
+	for p, granted := range want {
+		if perms[p] != granted {
+			t.Errorf("%s: expected %v, got %v", p, granted, perms[p])
+		}
+	}
+}
+
+func TestProbePermissions_InvalidKey(t *testing.T) {
+	calls := 0
+	_, err := probePermissions(func(path string, body map[string]any) error {
+		calls++
+		return newAPIError([]string{"EAPI:Invalid key"})
+	})
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected probing to stop after the first request, got %d requests", calls)
+	}
+}
+
+func TestProbePermissions_RequestFailed(t *testing.T) {
+	_, err := probePermissions(func(path string, body map[string]any) error {
+		return errors.New("connection refused")
+	})
+	if err == nil || ErrorClass(err) != "other" {
+		t.Errorf("expected a request error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package kraken
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// redacted replaces secrets in debug traces.
+const redacted = "[REDACTED]"
+
+// maxTraceBody is the maximum number of response body bytes included in a debug trace.
+const maxTraceBody = 4096
+
+// secretHeaders and secretFields are never logged in clear text.
+var (
+	secretHeaders = map[string]bool{"Api-Key": true, "Api-Sign": true, "Authorization": true}
+	secretFields  = map[string]bool{"otp": true, "password": true}
+)
+
+// tracing reports whether request and response tracing is enabled (debug level).
+func tracing() bool {
+	return slog.Default().Enabled(context.Background(), slog.LevelDebug)
+}
+
+// traceRequest logs an outgoing request at debug level with credentials redacted.
+func traceRequest(method, url string, headers http.Header, body map[string]any) {
+	if !tracing() {
+		return
+	}
+	slog.Debug("Kraken request", "method", method, "url", url, "headers", redactHeaders(headers), "body", redactFields(body))
+}
+
+// traceResponse logs a response at debug level, truncating long bodies.
+func traceResponse(path string, status int, body []byte) {
+	if !tracing() {
+		return
+	}
+	truncated := len(body) > maxTraceBody
+	if truncated {
+		body = body[:maxTraceBody]
+	}
+	slog.Debug("Kraken response", "path", path, "status", status, "body", string(body), "truncated", truncated)
+}
+
+// redactHeaders returns the headers as a flat map with secret values replaced.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if secretHeaders[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// redactFields returns a copy of a request body with secret fields replaced.
+func redactFields(body map[string]any) map[string]any {
+	if body == nil {
+		return nil
+	}
+	out := make(map[string]any, len(body))
+	for k, v := range body {
+		if secretFields[strings.ToLower(k)] {
+			v = redacted
+		}
+		out[k] = v
+	}
+	return out
+}
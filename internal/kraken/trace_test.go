@@ -0,0 +1,42 @@
+package kraken
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTraceRequestRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(orig *slog.Logger) { slog.SetDefault(orig) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	headers := make(http.Header)
+	headers.Set("API-Key", "my-public-key")
+	headers.Set("API-Sign", "my-signature")
+	headers.Set("Content-Type", "application/json")
+	traceRequest("POST", "https://api.kraken.com/0/private/AddOrder", headers, map[string]any{"otp": "123456", "pair": "BTC/EUR"})
+
+	out := buf.String()
+	for _, secret := range []string{"my-public-key", "my-signature", "123456"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("trace contains secret %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "BTC/EUR") || !strings.Contains(out, "application/json") {
+		t.Errorf("trace is missing non-secret fields: %s", out)
+	}
+}
+
+func TestTraceDisabledAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(orig *slog.Logger) { slog.SetDefault(orig) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	traceResponse("/0/public/Depth", 200, []byte(`{"error":[]}`))
+	if buf.Len() != 0 {
+		t.Errorf("expected no trace at info level, got %s", buf.String())
+	}
+}
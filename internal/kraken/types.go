@@ -1,22 +1,137 @@
 // Package kraken provides types for Kraken API responses.
 package kraken
 
-import "github.com/mayrf/easy-dca/internal/order"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
 
 // OrderBookResponse represents the complete API response structure from Kraken
 // It uses the generic OrderBook type from the order package
 type OrderBookResponse struct {
-	Error  []string                `json:"error"` // List of error messages from the API
+	Error  []string                   `json:"error"`  // List of error messages from the API
 	Result map[string]order.OrderBook `json:"result"` // Map of trading pair to order book
 }
 
 // AddOrderResponse represents the response from the AddOrder API call
 type AddOrderResponse struct {
-	Error  []string `json:"error"`  // List of error messages from the API
+	Error  []string `json:"error"` // List of error messages from the API
 	Result struct {
-		Txid  []string `json:"txid"`  // Transaction IDs (empty for dry run)
+		Txid  []string `json:"txid"` // Transaction IDs (empty for dry run)
 		Descr struct {
 			Order string `json:"order"` // Order description
 		} `json:"descr"`
 	} `json:"result"`
-} 
\ No newline at end of file
+}
+
+// OrderInfo describes an order as returned by the OpenOrders and ClosedOrders API calls
+type OrderInfo struct {
+	Txid    string  `json:"-"`         // Transaction ID (the key of the order in the API response)
+	ClOrdID string  `json:"cl_ord_id"` // Client order id
+	Status  string  `json:"status"`    // Order status: pending, open, closed, canceled or expired
+	OpenTm  float64 `json:"opentm"`    // Unix timestamp of when the order was placed
+	CloseTm float64 `json:"closetm"`   // Unix timestamp of when the order was closed (closed orders only)
+	Vol     string  `json:"vol"`       // Volume of the order
+	VolExec string  `json:"vol_exec"`  // Volume executed
+	Cost    string  `json:"cost"`      // Total cost in quote currency
+	Price   string  `json:"price"`     // Average execution price
+	Descr   struct {
+		Pair      string `json:"pair"`      // Asset pair, e.g. XBTEUR
+		Type      string `json:"type"`      // Side: buy or sell
+		OrderType string `json:"ordertype"` // Order type, e.g. limit or market
+		Price     string `json:"price"`     // Limit price
+		Order     string `json:"order"`     // Order description
+	} `json:"descr"`
+}
+
+// OpenOrdersResponse represents the response from the OpenOrders API call
+type OpenOrdersResponse struct {
+	Error  []string `json:"error"` // List of error messages from the API
+	Result struct {
+		Open map[string]OrderInfo `json:"open"` // Open orders keyed by transaction ID
+	} `json:"result"`
+}
+
+// ClosedOrdersResponse represents the response from the ClosedOrders API call
+type ClosedOrdersResponse struct {
+	Error  []string `json:"error"` // List of error messages from the API
+	Result struct {
+		Closed map[string]OrderInfo `json:"closed"` // Closed orders keyed by transaction ID
+		Count  int                  `json:"count"`  // Total number of matching closed orders
+	} `json:"result"`
+}
+
+// QueryOrdersResponse represents the response from the QueryOrders API call
+type QueryOrdersResponse struct {
+	Error  []string             `json:"error"`  // List of error messages from the API
+	Result map[string]OrderInfo `json:"result"` // Orders keyed by transaction ID
+}
+
+// CancelOrderResponse represents the response from the CancelOrder API call
+type CancelOrderResponse struct {
+	Error  []string `json:"error"` // List of error messages from the API
+	Result struct {
+		Count int `json:"count"` // Number of orders cancelled
+	} `json:"result"`
+}
+
+// SystemStatusResponse represents the response from the SystemStatus API call
+type SystemStatusResponse struct {
+	Error  []string `json:"error"` // List of error messages from the API
+	Result struct {
+		Status    string `json:"status"`    // online, maintenance, cancel_only or post_only
+		Timestamp string `json:"timestamp"` // Time of the status, RFC 3339
+	} `json:"result"`
+}
+
+// OHLCResponse represents the response from the OHLC API call
+type OHLCResponse struct {
+	Error  []string                   `json:"error"`  // List of error messages from the API
+	Result map[string]json.RawMessage `json:"result"` // Candles keyed by trading pair, plus "last" (id of the last committed candle)
+}
+
+// Candle is a single OHLC candle
+type Candle struct {
+	Time   int64         // Unix timestamp of the start of the candle
+	Open   order.Decimal // Opening price
+	High   order.Decimal // Highest price
+	Low    order.Decimal // Lowest price
+	Close  order.Decimal // Closing price
+	VWAP   order.Decimal // Volume-weighted average price
+	Volume order.Decimal // Traded volume
+	Count  int           // Number of trades
+}
+
+// UnmarshalJSON decodes a candle from Kraken's array format:
+// [time, "open", "high", "low", "close", "vwap", "volume", count]
+func (c *Candle) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 8 {
+		return fmt.Errorf("invalid OHLC candle: expected 8 fields, got %d", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &c.Time); err != nil {
+		return fmt.Errorf("invalid OHLC candle time: %w", err)
+	}
+	for i, d := range []*order.Decimal{&c.Open, &c.High, &c.Low, &c.Close, &c.VWAP, &c.Volume} {
+		if err := d.UnmarshalJSON(raw[i+1]); err != nil {
+			return fmt.Errorf("invalid OHLC candle: %w", err)
+		}
+	}
+	if err := json.Unmarshal(raw[7], &c.Count); err != nil {
+		return fmt.Errorf("invalid OHLC candle trade count: %w", err)
+	}
+	return nil
+}
+
+func (r *AddOrderResponse) apiErrors() []string     { return r.Error }
+func (r *OpenOrdersResponse) apiErrors() []string   { return r.Error }
+func (r *ClosedOrdersResponse) apiErrors() []string { return r.Error }
+func (r *QueryOrdersResponse) apiErrors() []string  { return r.Error }
+func (r *CancelOrderResponse) apiErrors() []string  { return r.Error }
+func (r *OHLCResponse) apiErrors() []string         { return r.Error }
+func (r *SystemStatusResponse) apiErrors() []string { return r.Error }
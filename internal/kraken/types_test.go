@@ -0,0 +1,43 @@
+package kraken
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+func TestCandleUnmarshalJSON(t *testing.T) {
+	data := `{"error":[],"result":{"XXBTZEUR":[[1760860800,"60000.0","60150.5","59900.1","60100.0","60050.2","12.34567890",321]],"last":1760860740}}`
+	var response OHLCResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("failed to unmarshal OHLC response: %v", err)
+	}
+	var candles []Candle
+	if err := json.Unmarshal(response.Result["XXBTZEUR"], &candles); err != nil {
+		t.Fatalf("failed to unmarshal candles: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("expected 1 candle, got %d", len(candles))
+	}
+	c := candles[0]
+	if c.Time != 1760860800 || c.Count != 321 {
+		t.Errorf("unexpected time or count: %d, %d", c.Time, c.Count)
+	}
+	if c.High != order.MustParseDecimal("60150.5") || c.Low != order.MustParseDecimal("59900.1") {
+		t.Errorf("unexpected high/low: %s/%s", c.High, c.Low)
+	}
+	if c.Volume != order.MustParseDecimal("12.3456789") {
+		t.Errorf("unexpected volume: %s", c.Volume)
+	}
+}
+
+func TestCandleUnmarshalJSON_Invalid(t *testing.T) {
+	var c Candle
+	if err := json.Unmarshal([]byte(`[1760860800,"60000.0"]`), &c); err == nil {
+		t.Error("expected an error for a short candle")
+	}
+	if err := json.Unmarshal([]byte(`[1760860800,"x","1","1","1","1","1",1]`), &c); err == nil {
+		t.Error("expected an error for an invalid price")
+	}
+}
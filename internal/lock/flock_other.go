@@ -0,0 +1,21 @@
+//go:build !unix
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile is not supported without flock; file locking always fails on these platforms.
+func tryLockFile(f *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+func lockFile(f *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
@@ -0,0 +1,74 @@
+// Package lock provides a single-instance lock so that only one DCA run is active at a time.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned by TryLock when the lock is held by another run.
+var ErrLocked = errors.New("another easy-dca run is active")
+
+// Locker defines the interface for acquiring the instance lock.
+type Locker interface {
+	// TryLock acquires the lock without blocking and returns a function that releases it.
+	// Returns an error wrapping ErrLocked if the lock is held elsewhere.
+	TryLock() (release func() error, err error)
+}
+
+// FileLocker implements Locker using an advisory lock on a file.
+// The lock is released automatically by the OS if the process dies.
+type FileLocker struct {
+	Path string
+}
+
+// NewFileLocker creates a new file-based locker for the given path.
+func NewFileLocker(path string) *FileLocker {
+	return &FileLocker{Path: path}
+}
+
+// TryLock acquires the file lock and records the holder's PID in the file.
+func (l *FileLocker) TryLock() (func() error, error) {
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", l.Path, err)
+	}
+	if err := tryLockFile(f); err != nil {
+		holder, _ := os.ReadFile(l.Path)
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%w (lock file %s held by %s)", ErrLocked, l.Path, describeHolder(holder))
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", l.Path, err)
+	}
+
+	// Record who holds the lock to make skipped runs easier to diagnose
+	if err := f.Truncate(0); err == nil {
+		fmt.Fprintf(f, "pid=%d started=%s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+	}
+
+	return func() error {
+		defer f.Close()
+		return unlockFile(f)
+	}, nil
+}
+
+// Exclusive blocks until it holds an exclusive advisory lock on f and returns a function that releases it.
+// It is meant for short critical sections on shared state files, not for the instance lock.
+func Exclusive(f *os.File) (func() error, error) {
+	if err := lockFile(f); err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+	}
+	return func() error { return unlockFile(f) }, nil
+}
+
+// describeHolder returns a printable description of the lock file contents.
+func describeHolder(content []byte) string {
+	if holder := strings.TrimSpace(string(content)); holder != "" {
+		return holder
+	}
+	return "unknown process"
+}
@@ -0,0 +1,31 @@
+package lock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileLocker_TryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "easy-dca.lock")
+
+	release, err := NewFileLocker(path).TryLock()
+	if err != nil {
+		t.Fatalf("expected to acquire lock, got %v", err)
+	}
+
+	// A second locker on the same file must fail while the first holds it
+	if _, err := NewFileLocker(path).TryLock(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+
+	release, err = NewFileLocker(path).TryLock()
+	if err != nil {
+		t.Fatalf("expected to reacquire lock after release, got %v", err)
+	}
+	release()
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import "net/http"
+
+// Default is the registry of the easy-dca metrics below.
+var Default = NewRegistry()
+
+// Run outcomes used as the outcome label of RunsTotal.
+const (
+	OutcomeSuccess = "success"
+	OutcomeSkipped = "skipped"
+	OutcomeError   = "error"
+)
+
+// easy-dca metrics.
+var (
+	RunsTotal = Default.NewCounter("easy_dca_runs_total",
+		"DCA runs by plan and outcome (success, skipped or error).", "plan", "outcome")
+	ConsecutiveFailures = Default.NewGauge("easy_dca_consecutive_failed_runs",
+		"Number of DCA runs of a plan that failed in a row since the last successful or skipped run.", "plan")
+	LastSuccessTimestamp = Default.NewGauge("easy_dca_last_success_timestamp_seconds",
+		"Unix time of the last successful DCA run of a plan.", "plan")
+	SchedulePaused = Default.NewGauge("easy_dca_schedule_paused",
+		"1 if scheduled DCA runs of a plan are paused via the control API, 0 otherwise (cron mode only).", "plan")
+	NextRunTimestamp = Default.NewGauge("easy_dca_next_run_timestamp_seconds",
+		"Unix time of the next scheduled DCA run of a plan (cron mode only).", "plan")
+	FiatPlacedTotal = Default.NewCounter("easy_dca_fiat_placed_total",
+		"Fiat amount of placed live buy orders (limit price × volume, estimated for market orders), by plan and currency. Orders may fill partly or not at all.", "plan", "currency")
+	BTCPlacedTotal = Default.NewCounter("easy_dca_btc_placed_total",
+		"BTC volume of placed live buy orders, by plan. Orders may fill partly or not at all.", "plan")
+	LastOrderPrice = Default.NewGauge("easy_dca_last_order_price",
+		"Limit price of the last placed live buy order, by plan and currency (estimated for market orders).", "plan", "currency")
+	KrakenRequestDuration = Default.NewHistogram("easy_dca_kraken_request_duration_seconds",
+		"Latency of Kraken API requests, by endpoint.",
+		[]float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}, "endpoint")
+	KrakenErrorsTotal = Default.NewCounter("easy_dca_kraken_errors_total",
+		"Failed Kraken API requests, by error class (e.g. insufficient_funds, rate_limited, other).", "class")
+)
+
+// InitPlan exposes the run counters of a plan from the start, so rate() and increase() see its first run.
+// The plan label is empty if the configuration defines no plans.
+func InitPlan(plan string) {
+	for _, outcome := range []string{OutcomeSuccess, OutcomeSkipped, OutcomeError} {
+		RunsTotal.Add(0, plan, outcome)
+	}
+}
+
+// Handler returns an HTTP handler serving the default registry.
+func Handler() http.Handler {
+	return Default.Handler()
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryCounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	runs := r.NewCounter("test_runs_total", "Runs by outcome.", "outcome")
+	last := r.NewGauge("test_last_success_timestamp_seconds", "Last success.")
+	runs.Inc("success")
+	runs.Inc("success")
+	runs.Add(1, `we"ird`)
+	runs.Add(-5, "success") // ignored
+	last.SetTime(time.Unix(1700000000, 500_000_000))
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	want := `# HELP test_runs_total Runs by outcome.
+# TYPE test_runs_total counter
+test_runs_total{outcome="success"} 2
+test_runs_total{outcome="we\"ird"} 1
+# HELP test_last_success_timestamp_seconds Last success.
+# TYPE test_last_success_timestamp_seconds gauge
+test_last_success_timestamp_seconds 1.7000000005e+09
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRegistryHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "endpoint")
+	h.Observe(0.05, "/0/public/Depth")
+	h.Observe(0.5, "/0/public/Depth")
+	h.Observe(3, "/0/public/Depth")
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	for _, line := range []string{
+		`test_duration_seconds_bucket{endpoint="/0/public/Depth",le="0.1"} 1`,
+		`test_duration_seconds_bucket{endpoint="/0/public/Depth",le="1"} 2`,
+		`test_duration_seconds_bucket{endpoint="/0/public/Depth",le="+Inf"} 3`,
+		`test_duration_seconds_sum{endpoint="/0/public/Depth"} 3.55`,
+		`test_duration_seconds_count{endpoint="/0/public/Depth"} 3`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestRegistryDuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a duplicate metric")
+		}
+	}()
+	r.NewGauge("test_total", "Test.")
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE easy_dca_runs_total counter") {
+		t.Errorf("expected easy-dca metrics, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// chatTimeout limits requests to chat and push services (Slack, Discord, Matrix, Gotify, Pushover).
+const chatTimeout = 10 * time.Second
+
+// eventStyles are the emoji, embed colours and priorities of notifications by event, see eventName.
+var eventStyles = map[string]struct {
+	emoji    string
+	color    int
+	priority int // -1 low, 0 normal, 1 high
+}{
+	"success":         {"✅", 0x2ECC71, 0},
+	"error":           {"❌", 0xE74C3C, 1},
+	"alert":           {"🚨", 0xE67E22, 1},
+	"skipped":         {"⏭️", 0x95A5A6, -1},
+	"config_reloaded": {"🔄", 0x3498DB, -1},
+	"config_error":    {"⚠️", 0xE74C3C, 1},
+}
+
+// eventStyle returns the emoji and colour of a notification subject.
+func eventStyle(subject string) (emoji string, color int) {
+	style, ok := eventStyles[eventName(subject)]
+	if !ok {
+		return "ℹ️", 0x95A5A6
+	}
+	return style.emoji, style.color
+}
+
+// eventPriority returns the priority of a notification subject: -1 low, 0 normal or 1 high.
+// Failures are high, so push services alert even when routine notifications are quiet.
+func eventPriority(subject string) int {
+	return eventStyles[eventName(subject)].priority
+}
+
+// postJSON sends payload as JSON and fails on responses other than 2xx. Errors omit the URL,
+// as chat webhook URLs are secrets.
+func postJSON(ctx context.Context, client *http.Client, method, target string, header http.Header, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = &http.Client{Timeout: chatTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		// The services explain rejected payloads in the body, e.g. Slack's "invalid_blocks"
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if len(detail) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(detail))
+		}
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes, as chat services reject overlong fields.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// titleWithPlan returns the subject with the plan, if the configuration has plans.
+func titleWithPlan(subject, plan string) string {
+	if plan != "" {
+		return subject + " (" + plan + ")"
+	}
+	return subject
+}
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// DiscordNotifier sends notifications to a Discord webhook as embeds coloured by event.
+type DiscordNotifier struct {
+	URL    string // Webhook URL, e.g. https://discord.com/api/webhooks/...
+	Plan   string
+	Pair   string
+	Client *http.Client
+}
+
+// NewDiscordNotifier creates a DiscordNotifier from the configuration.
+func NewDiscordNotifier(cfg config.Config) *DiscordNotifier {
+	return &DiscordNotifier{URL: cfg.NotifyDiscordWebhookURL, Plan: cfg.Plan, Pair: cfg.Pair.String()}
+}
+
+// Notify posts the message to Discord.
+func (n *DiscordNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport posts the message to Discord with the order details as embed fields.
+func (n *DiscordNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send posts an embed with the subject as title, the message as description and the order details as fields.
+func (n *DiscordNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	emoji, color := eventStyle(subject)
+	embed := map[string]any{
+		"title":       truncate(emoji+" "+titleWithPlan(subject, n.Plan), 256),
+		"description": truncate(message, 4096),
+		"color":       color,
+		"footer":      map[string]any{"text": "easy-dca · " + n.Pair},
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+	}
+	if rows := reportRows(report); len(rows) > 0 {
+		fields := make([]map[string]any, len(rows))
+		for i, row := range rows {
+			// Long values such as the pricing explanation get a line of their own
+			fields[i] = map[string]any{"name": row.Label, "value": truncate(row.Value, 1024), "inline": len(row.Value) <= 32}
+		}
+		embed["fields"] = fields
+	}
+	payload := map[string]any{
+		"username":         "easy-dca",
+		"embeds":           []map[string]any{embed},
+		"allowed_mentions": map[string]any{"parse": []string{}}, // Never ping anyone from message text
+	}
+	if err := postJSON(ctx, n.Client, http.MethodPost, n.URL, nil, payload); err != nil {
+		return fmt.Errorf("discord notification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestDiscordNotifier(t *testing.T) {
+	srv := newWebhookServer(t)
+	cfg := webhookConfig(t, "")
+	cfg.NotifyMethod = "discord"
+	cfg.NotifyDiscordWebhookURL = srv.URL + "/api/webhooks/123/secret"
+	n := CreateNotifier(cfg)
+	if n == nil {
+		t.Fatal("expected a discord notifier")
+	}
+
+	type embed struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Color       int    `json:"color"`
+		Fields      []struct {
+			Name   string `json:"name"`
+			Value  string `json:"value"`
+			Inline bool   `json:"inline"`
+		} `json:"fields"`
+		Footer struct {
+			Text string `json:"text"`
+		} `json:"footer"`
+	}
+	var payload struct {
+		Embeds []embed `json:"embeds"`
+	}
+
+	if err := n.(ReportNotifier).NotifyReport(context.Background(), "DCA Success", "LIVE ORDER: Placed order", testReport); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := json.Unmarshal(srv.body, &payload); err != nil || len(payload.Embeds) != 1 {
+		t.Fatalf("invalid payload %s: %v", srv.body, err)
+	}
+	e := payload.Embeds[0]
+	if e.Title != "✅ DCA Success (weekly)" || e.Color != 0x2ECC71 || e.Footer.Text != "easy-dca · BTC/EUR" {
+		t.Errorf("unexpected embed %+v", e)
+	}
+	if len(e.Fields) != 8 || e.Fields[7].Name != "TXID" || !e.Fields[2].Inline || e.Fields[6].Inline {
+		t.Errorf("unexpected fields %+v", e.Fields)
+	}
+
+	if err := n.Notify(context.Background(), "DCA Alert", "Insufficient funds"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload.Embeds = nil
+	json.Unmarshal(srv.body, &payload)
+	if e := payload.Embeds[0]; e.Color != 0xE67E22 || e.Description != "Insufficient funds" || len(e.Fields) != 0 {
+		t.Errorf("expected an orange alert without fields, got %+v", e)
+	}
+}
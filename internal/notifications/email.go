@@ -0,0 +1,295 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// EmailNotifier sends notifications as multipart emails with a plain-text and an HTML part via SMTP.
+type EmailNotifier struct {
+	Host      string
+	Port      int
+	Security  string // starttls, tls (implicit TLS) or none
+	Username  string // Empty disables authentication
+	Password  string
+	From      string
+	To        []string
+	Plan      string
+	Timeout   time.Duration
+	TLSConfig *tls.Config // nil verifies the certificate of Host against the system roots
+}
+
+// NewEmailNotifier creates an EmailNotifier from the configuration.
+func NewEmailNotifier(cfg config.Config) *EmailNotifier {
+	return &EmailNotifier{
+		Host:     cfg.NotifySMTPHost,
+		Port:     cfg.NotifySMTPPort,
+		Security: cfg.NotifySMTPSecurity,
+		Username: cfg.NotifySMTPUsername,
+		Password: cfg.NotifySMTPPassword,
+		From:     cfg.NotifySMTPFrom,
+		To:       cfg.NotifySMTPTo,
+		Plan:     cfg.Plan,
+		Timeout:  cfg.NotifySMTPTimeout,
+	}
+}
+
+// Notify sends an email with the message.
+func (n *EmailNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport sends an email with the message and a table of the order details.
+func (n *EmailNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send renders the email and delivers it to all recipients.
+func (n *EmailNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to := make([]*mail.Address, len(n.To))
+	for i, addr := range n.To {
+		if to[i], err = mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid recipient address: %w", err)
+		}
+	}
+	if subject != "" && n.Plan != "" {
+		subject += " (" + n.Plan + ")"
+	}
+	msg, err := buildEmail(from, to, subject, message, report, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := n.deliver(ctx, from, to, msg); err != nil {
+		return fmt.Errorf("email notification failed: %w", err)
+	}
+	return nil
+}
+
+// deliver sends a message over one SMTP connection.
+func (n *EmailNotifier) deliver(ctx context.Context, from *mail.Address, to []*mail.Address, msg []byte) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tlsConfig := &tls.Config{}
+	if n.TLSConfig != nil {
+		tlsConfig = n.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = n.Host
+	}
+
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	var conn net.Conn
+	var err error
+	if n.Security == "tls" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	// Abort the SMTP conversation when the context ends
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if n.Security == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS (set NOTIFY_SMTP_SECURITY=tls for implicit TLS)")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("recipient %s: %w", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// emailRow is a row of the order details table.
+type emailRow struct {
+	Label string
+	Value string
+}
+
+// emailData is the data of the HTML email template.
+type emailData struct {
+	Subject string
+	Message string
+	Rows    []emailRow
+}
+
+// emailTemplate renders the HTML part of emails. Styles are inline, as many mail clients drop style sheets.
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #222;">
+<h2 style="margin: 0 0 12px;">{{.Subject}}</h2>
+<p style="white-space: pre-wrap;">{{.Message}}</p>
+{{- with .Rows}}
+<table style="border-collapse: collapse; margin: 12px 0;">
+{{- range .}}
+<tr><th style="text-align: left; padding: 4px 16px 4px 0; color: #555;">{{.Label}}</th><td style="padding: 4px 0; font-family: monospace;">{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p style="color: #888; font-size: 12px;">Sent by easy-dca</p>
+</body>
+</html>
+`))
+
+// reportRows returns the order details shown in emails.
+func reportRows(report *OrderReport) []emailRow {
+	if report == nil {
+		return nil
+	}
+	mode := "live"
+	if report.DryRun {
+		mode = "dry run (validated, not placed)"
+	}
+	rows := []emailRow{{"Pair", report.Pair}, {"Mode", mode}, {"Order type", report.Type}, {"Volume", report.Volume + " " + report.Unit}}
+	if report.Price != "" {
+		rows = append(rows, emailRow{"Price", report.Price + " " + report.Currency})
+	}
+	if report.Total != "" {
+		rows = append(rows, emailRow{"Total", report.Total + " " + report.Currency})
+	}
+	if report.Pricing != "" {
+		rows = append(rows, emailRow{"Pricing", report.Pricing})
+	}
+	if report.Txid != "" {
+		rows = append(rows, emailRow{"TXID", report.Txid})
+	}
+	if report.Plan != "" {
+		rows = append(rows, emailRow{"Plan", report.Plan})
+	}
+	return rows
+}
+
+// buildEmail renders a multipart/alternative email with a plain-text and an HTML part.
+func buildEmail(from *mail.Address, to []*mail.Address, subject, message string, report *OrderReport, now time.Time) ([]byte, error) {
+	rows := reportRows(report)
+	text := message + "\n"
+	if len(rows) > 0 {
+		text += "\n"
+		for _, row := range rows {
+			text += fmt.Sprintf("%-11s %s\n", row.Label+":", row.Value)
+		}
+	}
+	text += "\n-- \nSent by easy-dca\n"
+	var html bytes.Buffer
+	if err := emailTemplate.Execute(&html, emailData{Subject: subject, Message: message, Rows: rows}); err != nil {
+		return nil, fmt.Errorf("email template: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html.String()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+	var msg bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})},
+	} {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// messageID returns a unique Message-ID in the domain of the sender.
+func messageID(from string) string {
+	domain := "easy-dca"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
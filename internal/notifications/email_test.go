@@ -0,0 +1,287 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is a local stand-in for an SMTP server that records the mail it receives.
+type smtpServer struct {
+	ln          net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool // TLS from the start, as on port 465
+	startTLS    bool // Offers STARTTLS
+
+	mu   sync.Mutex
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+// newSMTPServer starts an SMTP stand-in with a self-signed certificate and returns it with a
+// client TLS configuration trusting the certificate.
+func newSMTPServer(t *testing.T, implicitTLS, startTLS bool) (*smtpServer, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	s := &smtpServer{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		implicitTLS: implicitTLS,
+		startTLS:    startTLS,
+	}
+	if s.ln, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.ln.Close() })
+	go func() {
+		for {
+			conn, err := s.ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, &tls.Config{RootCAs: roots}
+}
+
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// serve speaks just enough SMTP for net/smtp.
+func (s *smtpServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	reply := func(line string) {
+		w.WriteString(line + "\r\n")
+		w.Flush()
+	}
+	secure := s.implicitTLS
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			if s.startTLS && !secure {
+				reply("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				reply("250-localhost\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 Ready to start TLS")
+			conn = tls.Server(conn, s.tlsConfig)
+			r, w = bufio.NewReader(conn), bufio.NewWriter(conn)
+			secure = true
+		case "AUTH":
+			_, resp, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(resp)
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 Authentication successful")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, arg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 OK: queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func emailNotifier(s *smtpServer, tlsConfig *tls.Config, security string) *EmailNotifier {
+	return &EmailNotifier{
+		Host:      "127.0.0.1",
+		Port:      s.port(),
+		Security:  security,
+		Username:  "dca@example.com",
+		Password:  "smtp-password",
+		From:      "easy-dca <dca@example.com>",
+		To:        []string{"finance@example.com", "Max Mustermann <max@example.com>"},
+		Plan:      "weekly",
+		Timeout:   5 * time.Second,
+		TLSConfig: tlsConfig,
+	}
+}
+
+// readParts returns the content of the parts of a multipart/alternative email by content type.
+func readParts(t *testing.T, data string) (*mail.Message, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected a multipart/alternative email, got %q", msg.Header.Get("Content-Type"))
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(p) // Decodes quoted-printable
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[contentType] = string(content)
+	}
+	return msg, parts
+}
+
+func TestEmailNotifier_StartTLS(t *testing.T) {
+	srv, tlsConfig := newSMTPServer(t, false, true)
+	n := emailNotifier(srv, tlsConfig, "starttls")
+	report := OrderReport{
+		Pair:     "BTC/EUR",
+		Type:     "limit",
+		Volume:   "0.00020000",
+		Unit:     "BTC",
+		Price:    "49750.00",
+		Total:    "10.00",
+		Currency: "EUR",
+		Pricing:  "price factor 0.995 × best ask 50000.00",
+		Txid:     "OABCDE-12345-FGHIJK",
+	}
+	if err := n.NotifyReport(context.Background(), "DCA Success", "LIVE ORDER: Placed order <b>", report); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.auth != "\x00dca@example.com\x00smtp-password" {
+		t.Errorf("unexpected credentials %q", srv.auth)
+	}
+	if srv.from != "FROM:<dca@example.com>" || len(srv.rcpt) != 2 || srv.rcpt[1] != "TO:<max@example.com>" {
+		t.Errorf("unexpected envelope %q to %q", srv.from, srv.rcpt)
+	}
+	msg, parts := readParts(t, srv.data)
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "DCA Success (weekly)" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if !strings.Contains(msg.Header.Get("To"), `"Max Mustermann" <max@example.com>`) {
+		t.Errorf("unexpected recipients %q", msg.Header.Get("To"))
+	}
+	text := parts["text/plain"]
+	for _, want := range []string{"LIVE ORDER: Placed order <b>", "Volume:     0.00020000 BTC", "Total:      10.00 EUR", "TXID:       OABCDE-12345-FGHIJK"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in the text part, got %q", want, text)
+		}
+	}
+	html := parts["text/html"]
+	for _, want := range []string{"Placed order &lt;b&gt;", "<td style=\"padding: 4px 0; font-family: monospace;\">49750.00 EUR</td>", "× best ask"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in the HTML part, got %q", want, html)
+		}
+	}
+}
+
+func TestEmailNotifier_ImplicitTLS(t *testing.T) {
+	srv, tlsConfig := newSMTPServer(t, true, false)
+	if err := emailNotifier(srv, tlsConfig, "tls").Notify(context.Background(), "DCA Error", "Failed to add order"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	_, parts := readParts(t, srv.data)
+	if !strings.Contains(parts["text/plain"], "Failed to add order") || strings.Contains(parts["text/html"], "<table") {
+		t.Errorf("expected the message without order details, got %q", parts)
+	}
+}
+
+func TestEmailNotifier_Errors(t *testing.T) {
+	// Without STARTTLS the password would be sent in the clear
+	srv, tlsConfig := newSMTPServer(t, false, false)
+	err := emailNotifier(srv, tlsConfig, "starttls").Notify(context.Background(), "DCA Error", "message")
+	if err == nil || !strings.Contains(err.Error(), "does not support STARTTLS") {
+		t.Errorf("expected a STARTTLS error, got %v", err)
+	}
+
+	// The certificate is not trusted
+	srv, _ = newSMTPServer(t, true, false)
+	err = emailNotifier(srv, nil, "tls").Notify(context.Background(), "DCA Error", "message")
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("expected a certificate error, got %v", err)
+	}
+
+	n := emailNotifier(srv, nil, "none")
+	n.Port = 1 // Nothing listens on port 1
+	n.Timeout = time.Second
+	if err := n.Notify(context.Background(), "DCA Error", "message"); err == nil {
+		t.Error("expected a connection error")
+	}
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// gotifyPriorities map event priorities to Gotify priorities; the Android app plays a sound
+// from 4 and pops up from 8.
+var gotifyPriorities = map[int]int{-1: 2, 0: 5, 1: 8}
+
+// GotifyNotifier sends notifications to a Gotify server.
+type GotifyNotifier struct {
+	URL    string // Server URL, e.g. https://gotify.example.com
+	Token  string // Application token
+	Plan   string
+	Client *http.Client
+}
+
+// NewGotifyNotifier creates a GotifyNotifier from the configuration.
+func NewGotifyNotifier(cfg config.Config) *GotifyNotifier {
+	return &GotifyNotifier{URL: cfg.NotifyGotifyURL, Token: cfg.NotifyGotifyToken, Plan: cfg.Plan}
+}
+
+// Notify sends the message to Gotify.
+func (n *GotifyNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport sends the message to Gotify with a Markdown table of the order details.
+func (n *GotifyNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send posts a message with a priority by event.
+func (n *GotifyNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	payload := map[string]any{
+		"title":    titleWithPlan(subject, n.Plan),
+		"message":  message,
+		"priority": gotifyPriorities[eventPriority(subject)],
+	}
+	if rows := reportRows(report); len(rows) > 0 {
+		var b strings.Builder
+		b.WriteString(message + "\n\n| | |\n|---|---|\n")
+		for _, row := range rows {
+			fmt.Fprintf(&b, "| **%s** | %s |\n", row.Label, strings.ReplaceAll(row.Value, "|", `\|`))
+		}
+		payload["message"] = b.String()
+		payload["extras"] = map[string]any{"client::display": map[string]any{"contentType": "text/markdown"}}
+	}
+	// The token in a header rather than the query keeps it out of proxy logs
+	header := http.Header{"X-Gotify-Key": {n.Token}}
+	if err := postJSON(ctx, n.Client, http.MethodPost, strings.TrimRight(n.URL, "/")+"/message", header, payload); err != nil {
+		return fmt.Errorf("gotify notification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGotifyNotifier(t *testing.T) {
+	srv := newWebhookServer(t)
+	cfg := webhookConfig(t, "")
+	cfg.NotifyMethod = "gotify"
+	cfg.NotifyGotifyURL = srv.URL + "/gotify/"
+	cfg.NotifyGotifyToken = "AbCdEf.gotify"
+	n := CreateNotifier(cfg)
+	if n == nil {
+		t.Fatal("expected a gotify notifier")
+	}
+
+	var payload struct {
+		Title    string         `json:"title"`
+		Message  string         `json:"message"`
+		Priority int            `json:"priority"`
+		Extras   map[string]any `json:"extras"`
+	}
+	if err := n.(ReportNotifier).NotifyReport(context.Background(), "DCA Success", "LIVE ORDER: Placed order", testReport); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if srv.path != "/gotify/message" || srv.header.Get("X-Gotify-Key") != "AbCdEf.gotify" {
+		t.Errorf("unexpected request to %s with key %q", srv.path, srv.header.Get("X-Gotify-Key"))
+	}
+	if err := json.Unmarshal(srv.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", srv.body, err)
+	}
+	if payload.Title != "DCA Success (weekly)" || payload.Priority != 5 || payload.Extras == nil {
+		t.Errorf("unexpected payload %s", srv.body)
+	}
+	if !strings.Contains(payload.Message, "| **Total** | 10.00 EUR |") {
+		t.Errorf("expected a Markdown table, got %q", payload.Message)
+	}
+
+	if err := n.Notify(context.Background(), "DCA Alert", "Insufficient funds"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload.Extras = nil
+	json.Unmarshal(srv.body, &payload)
+	if payload.Priority != 8 || payload.Message != "Insufficient funds" || payload.Extras != nil {
+		t.Errorf("expected a high priority plain message, got %s", srv.body)
+	}
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// MatrixNotifier sends notifications to a Matrix room via the client-server API.
+type MatrixNotifier struct {
+	Homeserver  string // Homeserver URL, e.g. https://matrix.example.org
+	AccessToken string // Access token of the sending account
+	RoomID      string // Room ID, e.g. !abc123:example.org
+	Plan        string
+	Client      *http.Client
+}
+
+// NewMatrixNotifier creates a MatrixNotifier from the configuration.
+func NewMatrixNotifier(cfg config.Config) *MatrixNotifier {
+	return &MatrixNotifier{
+		Homeserver:  cfg.NotifyMatrixHomeserver,
+		AccessToken: cfg.NotifyMatrixAccessToken,
+		RoomID:      cfg.NotifyMatrixRoomID,
+		Plan:        cfg.Plan,
+	}
+}
+
+// Notify sends the message to the room.
+func (n *MatrixNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport sends the message to the room with a table of the order details.
+func (n *MatrixNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send sends an m.notice message with a plain-text body and an HTML formatted body.
+func (n *MatrixNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	emoji, _ := eventStyle(subject)
+	title := emoji + " " + titleWithPlan(subject, n.Plan)
+	rows := reportRows(report)
+
+	var body, formatted strings.Builder
+	body.WriteString(title + "\n" + message)
+	fmt.Fprintf(&formatted, "<strong>%s</strong><br>%s", html.EscapeString(title), strings.ReplaceAll(html.EscapeString(message), "\n", "<br>"))
+	if len(rows) > 0 {
+		body.WriteString("\n")
+		formatted.WriteString("<table>")
+		for _, row := range rows {
+			body.WriteString("\n" + row.Label + ": " + row.Value)
+			fmt.Fprintf(&formatted, "<tr><th>%s</th><td><code>%s</code></td></tr>", html.EscapeString(row.Label), html.EscapeString(row.Value))
+		}
+		formatted.WriteString("</table>")
+	}
+
+	// Every event sent by the access token needs a unique transaction ID
+	txn := make([]byte, 16)
+	rand.Read(txn)
+	target := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(n.Homeserver, "/"), url.PathEscape(n.RoomID), hex.EncodeToString(txn))
+	header := http.Header{"Authorization": {"Bearer " + n.AccessToken}}
+	payload := map[string]any{
+		"msgtype":        "m.notice", // Matrix convention for automated messages; bots do not reply to notices
+		"body":           body.String(),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted.String(),
+	}
+	if err := postJSON(ctx, n.Client, http.MethodPut, target, header, payload); err != nil {
+		return fmt.Errorf("matrix notification failed: %w", err)
+	}
+	return nil
+}
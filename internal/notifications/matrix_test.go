@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatrixNotifier(t *testing.T) {
+	srv := newWebhookServer(t)
+	cfg := webhookConfig(t, "")
+	cfg.NotifyMethod = "matrix"
+	cfg.NotifyMatrixHomeserver = srv.URL + "/"
+	cfg.NotifyMatrixAccessToken = "syt_matrix_token"
+	cfg.NotifyMatrixRoomID = "!abc123:example.org"
+	n := CreateNotifier(cfg)
+	if n == nil {
+		t.Fatal("expected a matrix notifier")
+	}
+	if err := n.(ReportNotifier).NotifyReport(context.Background(), "DCA Success", "Placed order <b>\nsecond line", testReport); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if srv.method != http.MethodPut || !strings.HasPrefix(srv.path, "/_matrix/client/v3/rooms/%21abc123:example.org/send/m.room.message/") {
+		t.Errorf("unexpected request %s %s", srv.method, srv.path)
+	}
+	if srv.header.Get("Authorization") != "Bearer syt_matrix_token" {
+		t.Errorf("unexpected authorization %q", srv.header.Get("Authorization"))
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(srv.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", srv.body, err)
+	}
+	if payload["msgtype"] != "m.notice" || payload["format"] != "org.matrix.custom.html" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+	if !strings.HasPrefix(payload["body"], "✅ DCA Success (weekly)\nPlaced order <b>\nsecond line\n\nPair: BTC/EUR") {
+		t.Errorf("unexpected body %q", payload["body"])
+	}
+	for _, want := range []string{"<strong>✅ DCA Success (weekly)</strong><br>Placed order &lt;b&gt;<br>second line", "<tr><th>Total</th><td><code>10.00 EUR</code></td></tr>"} {
+		if !strings.Contains(payload["formatted_body"], want) {
+			t.Errorf("expected %q in the formatted body, got %q", want, payload["formatted_body"])
+		}
+	}
+
+	first := srv.path
+	n.Notify(context.Background(), "DCA Error", "message")
+	if srv.path == first {
+		t.Error("expected a new transaction ID for every message")
+	}
+}
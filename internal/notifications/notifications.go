@@ -3,9 +3,11 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/mayrf/easy-dca/internal/config"
@@ -16,10 +18,33 @@ type Notifier interface {
 	Notify(ctx context.Context, subject, message string) error
 }
 
+// OrderReport details the order placed (or validated) by a run.
+type OrderReport struct {
+	Plan     string // Plan name (empty without plans)
+	Pair     string // Trading pair, e.g. "BTC/EUR"
+	DryRun   bool
+	Type     string // Order type, e.g. "limit" or "market"
+	Volume   string // Volume in Unit
+	Unit     string // "BTC" or "sats"
+	Price    string // Price in Currency (empty for market fallback orders)
+	Total    string // Fiat amount in Currency (empty for market fallback orders)
+	Currency string // Fiat currency, e.g. "EUR"
+	Pricing  string // How the price was chosen
+	Txid     string // Kraken transaction ID (empty for dry runs)
+}
+
+// ReportNotifier is implemented by notifiers that format the details of an order themselves,
+// such as email. Other notifiers are sent the message only.
+type ReportNotifier interface {
+	Notifier
+	NotifyReport(ctx context.Context, subject, message string, report OrderReport) error
+}
+
 // NtfyNotifier sends notifications via ntfy.sh or a custom ntfy server.
 type NtfyNotifier struct {
-	Topic string
-	URL   string
+	Topic  string
+	URL    string
+	Client *http.Client // A client with chatTimeout if nil
 }
 
 // Notify sends a notification via ntfy.
@@ -31,16 +56,25 @@ func (n *NtfyNotifier) Notify(ctx context.Context, subject, message string) erro
 		return fmt.Errorf("ntfy URL is not set")
 	}
 	ntfyURL := fmt.Sprintf("%s/%s", strings.TrimRight(n.URL, "/"), n.Topic)
+	// Errors leave out the URL, as the topic is the only secret of a ntfy URL
 	req, err := http.NewRequestWithContext(ctx, "POST", ntfyURL, strings.NewReader(message))
 	if err != nil {
-		return err
+		return errors.New("invalid ntfy URL")
 	}
 	if subject != "" {
 		req.Header.Set("Title", subject)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: chatTimeout}
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("ntfy notification failed: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -49,16 +83,61 @@ func (n *NtfyNotifier) Notify(ctx context.Context, subject, message string) erro
 	return nil
 }
 
-// CreateNotifier creates a Notifier based on configuration.
+// CreateNotifier creates a Notifier based on configuration: the notifier of NOTIFY_METHOD and
+// of every notification URL, combined if there are several. It returns nil if none is configured.
 func CreateNotifier(cfg config.Config) Notifier {
+	var notifiers MultiNotifier
+	if n := methodNotifier(cfg); n != nil {
+		notifiers = append(notifiers, n)
+	}
+	for i, raw := range cfg.NotifyURLs {
+		n, err := ParseURL(raw, cfg)
+		if err != nil {
+			slog.Warn("Notification URL is invalid and will be skipped", "entry", i+1, "error", err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	switch len(notifiers) {
+	case 0:
+		return nil
+	case 1:
+		return notifiers[0]
+	}
+	return notifiers
+}
+
+// methodNotifier creates the Notifier of NOTIFY_METHOD, or nil if it is not set.
+func methodNotifier(cfg config.Config) Notifier {
 	switch strings.ToLower(cfg.NotifyMethod) {
+	case "discord":
+		return NewDiscordNotifier(cfg)
+	case "email":
+		return NewEmailNotifier(cfg)
+	case "gotify":
+		return NewGotifyNotifier(cfg)
+	case "matrix":
+		return NewMatrixNotifier(cfg)
 	case "ntfy":
 		if cfg.NotifyNtfyURL == "" {
-			log.Print("Warning: NOTIFY_NTFY_URL is required for ntfy notifications but is not set. Notifications will be disabled.")
+			slog.Warn("NOTIFY_NTFY_URL is required for ntfy notifications but is not set. Notifications will be disabled.")
 			return nil
 		}
 		return &NtfyNotifier{Topic: cfg.NotifyNtfyTopic, URL: cfg.NotifyNtfyURL}
-	// Add more cases for other notification methods (slack, email, etc.)
+	case "pushover":
+		return NewPushoverNotifier(cfg)
+	case "slack":
+		return NewSlackNotifier(cfg)
+	case "telegram":
+		return NewTelegramNotifier(cfg)
+	case "webhook":
+		n, err := NewWebhookNotifier(cfg)
+		if err != nil {
+			slog.Warn("Webhook notifications are misconfigured and will be disabled", "error", err)
+			return nil
+		}
+		return n
+	// Add more cases for other notification methods
 	default:
 		return nil
 	}
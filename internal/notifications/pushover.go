@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// PushoverAPIURL is the endpoint of the Pushover message API.
+const PushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+// PushoverNotifier sends notifications via Pushover.
+type PushoverNotifier struct {
+	Token  string // Application API token
+	User   string // User or group key
+	Device string // Devices to notify, separated by commas (empty notifies all devices of the user)
+	Plan   string
+	APIURL string // Message API endpoint (default PushoverAPIURL)
+	Client *http.Client
+}
+
+// NewPushoverNotifier creates a PushoverNotifier from the configuration.
+func NewPushoverNotifier(cfg config.Config) *PushoverNotifier {
+	return &PushoverNotifier{
+		Token:  cfg.NotifyPushoverToken,
+		User:   cfg.NotifyPushoverUser,
+		Device: cfg.NotifyPushoverDevice,
+		Plan:   cfg.Plan,
+	}
+}
+
+// Notify sends the message via Pushover.
+func (n *PushoverNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport sends the message via Pushover with the order details.
+func (n *PushoverNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send sends an HTML message with a priority by event; failures are high priority and bypass quiet hours.
+func (n *PushoverNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	// Pushover limits messages to 1024 characters; leave room for the order details
+	text := html.EscapeString(truncate(message, 600))
+	for _, row := range reportRows(report) {
+		text += fmt.Sprintf("\n<b>%s:</b> %s", html.EscapeString(row.Label), html.EscapeString(row.Value))
+	}
+	payload := map[string]any{
+		"token":    n.Token,
+		"user":     n.User,
+		"title":    truncate(titleWithPlan(subject, n.Plan), 250),
+		"message":  truncate(text, 1024),
+		"html":     1,
+		"priority": eventPriority(subject),
+	}
+	if n.Device != "" {
+		payload["device"] = n.Device
+	}
+	apiURL := n.APIURL
+	if apiURL == "" {
+		apiURL = PushoverAPIURL
+	}
+	if err := postJSON(ctx, n.Client, http.MethodPost, apiURL, nil, payload); err != nil {
+		return fmt.Errorf("pushover notification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPushoverNotifier(t *testing.T) {
+	srv := newWebhookServer(t)
+	n := &PushoverNotifier{Token: "app-token", User: "user-key", Device: "phone,tablet", Plan: "weekly", APIURL: srv.URL}
+
+	var payload struct {
+		Token    string `json:"token"`
+		User     string `json:"user"`
+		Device   string `json:"device"`
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		HTML     int    `json:"html"`
+		Priority int    `json:"priority"`
+	}
+	if err := n.NotifyReport(context.Background(), "DCA Success", "Placed order <b>", testReport); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := json.Unmarshal(srv.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", srv.body, err)
+	}
+	if payload.Token != "app-token" || payload.User != "user-key" || payload.Device != "phone,tablet" || payload.HTML != 1 || payload.Priority != 0 {
+		t.Errorf("unexpected payload %s", srv.body)
+	}
+	if !strings.HasPrefix(payload.Message, "Placed order &lt;b&gt;\n<b>Pair:</b> BTC/EUR") {
+		t.Errorf("unexpected message %q", payload.Message)
+	}
+
+	n.Notify(context.Background(), "DCA Config Reloaded", "Changed PriceFactor")
+	json.Unmarshal(srv.body, &payload)
+	if payload.Priority != -1 {
+		t.Errorf("expected a low priority, got %d", payload.Priority)
+	}
+
+	srv.status = http.StatusBadRequest
+	if err := n.Notify(context.Background(), "DCA Error", "message"); err == nil {
+		t.Error("expected an error for a rejected message")
+	}
+}
@@ -0,0 +1,70 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// SlackNotifier sends notifications to a Slack incoming webhook, formatted with Block Kit.
+type SlackNotifier struct {
+	URL    string // Incoming webhook URL, e.g. https://hooks.slack.com/services/...
+	Plan   string
+	Pair   string
+	Client *http.Client
+}
+
+// NewSlackNotifier creates a SlackNotifier from the configuration.
+func NewSlackNotifier(cfg config.Config) *SlackNotifier {
+	return &SlackNotifier{URL: cfg.NotifySlackWebhookURL, Plan: cfg.Plan, Pair: cfg.Pair.String()}
+}
+
+// Notify posts the message to Slack.
+func (n *SlackNotifier) Notify(ctx context.Context, subject, message string) error {
+	return n.send(ctx, subject, message, nil)
+}
+
+// NotifyReport posts the message to Slack with the order details as fields.
+func (n *SlackNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	return n.send(ctx, subject, message, &report)
+}
+
+// send posts a header, the message, the order details and the pair as Block Kit blocks.
+func (n *SlackNotifier) send(ctx context.Context, subject, message string, report *OrderReport) error {
+	emoji, _ := eventStyle(subject)
+	title := titleWithPlan(subject, n.Plan)
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": truncate(emoji+" "+title, 150), "emoji": true}},
+		{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": truncate(slackEscape(message), 3000)}},
+	}
+	if rows := reportRows(report); len(rows) > 0 {
+		fields := make([]map[string]any, 0, len(rows))
+		for _, row := range rows {
+			fields = append(fields, map[string]any{"type": "mrkdwn", "text": truncate(fmt.Sprintf("*%s*\n%s", row.Label, slackEscape(row.Value)), 2000)})
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields[:min(len(fields), 10)]})
+	}
+	blocks = append(blocks, map[string]any{
+		"type":     "context",
+		"elements": []map[string]any{{"type": "mrkdwn", "text": slackEscape("easy-dca · " + n.Pair)}},
+	})
+	payload := map[string]any{
+		"text":   title + ": " + message, // Shown in notifications and clients without blocks
+		"blocks": blocks,
+	}
+	if err := postJSON(ctx, n.Client, http.MethodPost, n.URL, nil, payload); err != nil {
+		return fmt.Errorf("slack notification failed: %w", err)
+	}
+	return nil
+}
+
+// slackEscaper escapes the control characters of Slack's mrkdwn.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes text for mrkdwn, so it is shown as written.
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
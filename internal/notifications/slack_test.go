@@ -0,0 +1,70 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testReport is the order report of the chat notifier tests.
+var testReport = OrderReport{
+	Pair:     "BTC/EUR",
+	Type:     "limit",
+	Volume:   "0.00020000",
+	Unit:     "BTC",
+	Price:    "49750.00",
+	Total:    "10.00",
+	Currency: "EUR",
+	Pricing:  "price factor 0.995 × best ask 50000.00, rounded down to tick 0.1",
+	Txid:     "OABCDE-12345-FGHIJK",
+}
+
+func TestSlackNotifier(t *testing.T) {
+	srv := newWebhookServer(t)
+	cfg := webhookConfig(t, "")
+	cfg.NotifyMethod = "slack"
+	cfg.NotifySlackWebhookURL = srv.URL + "/services/T000/B000/secret"
+	n := CreateNotifier(cfg)
+	if n == nil {
+		t.Fatal("expected a slack notifier")
+	}
+	if err := n.(ReportNotifier).NotifyReport(context.Background(), "DCA Success", "Placed order <b> & done", testReport); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var payload struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+			Fields []struct {
+				Text string `json:"text"`
+			} `json:"fields"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(srv.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", srv.body, err)
+	}
+	if payload.Text != "DCA Success (weekly): Placed order <b> & done" || len(payload.Blocks) != 4 {
+		t.Fatalf("unexpected payload %s", srv.body)
+	}
+	if payload.Blocks[0].Type != "header" || payload.Blocks[0].Text.Text != "✅ DCA Success (weekly)" {
+		t.Errorf("unexpected header %+v", payload.Blocks[0])
+	}
+	if payload.Blocks[1].Text.Text != "Placed order &lt;b&gt; &amp; done" {
+		t.Errorf("expected an escaped message, got %q", payload.Blocks[1].Text.Text)
+	}
+	if len(payload.Blocks[2].Fields) != 8 || payload.Blocks[2].Fields[4].Text != "*Price*\n49750.00 EUR" {
+		t.Errorf("unexpected fields %+v", payload.Blocks[2].Fields)
+	}
+
+	srv.status = http.StatusBadRequest
+	err := n.Notify(context.Background(), "DCA Error", "message")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected an error without the webhook URL, got %v", err)
+	}
+}
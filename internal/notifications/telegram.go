@@ -0,0 +1,42 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/telegram"
+)
+
+// TelegramNotifier sends notifications to Telegram chats via a bot.
+type TelegramNotifier struct {
+	Client  *telegram.Client
+	ChatIDs []int64
+	Plan    string
+}
+
+// NewTelegramNotifier creates a TelegramNotifier from the configuration.
+func NewTelegramNotifier(cfg config.Config) *TelegramNotifier {
+	return &TelegramNotifier{
+		Client:  &telegram.Client{Token: cfg.NotifyTelegramToken, APIURL: cfg.NotifyTelegramAPIURL},
+		ChatIDs: cfg.NotifyTelegramChatIDs,
+		Plan:    cfg.Plan,
+	}
+}
+
+// Notify sends the notification to every chat, with the subject in bold.
+func (n *TelegramNotifier) Notify(ctx context.Context, subject, message string) error {
+	title := subject
+	if n.Plan != "" {
+		title += " (" + n.Plan + ")"
+	}
+	text := "*" + telegram.EscapeMarkdown(title) + "*\n" + telegram.EscapeMarkdown(message)
+	var errs []error
+	for _, chatID := range n.ChatIDs {
+		if err := n.Client.SendMessage(ctx, chatID, text); err != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", chatID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
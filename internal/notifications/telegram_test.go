@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	type sent struct {
+		ChatID    int64  `json:"chat_id"`
+		Text      string `json:"text"`
+		ParseMode string `json:"parse_mode"`
+	}
+	var messages []sent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot123456:token/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 404, "description": "Not Found"})
+			return
+		}
+		var msg sent
+		json.NewDecoder(r.Body).Decode(&msg)
+		messages = append(messages, msg)
+		if msg.ChatID == 3 {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 403, "description": "Forbidden: bot was blocked by the user"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{}})
+	}))
+	t.Cleanup(srv.Close)
+
+	pair, _ := config.NewTradingPair("BTC/EUR")
+	n := CreateNotifier(config.Config{
+		Pair:                  pair,
+		Plan:                  "weekly",
+		NotifyMethod:          "telegram",
+		NotifyTelegramToken:   "123456:token",
+		NotifyTelegramChatIDs: []int64{1, 3},
+		NotifyTelegramAPIURL:  srv.URL,
+	})
+	if n == nil {
+		t.Fatal("expected a telegram notifier")
+	}
+	err := n.Notify(context.Background(), "DCA Success", "Bought 0.001 BTC (dry-run)")
+	if err == nil || !strings.Contains(err.Error(), "chat 3: telegram sendMessage: 403 Forbidden") {
+		t.Errorf("expected the error of the blocked chat, got %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected a message to each chat, got %+v", messages)
+	}
+	want := "*DCA Success \\(weekly\\)*\nBought 0\\.001 BTC \\(dry\\-run\\)"
+	if messages[0].Text != want || messages[0].ParseMode != "MarkdownV2" {
+		t.Errorf("expected %q in MarkdownV2, got %+v", want, messages[0])
+	}
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/telegram"
+)
+
+// ParseURL creates a notifier from an Apprise-style notification URL, e.g. "gotifys://gotify.example.com/AbCdEf"
+// or "pover://user_key@app_token", checked by config.ParseNotifyURL. Errors never contain the URL, as it contains tokens.
+func ParseURL(raw string, cfg config.Config) (Notifier, error) {
+	u, err := config.ParseNotifyURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "discord":
+		return &DiscordNotifier{URL: "https://discord.com/api/webhooks/" + url.PathEscape(u.Host) + "/" + url.PathEscape(u.Path[0]), Plan: cfg.Plan, Pair: cfg.Pair.String()}, nil
+	case "gotify", "gotifys":
+		last := len(u.Path) - 1
+		base := (&url.URL{Scheme: httpScheme(u.Scheme), Host: u.Host, Path: "/" + strings.Join(u.Path[:last], "/")}).String()
+		return &GotifyNotifier{URL: base, Token: u.Path[last], Plan: cfg.Plan}, nil
+	case "json", "jsons":
+		target := &url.URL{Scheme: httpScheme(u.Scheme), Host: u.Host, Path: "/" + strings.Join(u.Path, "/")}
+		return &WebhookNotifier{
+			URL:         target.String(),
+			Template:    defaultWebhookTemplate,
+			ContentType: "application/json",
+			Plan:        cfg.Plan,
+			Pair:        cfg.Pair.String(),
+			Client:      &http.Client{Timeout: chatTimeout},
+		}, nil
+	case "ntfy", "ntfys":
+		if len(u.Path) == 0 {
+			// ntfy://topic uses the public server, always over https
+			return &NtfyNotifier{Topic: u.Host, URL: "https://ntfy.sh", Client: &http.Client{Timeout: chatTimeout}}, nil
+		}
+		return &NtfyNotifier{Topic: u.Path[0], URL: httpScheme(u.Scheme) + "://" + u.Host, Client: &http.Client{Timeout: chatTimeout}}, nil
+	case "pover":
+		return &PushoverNotifier{Token: u.Host, User: u.User, Device: strings.Join(u.Path, ","), Plan: cfg.Plan}, nil
+	case "slack":
+		return &SlackNotifier{URL: "https://hooks.slack.com/services/" + u.Host + "/" + u.Path[0] + "/" + u.Path[1], Plan: cfg.Plan, Pair: cfg.Pair.String()}, nil
+	case "tgram":
+		return &TelegramNotifier{Client: &telegram.Client{Token: u.Host}, ChatIDs: u.ChatIDs, Plan: cfg.Plan}, nil
+	}
+	return nil, fmt.Errorf("unsupported notification URL scheme %q", u.Scheme)
+}
+
+// httpScheme returns https for the secure variant of a scheme, e.g. gotifys, and http otherwise.
+func httpScheme(scheme string) string {
+	if strings.HasSuffix(scheme, "s") {
+		return "https"
+	}
+	return "http"
+}
+
+// MultiNotifier sends every notification to several notifiers.
+type MultiNotifier []Notifier
+
+// Notify sends the notification to every notifier, returning the errors of all that failed.
+func (m MultiNotifier) Notify(ctx context.Context, subject, message string) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, subject, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyReport sends the notification to every notifier, with the order details to those that format them.
+func (m MultiNotifier) NotifyReport(ctx context.Context, subject, message string, report OrderReport) error {
+	var errs []error
+	for _, n := range m {
+		var err error
+		if rn, ok := n.(ReportNotifier); ok {
+			err = rn.NotifyReport(ctx, subject, message, report)
+		} else {
+			err = n.Notify(ctx, subject, message)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+// SignatureHeader is the header carrying the HMAC-SHA256 signature of a webhook body, as
+// "sha256=<hex>" like GitHub webhooks, so existing verification code can be reused.
+const SignatureHeader = "X-Easy-DCA-Signature"
+
+// DefaultWebhookTemplate is the request body of webhooks without a custom template.
+const DefaultWebhookTemplate = `{"event": {{json .Event}}, "subject": {{json .Subject}}, "message": {{json .Message}}, "plan": {{json .Plan}}, "pair": {{json .Pair}}, "time": {{json .Time}}}`
+
+// defaultWebhookTemplate is DefaultWebhookTemplate, parsed.
+var defaultWebhookTemplate = template.Must(config.ParseWebhookTemplate(DefaultWebhookTemplate))
+
+// WebhookEvent is the data of webhook body templates.
+type WebhookEvent struct {
+	Event   string    // Kind of notification derived from the subject, e.g. "success", "error", "config_reloaded"
+	Subject string    // Notification subject, e.g. "DCA Success"
+	Message string    // Notification message
+	Plan    string    // Plan name (empty without plans)
+	Pair    string    // Trading pair, e.g. "BTC/EUR"
+	Time    time.Time // Time of the notification
+}
+
+// WebhookNotifier sends notifications as HTTP POST requests with a templated body.
+type WebhookNotifier struct {
+	URL         string
+	Headers     http.Header
+	Template    *template.Template
+	ContentType string
+	HMACKey     []byte // Signs the body if set
+	Plan        string
+	Pair        string
+	Client      *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier from the configuration, parsing its body template.
+func NewWebhookNotifier(cfg config.Config) (*WebhookNotifier, error) {
+	text := cfg.NotifyWebhookTemplate
+	if text == "" {
+		text = DefaultWebhookTemplate
+	}
+	tmpl, err := config.ParseWebhookTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	n := &WebhookNotifier{
+		URL:         cfg.NotifyWebhookURL,
+		Headers:     cfg.NotifyWebhookHeaders,
+		Template:    tmpl,
+		ContentType: cfg.NotifyWebhookContentType,
+		Plan:        cfg.Plan,
+		Pair:        cfg.Pair.String(),
+		Client:      &http.Client{Timeout: cfg.NotifyWebhookTimeout},
+	}
+	if cfg.NotifyWebhookHMACKey != "" {
+		n.HMACKey = []byte(cfg.NotifyWebhookHMACKey)
+	}
+	return n, nil
+}
+
+// Notify renders the body template and POSTs it to the webhook URL.
+func (n *WebhookNotifier) Notify(ctx context.Context, subject, message string) error {
+	var body bytes.Buffer
+	event := WebhookEvent{
+		Event:   eventName(subject),
+		Subject: subject,
+		Message: message,
+		Plan:    n.Plan,
+		Pair:    n.Pair,
+		Time:    time.Now().UTC(),
+	}
+	if err := n.Template.Execute(&body, event); err != nil {
+		return fmt.Errorf("webhook template: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	if n.ContentType != "" {
+		req.Header.Set("Content-Type", n.ContentType)
+	}
+	for name, values := range n.Headers {
+		req.Header[name] = values
+	}
+	if len(n.HMACKey) > 0 {
+		mac := hmac.New(sha256.New, n.HMACKey)
+		mac.Write(body.Bytes())
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may contain a secret, such as a Home Assistant webhook ID
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook notification failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// eventName derives the kind of a notification from its subject, e.g. "config_reloaded" from "DCA Config Reloaded".
+func eventName(subject string) string {
+	name := strings.TrimPrefix(subject, "DCA ")
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+)
+
+func webhookConfig(t *testing.T, url string) config.Config {
+	t.Helper()
+	pair, err := config.NewTradingPair("BTC/EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Config{
+		Pair:                     pair,
+		Plan:                     "weekly",
+		NotifyMethod:             "webhook",
+		NotifyWebhookURL:         url,
+		NotifyWebhookContentType: "application/json",
+		NotifyWebhookTimeout:     time.Second,
+	}
+}
+
+// webhookServer records the last request it received.
+type webhookServer struct {
+	*httptest.Server
+	method string
+	path   string
+	header http.Header
+	body   []byte
+	status int
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	s := &webhookServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.method, s.path = r.Method, r.URL.EscapedPath()
+		s.header = r.Header.Clone()
+		s.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestWebhookNotifier_DefaultPayload(t *testing.T) {
+	srv := newWebhookServer(t)
+	n := CreateNotifier(webhookConfig(t, srv.URL+"/api/webhook/secret-id"))
+	if n == nil {
+		t.Fatal("expected a webhook notifier")
+	}
+	if err := n.Notify(context.Background(), "DCA Config Reloaded", `Changed "PriceFactor"`); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(srv.body, &payload); err != nil {
+		t.Fatalf("expected a JSON body, got %q: %v", srv.body, err)
+	}
+	if payload["event"] != "config_reloaded" || payload["subject"] != "DCA Config Reloaded" || payload["message"] != `Changed "PriceFactor"` ||
+		payload["plan"] != "weekly" || payload["pair"] != "BTC/EUR" || payload["time"] == "" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+	if got := srv.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", got)
+	}
+	if got := srv.header.Get(SignatureHeader); got != "" {
+		t.Errorf("expected no signature without a key, got %q", got)
+	}
+}
+
+func TestWebhookNotifier_TemplateHeadersAndSignature(t *testing.T) {
+	srv := newWebhookServer(t)
+	cfg := webhookConfig(t, srv.URL)
+	cfg.NotifyWebhookTemplate = `{"text": {{json (printf "%s: %s" .Subject .Message)}}}`
+	cfg.NotifyWebhookHeaders = http.Header{"Authorization": {"Bearer token"}}
+	cfg.NotifyWebhookHMACKey = "webhook-signing-key"
+	n, err := NewWebhookNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), "DCA Success", "Bought 0.001 BTC"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if string(srv.body) != `{"text": "DCA Success: Bought 0.001 BTC"}` {
+		t.Errorf("unexpected body %q", srv.body)
+	}
+	if got := srv.header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("expected the configured header, got %q", got)
+	}
+	mac := hmac.New(sha256.New, []byte("webhook-signing-key"))
+	mac.Write(srv.body)
+	if got, want := srv.header.Get(SignatureHeader), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+}
+
+func TestWebhookNotifier_Errors(t *testing.T) {
+	srv := newWebhookServer(t)
+	srv.status = http.StatusUnauthorized
+	n, err := NewWebhookNotifier(webhookConfig(t, srv.URL+"/secret-id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), "DCA Error", "failed"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected a status error, got %v", err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	cfg := webhookConfig(t, slow.URL+"/secret-id")
+	cfg.NotifyWebhookTimeout = 20 * time.Millisecond
+	n, err = NewWebhookNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Notify(context.Background(), "DCA Error", "failed")
+	if err == nil || strings.Contains(err.Error(), "secret-id") {
+		t.Errorf("expected a timeout error without the URL, got %v", err)
+	}
+
+	cfg.NotifyWebhookTemplate = "{{.Unknown"
+	if _, err := NewWebhookNotifier(cfg); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+	if CreateNotifier(cfg) != nil {
+		t.Error("expected notifications to be disabled with an invalid template")
+	}
+}
@@ -0,0 +1,246 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the number of fractional digits a Decimal can represent exactly.
+const DecimalPlaces = 10
+
+// decimalUnit is the number of units in 1 (10^DecimalPlaces).
+const decimalUnit = 10_000_000_000
+
+// Decimal is a fixed-point decimal number with DecimalPlaces fractional digits.
+// Its range is about ±922,337,203 with 10 fractional digits, which is plenty for
+// fiat amounts, prices and volumes of the supported pairs. The zero value is 0.
+// Decimals are comparable with ==.
+type Decimal struct {
+	units int64 // value × 10^DecimalPlaces
+}
+
+// RoundingMode determines how a Decimal is rounded to fewer fractional digits.
+type RoundingMode int
+
+const (
+	RoundDown     RoundingMode = iota // Round toward zero (truncate)
+	RoundUp                           // Round away from zero
+	RoundHalfUp                       // Round to nearest, ties away from zero
+	RoundHalfEven                     // Round to nearest, ties to even (banker's rounding)
+)
+
+// Zero is the Decimal value 0.
+var Zero = Decimal{}
+
+// NewDecimalFromInt returns the Decimal value of n.
+func NewDecimalFromInt(n int64) Decimal {
+	return Decimal{units: checkedUnits(new(big.Int).Mul(big.NewInt(n), big.NewInt(decimalUnit)))}
+}
+
+// ParseDecimal parses a decimal string such as "123.45", "-0.0001" or "1e-5".
+// Returns an error if the value has more than DecimalPlaces significant fractional digits
+// or does not fit into a Decimal.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	// big.Rat also accepts fractions and hex/octal/binary floats, which are not decimals
+	if s == "" || strings.Trim(s, "0123456789.eE+-") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+	units := r.Mul(r, new(big.Rat).SetInt64(decimalUnit))
+	if !units.IsInt() {
+		return Decimal{}, fmt.Errorf("decimal %q has more than %d fractional digits", s, DecimalPlaces)
+	}
+	if !units.Num().IsInt64() {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+	}
+	return Decimal{units: units.Num().Int64()}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a valid decimal.
+// It is meant for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Add returns d + o.
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{units: checkedUnits(new(big.Int).Add(big.NewInt(d.units), big.NewInt(o.units)))}
+}
+
+// Sub returns d - o.
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{units: checkedUnits(new(big.Int).Sub(big.NewInt(d.units), big.NewInt(o.units)))}
+}
+
+// Mul returns d × o, rounded half-even to DecimalPlaces fractional digits.
+func (d Decimal) Mul(o Decimal) Decimal {
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+	return Decimal{units: checkedUnits(divRound(product, big.NewInt(decimalUnit), RoundHalfEven))}
+}
+
+// Div returns d ÷ o rounded to the given number of fractional digits (at most DecimalPlaces)
+// using the given rounding mode. It panics if o is zero.
+func (d Decimal) Div(o Decimal, places int, mode RoundingMode) Decimal {
+	if o.units == 0 {
+		panic("order: decimal division by zero")
+	}
+	places = clampPlaces(places)
+	// d/o at `places` digits = d.units × 10^places / o.units, then scaled back to DecimalPlaces
+	num := new(big.Int).Mul(big.NewInt(d.units), pow10(places))
+	q := divRound(num, big.NewInt(o.units), mode)
+	return Decimal{units: checkedUnits(q.Mul(q, pow10(DecimalPlaces-places)))}
+}
+
+// Round returns d rounded to the given number of fractional digits using the given rounding mode.
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	places = clampPlaces(places)
+	step := pow10(DecimalPlaces - places)
+	q := divRound(big.NewInt(d.units), step, mode)
+	return Decimal{units: checkedUnits(q.Mul(q, step))}
+}
+
+// RoundToStep returns d rounded to a multiple of step (e.g. a price tick or lot size)
+// using the given rounding mode. A zero or negative step returns d unchanged.
+func (d Decimal) RoundToStep(step Decimal, mode RoundingMode) Decimal {
+	if step.units <= 0 {
+		return d
+	}
+	q := divRound(big.NewInt(d.units), big.NewInt(step.units), mode)
+	return Decimal{units: checkedUnits(q.Mul(q, big.NewInt(step.units)))}
+}
+
+// Cmp compares d and o and returns -1 if d < o, 0 if d == o and +1 if d > o.
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LessThan reports whether d < o.
+func (d Decimal) LessThan(o Decimal) bool { return d.units < o.units }
+
+// GreaterThan reports whether d > o.
+func (d Decimal) GreaterThan(o Decimal) bool { return d.units > o.units }
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int { return d.Cmp(Zero) }
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+// IntPart returns the integer part of d, truncated toward zero.
+func (d Decimal) IntPart() int64 { return d.units / decimalUnit }
+
+// Float64 returns the nearest float64 value of d. Only use it for display or statistics.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac64(d.units, decimalUnit).Float64()
+	return f
+}
+
+// String returns d without trailing fractional zeros, e.g. "0.00016666" or "60000.1".
+func (d Decimal) String() string {
+	s := d.StringFixed(DecimalPlaces)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// StringFixed returns d rounded half-up to exactly the given number of fractional digits.
+func (d Decimal) StringFixed(places int) string {
+	places = clampPlaces(places)
+	r := d.Round(places, RoundHalfUp)
+	sign := ""
+	units := r.units
+	if units < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(units))
+	intPart, frac := new(big.Int).QuoRem(abs, big.NewInt(decimalUnit), new(big.Int))
+	if places == 0 {
+		return sign + intPart.String()
+	}
+	fracStr := fmt.Sprintf("%0*s", DecimalPlaces, frac.String())[:places]
+	return sign + intPart.String() + "." + fracStr
+}
+
+// MarshalJSON encodes d as a JSON string, the format Kraken expects for prices and volumes.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a JSON string or number into d without going through float64.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// divRound returns num ÷ den rounded to an integer using the given rounding mode.
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+	// Sign of the exact quotient, used to round away from zero
+	sign := int64(num.Sign() * den.Sign())
+	var roundAway bool
+	switch mode {
+	case RoundUp:
+		roundAway = true
+	case RoundHalfUp, RoundHalfEven:
+		twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+		switch twice.Cmp(new(big.Int).Abs(den)) {
+		case 1:
+			roundAway = true
+		case 0:
+			roundAway = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	}
+	if roundAway {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+// checkedUnits converts n to int64 units, panicking if the result does not fit into a Decimal.
+func checkedUnits(n *big.Int) int64 {
+	if !n.IsInt64() {
+		panic(fmt.Sprintf("order: decimal overflow (%s × 10^-%d)", n.String(), DecimalPlaces))
+	}
+	return n.Int64()
+}
+
+func clampPlaces(places int) int {
+	return max(0, min(places, DecimalPlaces))
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
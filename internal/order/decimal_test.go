@@ -0,0 +1,145 @@
+package order
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"12345.67", "12345.67"},
+		{"0.00016666", "0.00016666"},
+		{"-1.50", "-1.5"},
+		{"1e-5", "0.00001"},
+		{"100", "100"},
+		{" 0.998 ", "0.998"},
+	}
+	for _, tc := range tests {
+		d, err := ParseDecimal(tc.input)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if got := d.String(); got != tc.want {
+			t.Errorf("ParseDecimal(%q) = %s; want %s", tc.input, got, tc.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "abc", "1/3", "0x1p-2", "0.00000000001", "1e12"} {
+		if _, err := ParseDecimal(invalid); err == nil {
+			t.Errorf("ParseDecimal(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	price := MustParseDecimal("60000.1")
+	factor := MustParseDecimal("0.998")
+	if got := price.Mul(factor).String(); got != "59880.0998" {
+		t.Errorf("60000.1 × 0.998 = %s; want 59880.0998", got)
+	}
+	if got := MustParseDecimal("0.1").Add(MustParseDecimal("0.2")); got != MustParseDecimal("0.3") {
+		t.Errorf("0.1 + 0.2 = %s; want 0.3", got)
+	}
+	if got := MustParseDecimal("10").Sub(MustParseDecimal("10.5")).String(); got != "-0.5" {
+		t.Errorf("10 - 10.5 = %s; want -0.5", got)
+	}
+	// 10 EUR at 60,000 EUR/BTC, rounded down to the 8 decimal lot size
+	if got := NewDecimalFromInt(10).Div(NewDecimalFromInt(60000), 8, RoundDown).String(); got != "0.00016666" {
+		t.Errorf("10 ÷ 60000 = %s; want 0.00016666", got)
+	}
+	if got := NewDecimalFromInt(10).Div(NewDecimalFromInt(60000), 8, RoundUp).String(); got != "0.00016667" {
+		t.Errorf("10 ÷ 60000 rounded up = %s; want 0.00016667", got)
+	}
+	if got := NewDecimalFromInt(300).Div(NewDecimalFromInt(31), 2, RoundHalfEven).String(); got != "9.68" {
+		t.Errorf("300 ÷ 31 = %s; want 9.68", got)
+	}
+}
+
+func TestDecimalRoundToStep(t *testing.T) {
+	tick := MustParseDecimal("0.1")
+	tests := []struct {
+		input string
+		mode  RoundingMode
+		want  string
+	}{
+		{"1.234", RoundDown, "1.2"},
+		{"1.25", RoundDown, "1.2"},
+		{"1.26", RoundDown, "1.2"},
+		{"0", RoundDown, "0"},
+		{"-1.27", RoundDown, "-1.2"},
+		{"2.99", RoundDown, "2.9"},
+		{"1.21", RoundUp, "1.3"},
+		{"1.25", RoundHalfUp, "1.3"},
+		{"1.25", RoundHalfEven, "1.2"},
+		{"1.35", RoundHalfEven, "1.4"},
+		{"-1.25", RoundHalfUp, "-1.3"},
+	}
+	for _, tc := range tests {
+		got := MustParseDecimal(tc.input).RoundToStep(tick, tc.mode).String()
+		if got != tc.want {
+			t.Errorf("RoundToStep(%s, 0.1, %d) = %s; want %s", tc.input, tc.mode, got, tc.want)
+		}
+	}
+
+	lot := MustParseDecimal("0.00000001")
+	if got := MustParseDecimal("0.0001666666").RoundToStep(lot, RoundDown).String(); got != "0.00016666" {
+		t.Errorf("RoundToStep to lot size = %s; want 0.00016666", got)
+	}
+}
+
+func TestDecimalStringFixed(t *testing.T) {
+	tests := []struct {
+		input  string
+		places int
+		want   string
+	}{
+		{"59880.0998", 2, "59880.10"},
+		{"0.00005", 8, "0.00005000"},
+		{"-0.005", 2, "-0.01"},
+		{"12", 0, "12"},
+		{"0.998", 4, "0.9980"},
+	}
+	for _, tc := range tests {
+		if got := MustParseDecimal(tc.input).StringFixed(tc.places); got != tc.want {
+			t.Errorf("StringFixed(%s, %d) = %s; want %s", tc.input, tc.places, got, tc.want)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]any{"volume": MustParseDecimal("0.00016666")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"volume":"0.00016666"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	var v struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"0.1","b":0.2}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.Add(v.B) != MustParseDecimal("0.3") {
+		t.Errorf("expected 0.1 + 0.2 = 0.3, got %s", v.A.Add(v.B))
+	}
+}
+
+func TestDecimalComparison(t *testing.T) {
+	a, b := MustParseDecimal("0.00005"), MustParseDecimal("0.000055")
+	if !a.LessThan(b) || a.GreaterThan(b) || a.Cmp(b) != -1 {
+		t.Errorf("expected %s < %s", a, b)
+	}
+	if !Zero.IsZero() || Zero.Sign() != 0 || MustParseDecimal("-1").Sign() != -1 {
+		t.Errorf("unexpected sign handling")
+	}
+	if got := MustParseDecimal("0.00012345").Mul(NewDecimalFromInt(100_000_000)).IntPart(); got != 12345 {
+		t.Errorf("IntPart = %d; want 12345", got)
+	}
+}
@@ -0,0 +1,179 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyOrderBook is returned when an analysis needs orders on a side that has none.
+var ErrEmptyOrderBook = errors.New("order book side is empty")
+
+// ErrInsufficientLiquidity is returned when the order book is not deep enough to fill an amount.
+var ErrInsufficientLiquidity = errors.New("insufficient order book liquidity")
+
+// Side selects the asks or the bids of an order book.
+type Side int
+
+const (
+	Asks Side = iota // Sell orders; a buy fills against the asks
+	Bids             // Buy orders; a sell fills against the bids
+)
+
+// String returns the name of the side.
+func (s Side) String() string {
+	if s == Bids {
+		return "bids"
+	}
+	return "asks"
+}
+
+// DepthLevel is a price level with the cumulative volume and cost up to and including it.
+type DepthLevel struct {
+	Price         Decimal // Price of the level
+	Volume        Decimal // Volume at this level
+	CumVolume     Decimal // Total volume from the best price up to this level
+	CumQuoteValue Decimal // Total quote (fiat) value from the best price up to this level
+}
+
+// Fill describes how an amount would be filled by walking the order book.
+type Fill struct {
+	Volume      Decimal // Base volume filled
+	QuoteValue  Decimal // Quote (fiat) value of the fill
+	VWAP        Decimal // Volume-weighted average price of the fill
+	WorstPrice  Decimal // Price of the last level touched
+	Levels      int     // Number of price levels touched
+	SlippageBps Decimal // Distance of the VWAP from the best price, in basis points
+}
+
+// orders returns the orders of the given side, best price first.
+func (ob OrderBook) orders(side Side) []Order {
+	if side == Bids {
+		return ob.Bids
+	}
+	return ob.Asks
+}
+
+// Best returns the best order of the given side (lowest ask or highest bid).
+func (ob OrderBook) Best(side Side) (Order, error) {
+	orders := ob.orders(side)
+	if len(orders) == 0 {
+		return Order{}, fmt.Errorf("%w: no %s", ErrEmptyOrderBook, side)
+	}
+	return orders[0], nil
+}
+
+// MidPrice returns the midpoint between the best ask and the best bid.
+func (ob OrderBook) MidPrice() (Decimal, error) {
+	ask, err := ob.Best(Asks)
+	if err != nil {
+		return Zero, err
+	}
+	bid, err := ob.Best(Bids)
+	if err != nil {
+		return Zero, err
+	}
+	return ask.Price.Add(bid.Price).Div(NewDecimalFromInt(2), DecimalPlaces, RoundHalfEven), nil
+}
+
+// SpreadBps returns the bid-ask spread relative to the mid price, in basis points.
+func (ob OrderBook) SpreadBps() (Decimal, error) {
+	mid, err := ob.MidPrice()
+	if err != nil {
+		return Zero, err
+	}
+	if mid.IsZero() {
+		return Zero, fmt.Errorf("mid price is zero")
+	}
+	spread := ob.Asks[0].Price.Sub(ob.Bids[0].Price)
+	return toBps(spread, mid), nil
+}
+
+// Depth returns the cumulative depth of the given side, best price first.
+func (ob OrderBook) Depth(side Side) []DepthLevel {
+	orders := ob.orders(side)
+	levels := make([]DepthLevel, 0, len(orders))
+	cumVolume, cumValue := Zero, Zero
+	for _, o := range orders {
+		cumVolume = cumVolume.Add(o.Volume)
+		cumValue = cumValue.Add(o.Price.Mul(o.Volume))
+		levels = append(levels, DepthLevel{
+			Price:         o.Price,
+			Volume:        o.Volume,
+			CumVolume:     cumVolume,
+			CumQuoteValue: cumValue,
+		})
+	}
+	return levels
+}
+
+// FillBase walks the given side to fill a base (BTC) volume.
+// If the book is not deep enough, the partial fill is returned with ErrInsufficientLiquidity.
+func (ob OrderBook) FillBase(side Side, volume Decimal) (Fill, error) {
+	return ob.fill(side, func(o Order, f Fill) Decimal {
+		return volume.Sub(f.Volume)
+	})
+}
+
+// FillQuote walks the given side to fill a quote (fiat) amount.
+// Volumes are rounded down, so the fill may be worth slightly less than amount.
+// If the book is not deep enough, the partial fill is returned with ErrInsufficientLiquidity.
+func (ob OrderBook) FillQuote(side Side, amount Decimal) (Fill, error) {
+	return ob.fill(side, func(o Order, f Fill) Decimal {
+		return amount.Sub(f.QuoteValue).Div(o.Price, DecimalPlaces, RoundDown)
+	})
+}
+
+// fill walks the orders of a side, best price first. want returns the volume still needed
+// at the price of the given level; the fill is complete once a level covers it.
+func (ob OrderBook) fill(side Side, want func(o Order, f Fill) Decimal) (Fill, error) {
+	orders := ob.orders(side)
+	if len(orders) == 0 {
+		return Fill{}, fmt.Errorf("%w: no %s", ErrEmptyOrderBook, side)
+	}
+
+	var f Fill
+	complete := false
+	for _, o := range orders {
+		needed := want(o, f)
+		if needed.Sign() <= 0 {
+			complete = true
+			break
+		}
+		volume := minDecimal(needed, o.Volume)
+		f.Volume = f.Volume.Add(volume)
+		f.QuoteValue = f.QuoteValue.Add(o.Price.Mul(volume))
+		f.WorstPrice = o.Price
+		f.Levels++
+		if volume == needed {
+			complete = true
+			break
+		}
+	}
+
+	if f.Volume.Sign() > 0 {
+		best := orders[0].Price
+		f.VWAP = f.QuoteValue.Div(f.Volume, DecimalPlaces, RoundHalfEven)
+		// Slippage is the price moving against us: up for buys, down for sells
+		if side == Bids {
+			f.SlippageBps = toBps(best.Sub(f.VWAP), best)
+		} else {
+			f.SlippageBps = toBps(f.VWAP.Sub(best), best)
+		}
+	}
+	if !complete {
+		return f, fmt.Errorf("%w: %d %s only cover %s (%s quote)", ErrInsufficientLiquidity, f.Levels, side, f.Volume, f.QuoteValue.StringFixed(2))
+	}
+	return f, nil
+}
+
+// toBps returns part / whole in basis points, rounded to two decimals.
+func toBps(part, whole Decimal) Decimal {
+	return part.Div(whole, DecimalPlaces, RoundHalfEven).Mul(NewDecimalFromInt(10000)).Round(2, RoundHalfEven)
+}
+
+func minDecimal(a, b Decimal) Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,120 @@
+package order
+
+import (
+	"errors"
+	"testing"
+)
+
+func testBook() OrderBook {
+	return OrderBook{
+		Asks: []Order{
+			{Price: MustParseDecimal("60000"), Volume: MustParseDecimal("0.1")},
+			{Price: MustParseDecimal("60010"), Volume: MustParseDecimal("0.2")},
+			{Price: MustParseDecimal("60100"), Volume: MustParseDecimal("0.5")},
+		},
+		Bids: []Order{
+			{Price: MustParseDecimal("59990"), Volume: MustParseDecimal("0.3")},
+			{Price: MustParseDecimal("59900"), Volume: MustParseDecimal("1")},
+		},
+	}
+}
+
+func TestOrderBookMidPriceAndSpread(t *testing.T) {
+	ob := testBook()
+	mid, err := ob.MidPrice()
+	if err != nil {
+		t.Fatalf("MidPrice returned error: %v", err)
+	}
+	if mid != MustParseDecimal("59995") {
+		t.Errorf("expected mid price 59995, got %s", mid)
+	}
+	spread, err := ob.SpreadBps()
+	if err != nil {
+		t.Fatalf("SpreadBps returned error: %v", err)
+	}
+	// 10 / 59995 × 10000 = 1.6668...
+	if spread != MustParseDecimal("1.67") {
+		t.Errorf("expected spread 1.67 bps, got %s", spread)
+	}
+}
+
+func TestOrderBookEmptySide(t *testing.T) {
+	ob := OrderBook{Asks: testBook().Asks}
+	if _, err := ob.MidPrice(); !errors.Is(err, ErrEmptyOrderBook) {
+		t.Errorf("expected ErrEmptyOrderBook, got %v", err)
+	}
+	if _, err := ob.FillBase(Bids, MustParseDecimal("0.1")); !errors.Is(err, ErrEmptyOrderBook) {
+		t.Errorf("expected ErrEmptyOrderBook, got %v", err)
+	}
+}
+
+func TestOrderBookDepth(t *testing.T) {
+	depth := testBook().Depth(Asks)
+	if len(depth) != 3 {
+		t.Fatalf("expected 3 levels, got %d", len(depth))
+	}
+	last := depth[2]
+	if last.CumVolume != MustParseDecimal("0.8") {
+		t.Errorf("expected cumulative volume 0.8, got %s", last.CumVolume)
+	}
+	// 6000 + 12002 + 30050
+	if last.CumQuoteValue != MustParseDecimal("48052") {
+		t.Errorf("expected cumulative value 48052, got %s", last.CumQuoteValue)
+	}
+}
+
+func TestOrderBookFillBase(t *testing.T) {
+	f, err := testBook().FillBase(Asks, MustParseDecimal("0.2"))
+	if err != nil {
+		t.Fatalf("FillBase returned error: %v", err)
+	}
+	// 0.1 @ 60000 + 0.1 @ 60010
+	if f.QuoteValue != MustParseDecimal("12001") || f.VWAP != MustParseDecimal("60005") {
+		t.Errorf("expected value 12001 at VWAP 60005, got %s at %s", f.QuoteValue, f.VWAP)
+	}
+	if f.Levels != 2 || f.WorstPrice != MustParseDecimal("60010") {
+		t.Errorf("expected 2 levels up to 60010, got %d up to %s", f.Levels, f.WorstPrice)
+	}
+	if f.SlippageBps != MustParseDecimal("0.83") {
+		t.Errorf("expected slippage 0.83 bps, got %s", f.SlippageBps)
+	}
+
+	f, err = testBook().FillBase(Bids, MustParseDecimal("0.3"))
+	if err != nil {
+		t.Fatalf("FillBase returned error: %v", err)
+	}
+	if f.Levels != 1 || !f.SlippageBps.IsZero() {
+		t.Errorf("expected an exact fill of the best bid, got %d levels and %s bps", f.Levels, f.SlippageBps)
+	}
+}
+
+func TestOrderBookFillQuote(t *testing.T) {
+	f, err := testBook().FillQuote(Asks, MustParseDecimal("10"))
+	if err != nil {
+		t.Fatalf("FillQuote returned error: %v", err)
+	}
+	if f.Volume != MustParseDecimal("0.0001666666") || f.Levels != 1 {
+		t.Errorf("expected 0.0001666666 from one level, got %s from %d", f.Volume, f.Levels)
+	}
+	if f.QuoteValue.GreaterThan(MustParseDecimal("10")) {
+		t.Errorf("fill value %s exceeds the requested amount", f.QuoteValue)
+	}
+
+	f, err = testBook().FillQuote(Asks, MustParseDecimal("18002"))
+	if err != nil {
+		t.Fatalf("FillQuote returned error: %v", err)
+	}
+	if f.Volume != MustParseDecimal("0.3") || f.Levels != 2 {
+		t.Errorf("expected 0.3 from two levels, got %s from %d", f.Volume, f.Levels)
+	}
+}
+
+func TestOrderBookFillInsufficientLiquidity(t *testing.T) {
+	f, err := testBook().FillQuote(Asks, MustParseDecimal("100000"))
+	if !errors.Is(err, ErrInsufficientLiquidity) {
+		t.Fatalf("expected ErrInsufficientLiquidity, got %v", err)
+	}
+	if f.Volume != MustParseDecimal("0.8") || f.Levels != 3 {
+		t.Errorf("expected the partial fill of the whole book, got %s from %d levels", f.Volume, f.Levels)
+	}
+}
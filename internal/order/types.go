@@ -8,15 +8,17 @@ import (
 )
 
 // Order represents a single order entry [price, volume, timestamp].
+// Price and volume are exact decimals as sent by Kraken.
 type Order struct {
-	Price     float64 // Order price
-	Volume    float64 // Order volume
+	Price     Decimal // Order price
+	Volume    Decimal // Order volume
 	Timestamp float64 // Order timestamp
 }
 
 // UnmarshalJSON implements json.Unmarshaler for Order
 func (o *Order) UnmarshalJSON(data []byte) error {
-	var arr []interface{}
+	// Decode into raw elements so prices and volumes are parsed exactly rather than via float64
+	var arr []json.RawMessage
 	if err := json.Unmarshal(data, &arr); err != nil {
 		return err
 	}
@@ -24,34 +26,29 @@ func (o *Order) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("expected array of 3 elements, got %d", len(arr))
 	}
 
-	// Convert each element to float64, handling both string and number types
-	for i, val := range arr {
-		var floatVal float64
-		var err error
-
-		switch v := val.(type) {
-		case string:
-			floatVal, err = strconv.ParseFloat(v, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse string to float: %v", err)
-			}
-		case float64:
-			floatVal = v
-		case int:
-			floatVal = float64(v)
-		default:
-			return fmt.Errorf("unexpected type %T for array element", v)
-		}
+	if err := o.Price.UnmarshalJSON(arr[0]); err != nil {
+		return fmt.Errorf("failed to parse price: %v", err)
+	}
+	if err := o.Volume.UnmarshalJSON(arr[1]); err != nil {
+		return fmt.Errorf("failed to parse volume: %v", err)
+	}
 
-		// Assign to appropriate field
-		switch i {
-		case 0:
-			o.Price = floatVal
-		case 1:
-			o.Volume = floatVal
-		case 2:
-			o.Timestamp = floatVal
+	// Timestamps are not used for arithmetic, so float64 is sufficient
+	var ts any
+	if err := json.Unmarshal(arr[2], &ts); err != nil {
+		return err
+	}
+	switch v := ts.(type) {
+	case string:
+		floatVal, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse string to float: %v", err)
 		}
+		o.Timestamp = floatVal
+	case float64:
+		o.Timestamp = v
+	default:
+		return fmt.Errorf("unexpected type %T for array element", v)
 	}
 
 	return nil
@@ -64,8 +61,8 @@ type OrderBook struct {
 }
 
 // GetPrice returns the price of the order.
-func (o Order) GetPrice() float64     { return o.Price }
+func (o Order) GetPrice() Decimal     { return o.Price }
 // GetVolume returns the volume of the order.
-func (o Order) GetVolume() float64    { return o.Volume }
+func (o Order) GetVolume() Decimal    { return o.Volume }
 // GetTimestamp returns the timestamp of the order.
 func (o Order) GetTimestamp() float64 { return o.Timestamp } 
\ No newline at end of file
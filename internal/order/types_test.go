@@ -12,10 +12,10 @@ func TestOrderUnmarshalJSON(t *testing.T) {
 	if err := json.Unmarshal([]byte(jsonData), &o); err != nil {
 		t.Fatalf("failed to unmarshal Order: %v", err)
 	}
-	if o.Price != 12345.67 {
+	if o.Price != MustParseDecimal("12345.67") {
 		t.Errorf("expected Price 12345.67, got %v", o.Price)
 	}
-	if o.Volume != 0.01 {
+	if o.Volume != MustParseDecimal("0.01") {
 		t.Errorf("expected Volume 0.01, got %v", o.Volume)
 	}
 	if o.Timestamp != 1680000000 {
@@ -23,12 +23,26 @@ func TestOrderUnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestOrderUnmarshalJSON_Numbers(t *testing.T) {
+	jsonData := `[60000.1, 0.00016666, "1680000000.123"]`
+	var o Order
+	if err := json.Unmarshal([]byte(jsonData), &o); err != nil {
+		t.Fatalf("failed to unmarshal Order: %v", err)
+	}
+	if o.Price.String() != "60000.1" || o.Volume.String() != "0.00016666" {
+		t.Errorf("expected exact price and volume, got %s and %s", o.Price, o.Volume)
+	}
+	if o.Timestamp != 1680000000.123 {
+		t.Errorf("expected Timestamp 1680000000.123, got %v", o.Timestamp)
+	}
+}
+
 func TestOrderHelperMethods(t *testing.T) {
-	o := Order{Price: 100.5, Volume: 0.5, Timestamp: 1234567890}
-	if o.GetPrice() != 100.5 {
+	o := Order{Price: MustParseDecimal("100.5"), Volume: MustParseDecimal("0.5"), Timestamp: 1234567890}
+	if o.GetPrice() != MustParseDecimal("100.5") {
 		t.Errorf("GetPrice() = %v, want 100.5", o.GetPrice())
 	}
-	if o.GetVolume() != 0.5 {
+	if o.GetVolume() != MustParseDecimal("0.5") {
 		t.Errorf("GetVolume() = %v, want 0.5", o.GetVolume())
 	}
 	if o.GetTimestamp() != 1234567890 {
@@ -38,16 +52,16 @@ func TestOrderHelperMethods(t *testing.T) {
 
 func TestOrderBookStruct(t *testing.T) {
 	ob := OrderBook{
-		Asks: []Order{{Price: 1, Volume: 2, Timestamp: 3}},
-		Bids: []Order{{Price: 4, Volume: 5, Timestamp: 6}},
+		Asks: []Order{{Price: NewDecimalFromInt(1), Volume: NewDecimalFromInt(2), Timestamp: 3}},
+		Bids: []Order{{Price: NewDecimalFromInt(4), Volume: NewDecimalFromInt(5), Timestamp: 6}},
 	}
 	if len(ob.Asks) != 1 || len(ob.Bids) != 1 {
 		t.Errorf("unexpected asks or bids length: %+v", ob)
 	}
-	if !reflect.DeepEqual(ob.Asks[0], Order{Price: 1, Volume: 2, Timestamp: 3}) {
+	if !reflect.DeepEqual(ob.Asks[0], Order{Price: NewDecimalFromInt(1), Volume: NewDecimalFromInt(2), Timestamp: 3}) {
 		t.Errorf("unexpected ask: %+v", ob.Asks[0])
 	}
-	if !reflect.DeepEqual(ob.Bids[0], Order{Price: 4, Volume: 5, Timestamp: 6}) {
+	if !reflect.DeepEqual(ob.Bids[0], Order{Price: NewDecimalFromInt(4), Volume: NewDecimalFromInt(5), Timestamp: 6}) {
 		t.Errorf("unexpected bid: %+v", ob.Bids[0])
 	}
 } 
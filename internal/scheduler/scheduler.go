@@ -3,18 +3,60 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/metrics"
+	"github.com/mayrf/easy-dca/internal/state"
 )
 
+// ErrRunInProgress is returned by RunNow when a run is already in progress.
+var ErrRunInProgress = errors.New("a DCA run is already in progress")
+
 // DCARunner defines the interface for running a DCA operation.
+// The context is cancelled on shutdown, which ends long waits of a run such as the market fallback.
 type DCARunner interface {
-	RunDCA() error
+	RunDCA(ctx context.Context) error
+}
+
+// ManualRunner is implemented by runners that tell manual runs, requested via the control API or Telegram,
+// from scheduled ones. RunManual performs a run that is not tied to a schedule slot, and returns why
+// the run skipped its buy, if it did.
+type ManualRunner interface {
+	RunManual(ctx context.Context, requested time.Time) (skipReason string, err error)
 }
 
+// RunResult is the outcome of a run started by RunNow, as far as it is known when RunNow returns.
+type RunResult struct {
+	Done       bool   // The run finished; otherwise it continues in the background
+	SkipReason string // Why the run skipped its buy, if it did
+	Err        error  // Why the run failed, if it did
+}
+
+// Outcome returns the outcome of the run: success, skipped, error, or running if it has not finished.
+func (r RunResult) Outcome() string {
+	switch {
+	case !r.Done:
+		return "running"
+	case r.Err != nil:
+		return metrics.OutcomeError
+	case r.SkipReason != "":
+		return metrics.OutcomeSkipped
+	default:
+		return metrics.OutcomeSuccess
+	}
+}
+
+// runNowWait is how long RunNow waits for the run to finish before leaving it in the background,
+// e.g. while a limit order waits for the market fallback.
+var runNowWait = 30 * time.Second
+
 // Scheduler defines the interface for scheduling DCA operations.
 type Scheduler interface {
 	// Start begins the scheduling process. This should block until the scheduler is stopped.
@@ -25,43 +67,299 @@ type Scheduler interface {
 
 // CronScheduler implements Scheduler using cron expressions.
 type CronScheduler struct {
-	runner DCARunner
-	cron   *cron.Cron
-	expr   string
+	runner  DCARunner
+	cron    *cron.Cron
+	expr    string
+	running  atomic.Bool   // Set while a run is in progress; overlapping ticks are skipped
+	schedule cron.Schedule // Parsed cron schedule, set by Start
+	started  atomic.Bool   // Set while the cron loop is running
+	paused   atomic.Bool   // Set while scheduled runs are paused
+	skipNext atomic.Bool   // Set to skip the next scheduled run
+	store    *state.Store  // Persists paused and skip-next across restarts (nil keeps them in memory)
+	plan     string        // Plan label of the schedule metrics
+	log      atomic.Pointer[slog.Logger]
+
+	mu    sync.Mutex      // Guards runner, expr, schedule and entry, which Reload replaces, and ctx
+	entry cron.EntryID    // Cron entry of the schedule, set by Start
+	ctx   context.Context // Context of runs, set by Start
+}
+
+// Status describes the state of the cron scheduler.
+type Status struct {
+	Paused   bool      `json:"paused"`             // Scheduled runs are paused
+	SkipNext bool      `json:"skip_next"`          // The next scheduled run will be skipped
+	Running  bool      `json:"running"`            // A run is in progress
+	NextRun  time.Time `json:"next_run,omitzero"` // Time of the next scheduled run (zero if not started)
 }
 
 // NewCronScheduler creates a new cron-based scheduler.
 func NewCronScheduler(runner DCARunner, cronExpr string) *CronScheduler {
-	return &CronScheduler{
+	cs := &CronScheduler{
 		runner: runner,
 		cron:   cron.New(),
 		expr:   cronExpr,
 	}
+	cs.log.Store(slog.Default())
+	return cs
 }
 
 // Start begins the cron scheduler.
 func (cs *CronScheduler) Start(ctx context.Context) error {
+	cs.mu.Lock()
 	if cs.expr == "" {
+		cs.mu.Unlock()
 		return fmt.Errorf("cron expression is required")
 	}
-
-	_, err := cs.cron.AddFunc(cs.expr, func() {
-		if err := cs.runner.RunDCA(); err != nil {
-			log.Printf("DCA run failed: %v", err)
-		}
-	})
+	id, err := cs.cron.AddFunc(cs.expr, cs.tick)
 	if err != nil {
+		cs.mu.Unlock()
 		return fmt.Errorf("invalid cron expression: %w", err)
 	}
+	cs.entry, cs.schedule = id, cs.cron.Entry(id).Schedule
+	cs.ctx = ctx
+	expr := cs.expr
+	cs.mu.Unlock()
+	if cs.store != nil {
+		st, err := cs.store.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load scheduler state: %w", err)
+		}
+		cs.setFlags(st.Paused, st.SkipNext)
+	}
+	cs.updateNextRun()
+	if cs.paused.Load() {
+		cs.logger().Warn("Scheduled runs are paused; resume them via the control API")
+	}
 
-	log.Printf("Starting cron scheduler with expression: %s", cs.expr)
+	cs.logger().Info("Starting cron scheduler", "cron", expr)
 	cs.cron.Start()
+	cs.started.Store(true)
+	defer cs.started.Store(false)
 
 	// Wait for context cancellation
 	<-ctx.Done()
 	return nil
 }
 
+// Alive returns nil if the cron loop is running and responsive.
+// A stuck loop is detected by asking it for its entries, which the loop must answer.
+func (cs *CronScheduler) Alive(ctx context.Context) error {
+	if !cs.started.Load() {
+		return fmt.Errorf("cron scheduler is not running")
+	}
+	done := make(chan struct{})
+	go func() {
+		cs.cron.Entries()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cron scheduler is not responding")
+	}
+}
+
+// NextRuns returns the next n scheduled run times, or nil if the scheduler is not running.
+func (cs *CronScheduler) NextRuns(n int) []time.Time {
+	if !cs.started.Load() {
+		return nil
+	}
+	schedule := cs.currentSchedule()
+	runs := make([]time.Time, 0, n)
+	next := time.Now()
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs
+}
+
+// tick runs the DCA operation for one cron tick. The tick is skipped if the schedule is paused,
+// if the next run was to be skipped, or if the previous run is still in progress.
+func (cs *CronScheduler) tick() {
+	defer cs.updateNextRun()
+	if cs.paused.Load() {
+		cs.logger().Info("Skipping scheduled DCA run: schedule is paused")
+		return
+	}
+	if cs.skipNext.Load() {
+		if err := cs.update(func(st *state.State) { st.SkipNext = false }); err != nil {
+			cs.logger().Error("Failed to clear the skip-next flag", "error", err)
+		}
+		cs.logger().Info("Skipping scheduled DCA run as requested")
+		return
+	}
+	if !cs.running.CompareAndSwap(false, true) {
+		cs.logger().Warn("Skipping scheduled DCA run: previous run is still in progress")
+		return
+	}
+	defer cs.running.Store(false)
+
+	if err := cs.currentRunner().RunDCA(cs.runContext()); err != nil {
+		cs.logger().Error("DCA run failed", "error", err)
+	}
+}
+
+// RunNow starts a DCA run immediately, regardless of the schedule and pause state, and waits up to
+// runNowWait for its result; a longer run continues in the background. Runners implementing
+// ManualRunner place the order of a manual run in addition to that of the current schedule slot.
+// Returns ErrRunInProgress if a run is already in progress.
+func (cs *CronScheduler) RunNow() (RunResult, error) {
+	if !cs.running.CompareAndSwap(false, true) {
+		return RunResult{}, ErrRunInProgress
+	}
+	runner, ctx, requested := cs.currentRunner(), cs.runContext(), time.Now()
+	done := make(chan RunResult, 1)
+	go func() {
+		defer cs.running.Store(false)
+		var result RunResult
+		if manual, ok := runner.(ManualRunner); ok {
+			result.SkipReason, result.Err = manual.RunManual(ctx, requested)
+		} else {
+			result.Err = runner.RunDCA(ctx)
+		}
+		if result.Err != nil {
+			cs.logger().Error("DCA run failed", "error", result.Err)
+		}
+		result.Done = true
+		done <- result
+	}()
+
+	timer := time.NewTimer(runNowWait)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result, nil
+	case <-timer.C:
+		return RunResult{}, nil
+	}
+}
+
+// Pause stops scheduled runs until Resume is called.
+func (cs *CronScheduler) Pause() error {
+	return cs.update(func(st *state.State) { st.Paused = true })
+}
+
+// Resume resumes scheduled runs after Pause.
+func (cs *CronScheduler) Resume() error {
+	return cs.update(func(st *state.State) { st.Paused = false })
+}
+
+// SkipNext skips the next scheduled run. The following runs are not affected.
+func (cs *CronScheduler) SkipNext() error {
+	return cs.update(func(st *state.State) { st.SkipNext = true })
+}
+
+// Status returns the current state of the scheduler.
+func (cs *CronScheduler) Status() Status {
+	st := Status{
+		Paused:   cs.paused.Load(),
+		SkipNext: cs.skipNext.Load(),
+		Running:  cs.running.Load(),
+	}
+	if cs.started.Load() {
+		st.NextRun = cs.currentSchedule().Next(time.Now())
+	}
+	return st
+}
+
+// Reload replaces the runner and the cron expression with those of a reloaded configuration,
+// keeping the paused and skip-next flags. A run in progress finishes with the previous runner.
+// The scheduler is unchanged if the cron expression is invalid.
+func (cs *CronScheduler) Reload(runner DCARunner, cfg config.Config) error {
+	cronExpr := cfg.CronExpr
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	cs.log.Store(cfg.Logger())
+	cs.mu.Lock()
+	cs.runner = runner
+	if cronExpr != cs.expr {
+		cs.expr = cronExpr
+		if cs.schedule != nil {
+			// Started: replace the cron entry
+			cs.cron.Remove(cs.entry)
+			cs.entry, cs.schedule = cs.cron.Schedule(schedule, cron.FuncJob(cs.tick)), schedule
+		}
+	}
+	cs.mu.Unlock()
+	cs.updateNextRun()
+	return nil
+}
+
+// logger returns the logger of the scheduler.
+func (cs *CronScheduler) logger() *slog.Logger {
+	return cs.log.Load()
+}
+
+// currentRunner returns the runner of the next run.
+func (cs *CronScheduler) currentRunner() DCARunner {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.runner
+}
+
+// runContext returns the context of runs, which is cancelled when the scheduler stops.
+func (cs *CronScheduler) runContext() context.Context {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.ctx == nil {
+		return context.Background()
+	}
+	return cs.ctx
+}
+
+// currentSchedule returns the parsed cron schedule, or nil if the scheduler has not started.
+func (cs *CronScheduler) currentSchedule() cron.Schedule {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.schedule
+}
+
+// update changes the paused and skip-next flags, persisting them if a state store is configured.
+func (cs *CronScheduler) update(fn func(st *state.State)) error {
+	if cs.store == nil {
+		st := state.State{Paused: cs.paused.Load(), SkipNext: cs.skipNext.Load()}
+		fn(&st)
+		cs.setFlags(st.Paused, st.SkipNext)
+		return nil
+	}
+	var updated state.State
+	err := cs.store.Update(func(st *state.State) error {
+		fn(st)
+		updated = *st
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cs.setFlags(updated.Paused, updated.SkipNext)
+	return nil
+}
+
+// setFlags sets the paused and skip-next flags and the paused metric.
+func (cs *CronScheduler) setFlags(paused, skipNext bool) {
+	cs.paused.Store(paused)
+	cs.skipNext.Store(skipNext)
+	if paused {
+		metrics.SchedulePaused.Set(1, cs.plan)
+	} else {
+		metrics.SchedulePaused.Set(0, cs.plan)
+	}
+}
+
+// updateNextRun exposes the time of the next scheduled run as a metric.
+func (cs *CronScheduler) updateNextRun() {
+	if schedule := cs.currentSchedule(); schedule != nil {
+		metrics.NextRunTimestamp.SetTime(schedule.Next(time.Now()), cs.plan)
+	}
+}
+
 // Stop stops the cron scheduler.
 func (cs *CronScheduler) Stop() error {
 	ctx := cs.cron.Stop()
@@ -72,19 +370,21 @@ func (cs *CronScheduler) Stop() error {
 // OneTimeScheduler implements Scheduler for single execution.
 type OneTimeScheduler struct {
 	runner DCARunner
+	log    *slog.Logger
 }
 
 // NewOneTimeScheduler creates a new one-time scheduler.
 func NewOneTimeScheduler(runner DCARunner) *OneTimeScheduler {
 	return &OneTimeScheduler{
 		runner: runner,
+		log:    slog.Default(),
 	}
 }
 
 // Start runs the DCA operation once and returns.
 func (ots *OneTimeScheduler) Start(ctx context.Context) error {
-	log.Print("Running DCA operation once")
-	return ots.runner.RunDCA()
+	ots.log.Info("Running DCA operation once")
+	return ots.runner.RunDCA(ctx)
 }
 
 // Stop is a no-op for one-time scheduler.
@@ -96,19 +396,21 @@ func (ots *OneTimeScheduler) Stop() error {
 // This is designed to work with systemd's OnCalendar expressions.
 type SystemdScheduler struct {
 	runner DCARunner
+	log    *slog.Logger
 }
 
 // NewSystemdScheduler creates a new systemd-compatible scheduler.
 func NewSystemdScheduler(runner DCARunner) *SystemdScheduler {
 	return &SystemdScheduler{
 		runner: runner,
+		log:    slog.Default(),
 	}
 }
 
 // Start runs the DCA operation once (systemd handles the scheduling).
 func (ss *SystemdScheduler) Start(ctx context.Context) error {
-	log.Print("Running DCA operation (scheduled by systemd)")
-	return ss.runner.RunDCA()
+	ss.log.Info("Running DCA operation (scheduled by systemd)")
+	return ss.runner.RunDCA(ctx)
 }
 
 // Stop is a no-op for systemd scheduler.
@@ -117,17 +419,28 @@ func (ss *SystemdScheduler) Stop() error {
 }
 
 // CreateScheduler creates the appropriate scheduler based on configuration.
+// Schedulers of a plan log with the plan name and label their metrics with it.
 func CreateScheduler(runner DCARunner, cfg config.Config) (Scheduler, error) {
 	switch cfg.SchedulerMode {
 	case "cron":
 		if cfg.CronExpr == "" {
 			return nil, fmt.Errorf("cron scheduler mode requires EASY_DCA_CRON to be set")
 		}
-		return NewCronScheduler(runner, cfg.CronExpr), nil
+		cs := NewCronScheduler(runner, cfg.CronExpr)
+		if cfg.StateFile != "" {
+			cs.store = state.NewStore(cfg.StateFile)
+		}
+		cs.plan = cfg.Plan
+		cs.log.Store(cfg.Logger())
+		return cs, nil
 	case "systemd":
-		return NewSystemdScheduler(runner), nil
+		ss := NewSystemdScheduler(runner)
+		ss.log = cfg.Logger()
+		return ss, nil
 	case "manual":
-		return NewOneTimeScheduler(runner), nil
+		ots := NewOneTimeScheduler(runner)
+		ots.log = cfg.Logger()
+		return ots, nil
 	default:
 		return nil, fmt.Errorf("unknown scheduler mode: %s (supported: cron, systemd, manual)", cfg.SchedulerMode)
 	}
@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/state"
+)
+
+// countingRunner counts runs and blocks each run until release is closed.
+type countingRunner struct {
+	runs    atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRunner) RunDCA(ctx context.Context) error {
+	r.runs.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return nil
+}
+
+func TestCronSchedulerPauseAndSkipNext(t *testing.T) {
+	runner := &countingRunner{}
+	cs := NewCronScheduler(runner, "0 8 * * *")
+	cs.store = state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+
+	if err := cs.Pause(); err != nil {
+		t.Fatalf("Pause returned error: %v", err)
+	}
+	cs.tick()
+	if runner.runs.Load() != 0 {
+		t.Fatal("expected no run while paused")
+	}
+
+	if err := cs.Resume(); err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	if err := cs.SkipNext(); err != nil {
+		t.Fatalf("SkipNext returned error: %v", err)
+	}
+	cs.tick()
+	if runner.runs.Load() != 0 {
+		t.Fatal("expected the next run to be skipped")
+	}
+	if cs.Status().SkipNext {
+		t.Error("expected skip-next to be cleared after the skipped run")
+	}
+	cs.tick()
+	if runner.runs.Load() != 1 {
+		t.Fatalf("expected the following run to happen, got %d runs", runner.runs.Load())
+	}
+}
+
+func TestCronSchedulerPausePersists(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	cs := NewCronScheduler(&countingRunner{}, "0 8 * * *")
+	cs.store = store
+	if err := cs.Pause(); err != nil {
+		t.Fatalf("Pause returned error: %v", err)
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Paused {
+		t.Error("expected the paused flag to be persisted")
+	}
+}
+
+func TestCronSchedulerRunNow(t *testing.T) {
+	orig := runNowWait
+	runNowWait = 10 * time.Millisecond
+	t.Cleanup(func() { runNowWait = orig })
+	runner := &countingRunner{release: make(chan struct{})}
+	cs := NewCronScheduler(runner, "0 8 * * *")
+
+	if err := cs.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := cs.RunNow()
+	if err != nil {
+		t.Fatalf("RunNow returned error: %v", err)
+	}
+	if result.Done || result.Outcome() != "running" {
+		t.Errorf("expected the blocked run to continue in the background, got %+v", result)
+	}
+	if _, err := cs.RunNow(); !errors.Is(err, ErrRunInProgress) {
+		t.Errorf("expected ErrRunInProgress while a run is active, got %v", err)
+	}
+	if !cs.Status().Running {
+		t.Error("expected the status to report a running run")
+	}
+
+	close(runner.release)
+	deadline := time.Now().Add(time.Second)
+	for cs.Status().Running && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runner.runs.Load() != 1 || cs.Status().Running {
+		t.Errorf("expected one finished run, got %d runs (running: %v)", runner.runs.Load(), cs.Status().Running)
+	}
+}
+
+// manualRunner records manual runs and skips them for the given reason.
+type manualRunner struct {
+	countingRunner
+	requested  []time.Time
+	skipReason string
+}
+
+func (r *manualRunner) RunManual(ctx context.Context, requested time.Time) (string, error) {
+	r.requested = append(r.requested, requested)
+	return r.skipReason, nil
+}
+
+func TestCronSchedulerRunNow_Manual(t *testing.T) {
+	runner := &manualRunner{skipReason: "spread too wide"}
+	cs := NewCronScheduler(runner, "0 8 * * *")
+
+	result, err := cs.RunNow()
+	if err != nil {
+		t.Fatalf("RunNow returned error: %v", err)
+	}
+	if !result.Done || result.SkipReason != "spread too wide" || result.Outcome() != "skipped" {
+		t.Errorf("expected the skip reason of the finished run, got %+v", result)
+	}
+	if len(runner.requested) != 1 || runner.runs.Load() != 0 {
+		t.Errorf("expected one manual and no scheduled run, got %d manual and %d scheduled", len(runner.requested), runner.runs.Load())
+	}
+}
+
+func TestCronSchedulerReload(t *testing.T) {
+	old, updated := &countingRunner{}, &countingRunner{}
+	cs := NewCronScheduler(old, "0 8 * * *")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cs.Start(ctx)
+	for !cs.started.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := cs.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cs.Reload(updated, config.Config{CronExpr: "invalid"}); err == nil {
+		t.Fatal("expected an invalid cron expression to be rejected")
+	}
+	if err := cs.Reload(updated, config.Config{CronExpr: "30 9 * * *"}); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+	if next := cs.Status().NextRun; next.Hour() != 9 || next.Minute() != 30 {
+		t.Errorf("expected the next run at 09:30, got %v", next)
+	}
+	if entries := cs.cron.Entries(); len(entries) != 1 {
+		t.Errorf("expected the cron entry to be replaced, got %d entries", len(entries))
+	}
+	if !cs.Status().Paused {
+		t.Error("expected the paused flag to survive the reload")
+	}
+
+	if _, err := cs.RunNow(); err != nil {
+		t.Fatal(err)
+	}
+	for cs.Status().Running {
+		time.Sleep(time.Millisecond)
+	}
+	if old.runs.Load() != 0 || updated.runs.Load() != 1 {
+		t.Errorf("expected the run to use the new runner, got %d old and %d new runs", old.runs.Load(), updated.runs.Load())
+	}
+}
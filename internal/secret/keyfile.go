@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// keyFileWorkFactor is the scrypt work factor (2^18) of new key files, the default of age; lowered in tests.
+var keyFileWorkFactor = 18
+
+// KeyPair is a Kraken API key pair, the content of an encrypted key file.
+type KeyPair struct {
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+}
+
+// EncryptKeys writes a key pair to w as an armored age file encrypted with a passphrase (scrypt),
+// which the age command can decrypt too.
+func EncryptKeys(w io.Writer, keys KeyPair, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+	if keys.PublicKey == "" || keys.PrivateKey == "" {
+		return errors.New("both the public and the private key are required")
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	recipient.SetWorkFactor(keyFileWorkFactor)
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	defer clear(data)
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := ew.Write(data); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// DecryptKeys reads a key pair written by EncryptKeys, armored or binary. Both keys are tracked for
+// redaction from logs.
+func DecryptKeys(r io.Reader, passphrase string) (KeyPair, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return KeyPair{}, err
+	}
+	out, err := age.Decrypt(dearmor(r), identity)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return KeyPair{}, errors.New("wrong passphrase, or not encrypted with a passphrase")
+	}
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	data, err := io.ReadAll(out)
+	defer clear(data)
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	var keys KeyPair
+	if err := json.Unmarshal(data, &keys); err != nil {
+		// The JSON error could quote key material
+		return KeyPair{}, errors.New("not an easy-dca key file")
+	}
+	if keys.PublicKey == "" || keys.PrivateKey == "" {
+		return KeyPair{}, errors.New("the key file lacks the public or the private key")
+	}
+	Track(keys.PublicKey)
+	Track(keys.PrivateKey)
+	return keys, nil
+}
+
+// ReadKeyFile reads and decrypts a key file written by EncryptKeys.
+func ReadKeyFile(path, passphrase string) (KeyPair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return KeyPair{}, err
+	}
+	defer f.Close()
+	keys, err := DecryptKeys(f, passphrase)
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("key file %s: %w", path, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,49 @@
+package secret
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"filippo.io/age/armor"
+)
+
+func TestEncryptKeys(t *testing.T) {
+	keyFileWorkFactor = 10
+	t.Cleanup(func() { keyFileWorkFactor = 18 })
+	keys := KeyPair{PublicKey: "cHVibGljLWtleQ==", PrivateKey: "cHJpdmF0ZS1rZXktZm9yLWtleWZpbGU="}
+
+	var buf bytes.Buffer
+	if err := EncryptKeys(&buf, keys, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), armor.Header) || strings.Contains(buf.String(), keys.PrivateKey) {
+		t.Fatalf("expected an armored age file without the key, got %q", buf.String())
+	}
+
+	got, err := DecryptKeys(bytes.NewReader(buf.Bytes()), "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != keys {
+		t.Errorf("expected %+v, got %+v", keys, got)
+	}
+	if Redact(keys.PrivateKey) != Redacted {
+		t.Error("expected the decrypted private key to be tracked for redaction")
+	}
+
+	_, err = DecryptKeys(bytes.NewReader(buf.Bytes()), "wrong horse battery staple")
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected a wrong passphrase error, got %v", err)
+	}
+}
+
+func TestEncryptKeys_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncryptKeys(&buf, KeyPair{PublicKey: "public", PrivateKey: "private"}, ""); err == nil {
+		t.Error("expected an error for an empty passphrase")
+	}
+	if err := EncryptKeys(&buf, KeyPair{PublicKey: "public"}, "passphrase"); err == nil {
+		t.Error("expected an error for a missing private key")
+	}
+}
@@ -0,0 +1,165 @@
+package secret
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/zalando/go-keyring"
+)
+
+// defaultKeyringService is the keyring service of "keyring:<account>" references.
+const defaultKeyringService = "easy-dca"
+
+// readFile reads a file.
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// readCredential reads a systemd credential from $CREDENTIALS_DIRECTORY.
+func readCredential(ctx context.Context, name string) ([]byte, error) {
+	dir := getenv(ctx, "CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return nil, errors.New("CREDENTIALS_DIRECTORY is not set; pass the credential with LoadCredential= or SetCredential= in the systemd unit")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, errors.New("invalid credential name")
+	}
+	return os.ReadFile(filepath.Join(dir, name))
+}
+
+// ageIdentityFile returns the age identity file: EASY_DCA_AGE_IDENTITY_FILE, else SOPS_AGE_KEY_FILE,
+// else the default identity file of sops.
+func ageIdentityFile(ctx context.Context) (string, error) {
+	for _, env := range []string{"EASY_DCA_AGE_IDENTITY_FILE", "SOPS_AGE_KEY_FILE"} {
+		if path := getenv(ctx, env); path != "" {
+			return path, nil
+		}
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no age identity: set EASY_DCA_AGE_IDENTITY_FILE: %w", err)
+	}
+	return filepath.Join(dir, "sops", "age", "keys.txt"), nil
+}
+
+// decryptAge decrypts an age-encrypted file, binary or armored, with the local age identity.
+func decryptAge(ctx context.Context, path string) ([]byte, error) {
+	identityFile, err := ageIdentityFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idData, err := os.ReadFile(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age identity: %w", err)
+	}
+	defer clear(idData)
+	identities, err := age.ParseIdentities(bytes.NewReader(idData))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity file %s", identityFile)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out, err := age.Decrypt(dearmor(f), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return io.ReadAll(out)
+}
+
+// dearmor returns a reader of the binary age file r, which may be binary or armored.
+func dearmor(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if start, _ := br.Peek(len(armor.Header)); string(start) == armor.Header {
+		return armor.NewReader(br)
+	}
+	return br
+}
+
+// decryptSops decrypts a sops-encrypted file, or the key after "#" in it, with the sops command.
+// Nested keys are separated by dots, e.g. "secrets.yaml#kraken.private_key".
+func decryptSops(ctx context.Context, ref string) ([]byte, error) {
+	path, key, _ := strings.Cut(ref, "#")
+	args := []string{"--decrypt"}
+	if key != "" {
+		var extract strings.Builder
+		for _, part := range strings.Split(key, ".") {
+			fmt.Fprintf(&extract, "[%q]", part)
+		}
+		args = append(args, "--extract", extract.String())
+	}
+	cmd := exec.CommandContext(ctx, "sops", append(args, path)...)
+	cmd.Env = environ(ctx)
+	// sops finds its age identity in SOPS_AGE_KEY_FILE
+	if file := getenv(ctx, "EASY_DCA_AGE_IDENTITY_FILE"); file != "" && getenv(ctx, "SOPS_AGE_KEY_FILE") == "" {
+		cmd.Env = append(cmd.Env, "SOPS_AGE_KEY_FILE="+file)
+	}
+	return output(cmd)
+}
+
+// readKeyring reads an entry of the OS keyring.
+func readKeyring(ctx context.Context, ref string) ([]byte, error) {
+	service, account, ok := strings.Cut(ref, "/")
+	if !ok {
+		service, account = defaultKeyringService, ref
+	}
+	value, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("no keyring entry for service %q and account %q", service, account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+	return []byte(value), nil
+}
+
+// runCommand runs a command and returns the first line of its output, so password managers
+// storing metadata after the password, like pass, work unchanged. The command is split at
+// spaces and run without a shell.
+func runCommand(ctx context.Context, command string) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("no command")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = environ(ctx)
+	out, err := output(cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(out)
+	line, _, _ := bytes.Cut(out, []byte("\n"))
+	return bytes.Clone(line), nil
+}
+
+// output runs a command and returns its standard output. The error contains the first line of
+// the standard error, with tracked secrets redacted, but never the standard output.
+func output(cmd *exec.Cmd) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		clear(out)
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s is not installed", cmd.Path)
+		}
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, Redact(msg))
+		}
+		return nil, err
+	}
+	return out, nil
+}
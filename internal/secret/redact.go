@@ -0,0 +1,105 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in logs.
+const Redacted = "[REDACTED]"
+
+// minTrackLength is the length below which values are not tracked, so short values such as
+// "true" cannot garble unrelated log output. Kraken keys and accepted tokens are much longer.
+const minTrackLength = 8
+
+var (
+	trackedMu sync.RWMutex
+	tracked   []string
+)
+
+// Track registers a secret, so Redact and the handler of NewRedactingHandler remove it from
+// log output. Secrets shorter than 8 characters are ignored.
+func Track(value string) {
+	if len(value) < minTrackLength {
+		return
+	}
+	trackedMu.Lock()
+	defer trackedMu.Unlock()
+	for _, t := range tracked {
+		if t == value {
+			return
+		}
+	}
+	tracked = append(tracked, value)
+}
+
+// Redact returns s with every tracked secret replaced by [REDACTED].
+func Redact(s string) string {
+	trackedMu.RLock()
+	defer trackedMu.RUnlock()
+	for _, t := range tracked {
+		s = strings.ReplaceAll(s, t, Redacted)
+	}
+	return s
+}
+
+// redactingHandler removes tracked secrets from the messages and attributes of log records.
+type redactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler returns a handler that removes tracked secrets from log records before passing them to next.
+func NewRedactingHandler(next slog.Handler) slog.Handler {
+	return redactingHandler{next: next}
+}
+
+func (h redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr removes tracked secrets from an attribute. Values other than strings and groups,
+// such as errors, are redacted in their string form.
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		s := fmt.Sprint(v.Any())
+		if r := Redact(s); r != s {
+			return slog.String(a.Key, r)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,135 @@
+// Package secret resolves secrets such as API keys from pluggable providers and keeps
+// resolved secrets out of logs.
+//
+// A secret reference has the form "<scheme>:<reference>", e.g. "systemd:kraken-private-key".
+// The built-in schemes are:
+//   - file:<path>: the content of a file
+//   - systemd:<name>: a systemd credential in $CREDENTIALS_DIRECTORY (LoadCredential=, SetCredential=)
+//   - age:<path>: an age-encrypted file, decrypted with the identity in EASY_DCA_AGE_IDENTITY_FILE
+//   - sops:<path>[#<key>]: a sops-encrypted file, or one key of it, decrypted by the sops command
+//   - keyring:[<service>/]<account>: an entry of the OS keyring (Secret Service, macOS Keychain, ...)
+//   - exec:<command>: the first line printed by a command, e.g. "exec:pass show kraken/private"
+//
+// EncryptKeys and DecryptKeys write and read passphrase-encrypted API key files.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resolveTimeout limits how long resolving a single secret may take, e.g. a command waiting for input.
+const resolveTimeout = 30 * time.Second
+
+// Provider resolves the reference part of a secret reference, after "<scheme>:".
+type Provider interface {
+	Resolve(ctx context.Context, ref string) ([]byte, error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, ref string) ([]byte, error)
+
+// Resolve calls f.
+func (f ProviderFunc) Resolve(ctx context.Context, ref string) ([]byte, error) {
+	return f(ctx, ref)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"file":    ProviderFunc(readFile),
+		"systemd": ProviderFunc(readCredential),
+		"age":     ProviderFunc(decryptAge),
+		"sops":    ProviderFunc(decryptSops),
+		"keyring": ProviderFunc(readKeyring),
+		"exec":    ProviderFunc(runCommand),
+	}
+)
+
+// Env is an environment that providers read instead of the process environment, e.g. for
+// CREDENTIALS_DIRECTORY and EASY_DCA_AGE_IDENTITY_FILE, and pass to the commands they run.
+type Env interface {
+	Getenv(key string) string
+	Environ() []string
+}
+
+type envKey struct{}
+
+// WithEnv returns a context whose secrets are resolved with env, e.g. to validate a configuration
+// before the .env file it was loaded with is applied to the process environment.
+func WithEnv(ctx context.Context, env Env) context.Context {
+	return context.WithValue(ctx, envKey{}, env)
+}
+
+// getenv returns a variable of the environment of ctx.
+func getenv(ctx context.Context, key string) string {
+	if env, ok := ctx.Value(envKey{}).(Env); ok {
+		return env.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+// environ returns the environment of ctx for the commands providers run.
+func environ(ctx context.Context) []string {
+	if env, ok := ctx.Value(envKey{}).(Env); ok {
+		return env.Environ()
+	}
+	return os.Environ()
+}
+
+// Register adds a provider for a scheme, replacing the provider registered for it before.
+func Register(scheme string, p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = p
+}
+
+// Schemes returns the schemes of the registered providers, sorted.
+func Schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// Scheme returns the scheme of a secret reference, e.g. "systemd" for "systemd:kraken-private-key".
+func Scheme(reference string) string {
+	scheme, _, _ := strings.Cut(reference, ":")
+	return scheme
+}
+
+// Resolve resolves a secret reference and returns the secret without surrounding whitespace.
+// The secret is tracked for redaction from logs. Errors name the reference but never contain the secret.
+func Resolve(ctx context.Context, reference string) (string, error) {
+	scheme, ref, ok := strings.Cut(reference, ":")
+	providersMu.RLock()
+	p, known := providers[scheme]
+	providersMu.RUnlock()
+	if !ok || !known || ref == "" {
+		return "", fmt.Errorf("invalid secret reference %q: expected <scheme>:<reference> with scheme %s",
+			reference, strings.Join(Schemes(), ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	data, err := p.Resolve(ctx, ref)
+	defer clear(data)
+	if err != nil {
+		return "", fmt.Errorf("%s secret %q: %w", scheme, ref, err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s secret %q is empty", scheme, ref)
+	}
+	Track(value)
+	return value, nil
+}
@@ -0,0 +1,250 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/zalando/go-keyring"
+)
+
+const testSecret = "a2V5LWZvci10ZXN0aW5nLW9ubHk="
+
+func writeFile(t *testing.T, name, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func resolve(t *testing.T, reference string) string {
+	t.Helper()
+	value, err := Resolve(context.Background(), reference)
+	if err != nil {
+		t.Fatalf("%s: expected no error, got %v", reference, err)
+	}
+	return value
+}
+
+func TestResolve_File(t *testing.T) {
+	path := writeFile(t, "key", testSecret+"\n", 0o600)
+	if got := resolve(t, "file:"+path); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+}
+
+func TestResolve_Systemd(t *testing.T) {
+	path := writeFile(t, "kraken-private-key", testSecret, 0o400)
+	t.Setenv("CREDENTIALS_DIRECTORY", filepath.Dir(path))
+	if got := resolve(t, "systemd:kraken-private-key"); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+	if _, err := Resolve(context.Background(), "systemd:../kraken-private-key"); err == nil {
+		t.Error("expected an error for a credential outside the credentials directory")
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	if _, err := Resolve(context.Background(), "systemd:kraken-private-key"); err == nil || !strings.Contains(err.Error(), "CREDENTIALS_DIRECTORY") {
+		t.Errorf("expected an error about CREDENTIALS_DIRECTORY, got %v", err)
+	}
+}
+
+func TestResolve_Age(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EASY_DCA_AGE_IDENTITY_FILE", writeFile(t, "keys.txt", identity.String()+"\n", 0o600))
+
+	encrypt := func(armored bool) string {
+		var buf bytes.Buffer
+		var dst io.Writer = &buf
+		var aw io.WriteCloser
+		if armored {
+			aw = armor.NewWriter(&buf)
+			dst = aw
+		}
+		w, err := age.Encrypt(dst, identity.Recipient())
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, testSecret+"\n")
+		w.Close()
+		if aw != nil {
+			aw.Close()
+		}
+		return writeFile(t, "key.age", buf.String(), 0o600)
+	}
+	for _, armored := range []bool{false, true} {
+		if got := resolve(t, "age:"+encrypt(armored)); got != testSecret {
+			t.Errorf("armored %v: expected %q, got %q", armored, testSecret, got)
+		}
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	t.Setenv("EASY_DCA_AGE_IDENTITY_FILE", writeFile(t, "other.txt", other.String(), 0o600))
+	if _, err := Resolve(context.Background(), "age:"+encrypt(false)); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("expected a decryption error with the wrong identity, got %v", err)
+	}
+}
+
+// fakeCommand installs an executable shell script named name on PATH.
+func fakeCommand(t *testing.T, name, script string) {
+	t.Helper()
+	dir := filepath.Dir(writeFile(t, name, "#!/bin/sh\n"+script, 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestResolve_Sops(t *testing.T) {
+	// The stand-in prints its arguments, so the test sees how sops was called
+	fakeCommand(t, "sops", `echo "$@"`)
+	if got := resolve(t, "sops:secrets.yaml"); got != "--decrypt secrets.yaml" {
+		t.Errorf("unexpected sops call %q", got)
+	}
+	if got := resolve(t, "sops:secrets.yaml#kraken.private_key"); got != `--decrypt --extract ["kraken"]["private_key"] secrets.yaml` {
+		t.Errorf("unexpected sops call %q", got)
+	}
+
+	fakeCommand(t, "sops", "echo 'Failed to get the data key' >&2; exit 128")
+	if _, err := Resolve(context.Background(), "sops:secrets.yaml"); err == nil || !strings.Contains(err.Error(), "Failed to get the data key") {
+		t.Errorf("expected the sops error, got %v", err)
+	}
+}
+
+func TestResolve_Keyring(t *testing.T) {
+	keyring.MockInit()
+	if err := keyring.Set("easy-dca", "kraken-private-key", testSecret); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Set("kraken", "main", testSecret); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve(t, "keyring:kraken-private-key"); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+	if got := resolve(t, "keyring:kraken/main"); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+	if _, err := Resolve(context.Background(), "keyring:missing"); err == nil || !strings.Contains(err.Error(), "no keyring entry") {
+		t.Errorf("expected a missing entry error, got %v", err)
+	}
+}
+
+func TestResolve_Exec(t *testing.T) {
+	// Like pass, the stand-in prints metadata after the password
+	fakeCommand(t, "pass", `echo "`+testSecret+`"; echo "url: kraken.com"`)
+	if got := resolve(t, "exec:pass show kraken/private"); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+	if _, err := Resolve(context.Background(), "exec:no-such-command-easy-dca"); err == nil || !strings.Contains(err.Error(), "is not installed") {
+		t.Errorf("expected a not installed error, got %v", err)
+	}
+	if _, err := Resolve(context.Background(), "exec: "); err == nil {
+		t.Error("expected an error for an empty command")
+	}
+}
+
+// mapEnv is the process environment with the given variables set.
+type mapEnv map[string]string
+
+func (e mapEnv) Getenv(key string) string {
+	if value, ok := e[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+func (e mapEnv) Environ() []string {
+	environ := os.Environ()
+	for key, value := range e {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
+
+func TestResolve_WithEnv(t *testing.T) {
+	path := writeFile(t, "kraken-private-key", testSecret, 0o400)
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	ctx := WithEnv(context.Background(), mapEnv{"CREDENTIALS_DIRECTORY": filepath.Dir(path), "KRAKEN_ENTRY": "kraken/private"})
+	if got, err := Resolve(ctx, "systemd:kraken-private-key"); err != nil || got != testSecret {
+		t.Errorf("expected the credential of the given environment, got %q (error %v)", got, err)
+	}
+
+	fakeCommand(t, "pass", `echo "$KRAKEN_ENTRY"`)
+	if got, err := Resolve(ctx, "exec:pass show"); err != nil || got != "kraken/private" {
+		t.Errorf("expected the command to run with the given environment, got %q (error %v)", got, err)
+	}
+	if os.Getenv("KRAKEN_ENTRY") != "" {
+		t.Error("expected the process environment to be left unchanged")
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	for _, ref := range []string{"", "kraken-private-key", "vault:kraken", "file:"} {
+		if _, err := Resolve(context.Background(), ref); err == nil || !strings.Contains(err.Error(), "invalid secret reference") {
+			t.Errorf("%q: expected an invalid reference error, got %v", ref, err)
+		}
+	}
+
+	// A command printing the secret and failing must not leak it into the error
+	fakeCommand(t, "leaky", `echo "`+testSecret+`"; echo "`+testSecret+`" >&2; exit 1`)
+	Track(testSecret)
+	_, err := Resolve(context.Background(), "exec:leaky")
+	if err == nil || strings.Contains(err.Error(), testSecret) {
+		t.Errorf("expected an error without the secret, got %v", err)
+	}
+
+	if _, err := Resolve(context.Background(), "file:"+writeFile(t, "empty", "\n", 0o600)); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected an empty secret error, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test", ProviderFunc(func(ctx context.Context, ref string) ([]byte, error) {
+		if ref != "kraken" {
+			return nil, errors.New("unknown")
+		}
+		return []byte(testSecret), nil
+	}))
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "test")
+		providersMu.Unlock()
+	})
+	if got := resolve(t, "test:kraken"); got != testSecret {
+		t.Errorf("expected %q, got %q", testSecret, got)
+	}
+}
+
+func TestRedactingHandler(t *testing.T) {
+	const key = "cHJpdmF0ZS1rZXktZm9yLXJlZGFjdGlvbg=="
+	Track(key)
+	Track("short") // Too short to track
+
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil))).With("key", key)
+	logger.WithGroup("kraken").Info("signing with "+key,
+		"err", errors.New("bad key "+key),
+		slog.Group("keys", "private", key),
+		"word", "short")
+
+	line := buf.String()
+	if strings.Contains(line, key) {
+		t.Errorf("expected the key to be redacted, got %q", line)
+	}
+	for _, want := range []string{`msg="signing with [REDACTED]"`, "key=[REDACTED]", `kraken.err="bad key [REDACTED]"`, "kraken.keys.private=[REDACTED]", "kraken.word=short"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Package server provides the optional built-in HTTP server (metrics, health and status endpoints).
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout is how long in-flight requests may take to finish when the server stops.
+const shutdownTimeout = 5 * time.Second
+
+// Server is an HTTP server that runs until its context is cancelled.
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+}
+
+// New creates a server listening on addr (e.g. ":9090" or "127.0.0.1:9090").
+func New(addr string) *Server {
+	return &Server{addr: addr, mux: http.NewServeMux()}
+}
+
+// Handle registers a handler for a pattern, see http.ServeMux.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Listen binds the listen address, so that address errors are reported before the server runs.
+func (s *Server) Listen() (net.Listener, error) {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	return ln, nil
+}
+
+// Serve serves requests on ln until ctx is cancelled, then shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+	slog.Info("HTTP server listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServerServesUntilCancelled(t *testing.T) {
+	s := New("127.0.0.1:0")
+	s.Handle("/ping", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "pong")
+	}))
+	ln, err := s.Listen()
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("expected pong, got %q", body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after cancellation")
+	}
+}
+
+func TestServerListenError(t *testing.T) {
+	if _, err := New("256.0.0.1:99999").Listen(); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
@@ -0,0 +1,127 @@
+// Package state persists DCA state between runs, such as fiat carried forward from skipped buys
+// and whether the schedule is paused.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/lock"
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+// State is the persisted DCA state.
+type State struct {
+	CarriedFiat order.Decimal `json:"carried_fiat"` // Fiat from skipped buys to add to the next buy
+	SkippedBuys int           `json:"skipped_buys"` // Number of consecutive skipped buys
+	Paused      bool          `json:"paused"`       // Scheduled runs are paused (cron mode)
+	SkipNext    bool          `json:"skip_next"`    // The next scheduled run is skipped (cron mode)
+	UpdatedAt   time.Time     `json:"updated_at"`   // Time of the last update
+
+	PendingFallback *PendingFallback `json:"pending_fallback,omitempty"` // Market fallback still to be completed
+}
+
+// PendingFallback is a limit-market order whose unfilled volume is still to be bought at market.
+// It is recorded when the order is placed, so a restart during the wait does not drop the fallback.
+type PendingFallback struct {
+	Txid    string    `json:"txid"`      // Kraken transaction ID of the limit order
+	ClOrdID string    `json:"cl_ord_id"` // Client order id of the limit order, reused by the market order
+	Due     time.Time `json:"due"`       // When the unfilled volume is bought at market
+}
+
+// Store persists State in a JSON file.
+// The file is locked while it is read or written, so processes sharing it see consistent state.
+type Store struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a new file-backed state store.
+func NewStore(path string) *Store {
+	return &Store{Path: path}
+}
+
+// Load returns the stored state. A missing or empty file yields the zero State.
+func (s *Store) Load() (State, error) {
+	var st State
+	err := s.withFile(func(f *os.File) error {
+		var err error
+		st, err = s.read(f)
+		return err
+	})
+	return st, err
+}
+
+// Update applies fn to the stored state and writes the result back.
+// Nothing is written if fn returns an error.
+func (s *Store) Update(fn func(*State) error) error {
+	return s.withFile(func(f *os.File) error {
+		st, err := s.read(f)
+		if err != nil {
+			return err
+		}
+		if err := fn(&st); err != nil {
+			return err
+		}
+		st.UpdatedAt = time.Now().UTC()
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode state: %w", err)
+		}
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("failed to write state file: %w", err)
+		}
+		if _, err := f.WriteAt(append(data, '\n'), 0); err != nil {
+			return fmt.Errorf("failed to write state file: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("failed to write state file: %w", err)
+		}
+		return nil
+	})
+}
+
+// withFile opens and locks the state file for the duration of fn.
+func (s *Store) withFile(fn func(f *os.File) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The default state file is in a state directory that may not exist yet
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state file directory: %w", err)
+	}
+	f, err := os.OpenFile(s.Path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer f.Close()
+
+	unlock, err := lock.Exclusive(f)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn(f)
+}
+
+// read decodes the state from f, which must be positioned at the start of the file.
+func (s *Store) read(f *os.File) (State, error) {
+	var st State
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return st, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if len(data) == 0 {
+		return st, nil
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("invalid state file %s: %w", s.Path, err)
+	}
+	return st, nil
+}
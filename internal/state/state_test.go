@@ -0,0 +1,83 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mayrf/easy-dca/internal/order"
+)
+
+func TestStoreLoadMissingFile(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !st.CarriedFiat.IsZero() || st.SkippedBuys != 0 {
+		t.Errorf("expected zero state, got %+v", st)
+	}
+}
+
+func TestStoreUpdatePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewStore(path)
+	for i := 0; i < 2; i++ {
+		err := s.Update(func(st *State) error {
+			st.CarriedFiat = st.CarriedFiat.Add(order.MustParseDecimal("12.5"))
+			st.SkippedBuys++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+	}
+
+	st, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if st.CarriedFiat != order.MustParseDecimal("25") || st.SkippedBuys != 2 {
+		t.Errorf("expected 25 carried over 2 skipped buys, got %s over %d", st.CarriedFiat, st.SkippedBuys)
+	}
+	if st.UpdatedAt.IsZero() {
+		t.Error("expected UpdatedAt to be set")
+	}
+}
+
+func TestStoreCreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "easy-dca", "state.json")
+	if err := NewStore(path).Update(func(st *State) error { st.Paused = true; return nil }); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if st, err := NewStore(path).Load(); err != nil || !st.Paused {
+		t.Errorf("expected paused state, got %+v (error %v)", st, err)
+	}
+}
+
+func TestStoreUpdateErrorKeepsState(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	if err := s.Update(func(st *State) error { st.SkippedBuys = 1; return nil }); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	boom := errors.New("boom")
+	err := s.Update(func(st *State) error { st.SkippedBuys = 5; return boom })
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	st, _ := s.Load()
+	if st.SkippedBuys != 1 {
+		t.Errorf("expected state to be unchanged, got %d skipped buys", st.SkippedBuys)
+	}
+}
+
+func TestStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path).Load(); err == nil {
+		t.Error("expected an error for an invalid state file")
+	}
+}
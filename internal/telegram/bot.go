@@ -0,0 +1,270 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/control"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+// pollTimeout is how long a getUpdates request waits for a message.
+const pollTimeout = 30 * time.Second
+
+// retryDelay is the pause after a failed getUpdates request; shortened in tests.
+var retryDelay = 5 * time.Second
+
+// historyLength is the number of runs the /history command lists.
+const historyLength = 5
+
+// helpText lists the commands of the bot.
+const helpText = `/status - Schedule and last run
+/history - Latest runs
+/pause - Pause scheduled runs
+/resume - Resume scheduled runs
+/runnow - Run now`
+
+// Options configures the bot.
+type Options struct {
+	Client    *Client
+	ChatIDs   []int64              // Chats allowed to send commands; messages from other chats are ignored
+	Scheduler control.Scheduler    // Scheduler to control
+	Config    func() config.Config // Current configuration of the scheduler
+	History   *history.Store       // Records actions and provides the runs (nil if disabled)
+}
+
+// Bot answers commands sent to the Telegram bot from whitelisted chats.
+type Bot struct {
+	opts Options
+}
+
+// NewBot returns a bot controlling the scheduler of opts.
+func NewBot(opts Options) *Bot {
+	return &Bot{opts: opts}
+}
+
+// Run long-polls for commands until ctx is canceled.
+func (b *Bot) Run(ctx context.Context) {
+	slog.Info("Listening for Telegram commands", "chats", len(b.opts.ChatIDs))
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.opts.Client.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Failed to poll Telegram for commands, retrying", "error", err, "retry_in", retryDelay)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = max(offset, u.UpdateID+1)
+			if u.Message != nil {
+				b.handle(ctx, u.Message)
+			}
+		}
+	}
+}
+
+// handle answers a message with the reply of its command.
+func (b *Bot) handle(ctx context.Context, msg *Message) {
+	if !slices.Contains(b.opts.ChatIDs, msg.Chat.ID) {
+		// No reply, so the bot does not reveal what it controls
+		slog.Warn("Ignored Telegram message from a chat that is not allowed", "chat_id", msg.Chat.ID)
+		return
+	}
+	command, _, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+	command, _, _ = strings.Cut(strings.ToLower(command), "@") // "/status@my_bot" in groups
+
+	var reply string
+	switch command {
+	case "/status":
+		reply = b.status()
+	case "/history":
+		reply = b.history()
+	case "/pause":
+		reply = b.action(msg.Chat.ID, "pause", b.opts.Scheduler.Pause)
+	case "/resume":
+		reply = b.action(msg.Chat.ID, "resume", b.opts.Scheduler.Resume)
+	case "/runnow":
+		reply = b.runNow(msg.Chat.ID)
+	case "/start", "/help":
+		reply = bold("Commands") + "\n" + EscapeMarkdown(helpText)
+	default:
+		reply = EscapeMarkdown("Unknown command. Commands:\n" + helpText)
+	}
+	if err := b.opts.Client.SendMessage(ctx, msg.Chat.ID, reply); err != nil {
+		slog.Error("Failed to answer Telegram command", "command", command, "error", err)
+	}
+}
+
+// status describes the schedule and the last run.
+func (b *Bot) status() string {
+	cfg := b.opts.Config()
+	st := b.opts.Scheduler.Status()
+	mode := "live"
+	if cfg.DryRun {
+		mode = "dry run"
+	}
+	lines := []string{"Pair: " + cfg.Pair.String(), "Mode: " + mode, "Schedule: " + cfg.CronExpr}
+	switch {
+	case st.Running:
+		lines = append(lines, "State: running")
+	case st.Paused:
+		lines = append(lines, "State: paused")
+	default:
+		lines = append(lines, "State: active")
+	}
+	if !st.NextRun.IsZero() {
+		next := "Next run: " + st.NextRun.Format(time.DateTime)
+		if st.SkipNext {
+			next += " (skipped)"
+		}
+		lines = append(lines, next)
+	}
+	if b.opts.History != nil {
+		records, err := b.opts.History.All()
+		if err != nil {
+			slog.Error("Failed to read run history", "error", err)
+			lines = append(lines, "Last run: unknown (failed to read the run history)")
+		}
+		for i := len(records) - 1; i >= 0; i-- {
+			if records[i].Action == "" {
+				lines = append(lines, "Last run: "+formatRecord(records[i]))
+				break
+			}
+		}
+	}
+	return bold(title("Status", cfg.Plan)) + "\n" + EscapeMarkdown(strings.Join(lines, "\n"))
+}
+
+// history lists the latest runs and actions.
+func (b *Bot) history() string {
+	cfg := b.opts.Config()
+	if b.opts.History == nil {
+		return EscapeMarkdown("The run history is disabled (EASY_DCA_HISTORY_FILE=off).")
+	}
+	records, err := b.opts.History.Recent(historyLength)
+	if err != nil {
+		slog.Error("Failed to read run history", "error", err)
+		return EscapeMarkdown("Failed to read the run history.")
+	}
+	if len(records) == 0 {
+		return EscapeMarkdown("No runs yet.")
+	}
+	lines := make([]string, len(records))
+	for i, rec := range records {
+		lines[i] = "• " + formatRecord(rec)
+	}
+	return bold(title("History", cfg.Plan)) + "\n" + EscapeMarkdown(strings.Join(lines, "\n"))
+}
+
+// action performs an action, records it in the history and describes the outcome.
+func (b *Bot) action(chatID int64, name string, fn func() error) string {
+	if reply, ok := b.performed(chatID, name, fn()); !ok {
+		return reply
+	}
+	if name == "pause" {
+		return EscapeMarkdown("Scheduled runs paused. Send /resume to resume them.")
+	}
+	return EscapeMarkdown("Scheduled runs resumed.")
+}
+
+// runNow starts a run, records it in the history and describes its outcome, including why it skipped its buy.
+func (b *Bot) runNow(chatID int64) string {
+	result, err := b.opts.Scheduler.RunNow()
+	if reply, ok := b.performed(chatID, "run", err); !ok {
+		return reply
+	}
+	switch {
+	case !result.Done:
+		return EscapeMarkdown("Run started; you will be notified of the result.")
+	case result.Err != nil:
+		return EscapeMarkdown("Run failed: " + result.Err.Error())
+	case result.SkipReason != "":
+		return EscapeMarkdown("Run skipped: " + result.SkipReason)
+	default:
+		return EscapeMarkdown("Run finished; you will be notified of the order.")
+	}
+}
+
+// performed records an action in the history. If the action failed, it returns the reply describing the error and false.
+func (b *Bot) performed(chatID int64, name string, err error) (string, bool) {
+	b.record(chatID, name, err)
+	if errors.Is(err, scheduler.ErrRunInProgress) {
+		return EscapeMarkdown("A run is already in progress."), false
+	}
+	if err != nil {
+		slog.Error("Control action failed", "action", name, "error", err)
+		return EscapeMarkdown("Failed to " + name + ": " + err.Error()), false
+	}
+	slog.Info("Control action performed", "action", name, "telegram_chat_id", chatID)
+	return "", true
+}
+
+// record appends an action to the history, if configured.
+func (b *Bot) record(chatID int64, name string, err error) {
+	if b.opts.History == nil {
+		return
+	}
+	cfg := b.opts.Config()
+	now := time.Now().UTC()
+	rec := history.Record{
+		Action:   name,
+		Plan:     cfg.Plan,
+		Started:  now,
+		Finished: now,
+		Pair:     cfg.Pair.String(),
+		DryRun:   cfg.DryRun,
+		Outcome:  history.OutcomeSuccess,
+		Message:  fmt.Sprintf("requested via Telegram from chat %d", chatID),
+	}
+	if err != nil {
+		rec.Outcome = history.OutcomeError
+		rec.Message += ": " + err.Error()
+	}
+	if err := b.opts.History.Append(rec); err != nil {
+		slog.Error("Failed to record control action", "action", name, "error", err)
+	}
+}
+
+// formatRecord describes a run or action in one line.
+func formatRecord(rec history.Record) string {
+	s := rec.Started.Local().Format("2006-01-02 15:04") + " "
+	if rec.Action != "" {
+		s += rec.Action + " "
+	}
+	s += rec.Outcome
+	if rec.Order != nil {
+		s += fmt.Sprintf(": %s %s at %s", rec.Order.Type, rec.Order.Volume, rec.Order.Price)
+		if rec.DryRun {
+			s += " (dry run)"
+		}
+	} else if rec.Message != "" {
+		s += ": " + rec.Message
+	}
+	return s
+}
+
+// title returns a message title, with the plan if the configuration has plans.
+func title(s, plan string) string {
+	if plan != "" {
+		return s + " (" + plan + ")"
+	}
+	return s
+}
+
+// bold returns text in bold, escaped for MarkdownV2.
+func bold(text string) string {
+	return "*" + EscapeMarkdown(text) + "*"
+}
@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mayrf/easy-dca/internal/config"
+	"github.com/mayrf/easy-dca/internal/history"
+	"github.com/mayrf/easy-dca/internal/scheduler"
+)
+
+const testToken = "123456:test-bot-token"
+
+// sentMessage is a message sent through the fake Bot API.
+type sentMessage struct {
+	ChatID    int64  `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// fakeBotAPI is a local stand-in for the Telegram Bot API server.
+type fakeBotAPI struct {
+	*httptest.Server
+	mu         sync.Mutex
+	updates    []Update
+	sent       []sentMessage
+	lastOffset int64
+}
+
+func newFakeBotAPI(t *testing.T, updates ...Update) *fakeBotAPI {
+	f := &fakeBotAPI{updates: updates}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /bot"+testToken+"/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		var msg sentMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		f.mu.Lock()
+		f.sent = append(f.sent, msg)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"message_id": 1}})
+	})
+	mux.HandleFunc("POST /bot"+testToken+"/getUpdates", func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			Offset int64 `json:"offset"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		f.mu.Lock()
+		f.lastOffset = params.Offset
+		var pending []Update
+		for _, u := range f.updates {
+			if u.UpdateID >= params.Offset {
+				pending = append(pending, u)
+			}
+		}
+		f.mu.Unlock()
+		if len(pending) == 0 {
+			// Long poll without messages
+			select {
+			case <-r.Context().Done():
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": pending})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 401, "description": "Unauthorized"})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeBotAPI) messages() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.sent...)
+}
+
+// fakeScheduler records the actions of the bot.
+type fakeScheduler struct {
+	mu     sync.Mutex
+	paused bool
+	runs   int
+}
+
+func (s *fakeScheduler) RunNow() (scheduler.RunResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs++
+	return scheduler.RunResult{Done: true, SkipReason: "spread too wide"}, nil
+}
+
+func (s *fakeScheduler) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+func (s *fakeScheduler) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+func (s *fakeScheduler) SkipNext() error { return nil }
+
+func (s *fakeScheduler) Status() scheduler.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return scheduler.Status{Paused: s.paused, NextRun: time.Date(2025, 1, 2, 8, 0, 0, 0, time.Local)}
+}
+
+func message(id, chatID int64, text string) Update {
+	return Update{UpdateID: id, Message: &Message{MessageID: id, Chat: Chat{ID: chatID}, Text: text}}
+}
+
+func TestBot_Commands(t *testing.T) {
+	api := newFakeBotAPI(t,
+		message(1, 42, "/pause"),
+		message(2, 99, "/runnow"), // Not an allowed chat
+		message(3, 42, "/status@easy_dca_bot"),
+		message(4, 42, "/history"),
+		message(5, 42, "/runnow"),
+		message(6, 42, "hello"),
+	)
+	pair, _ := config.NewTradingPair("BTC/EUR")
+	cfg := config.Config{Pair: pair, DryRun: true, CronExpr: "0 8 * * *"}
+	sched := &fakeScheduler{}
+	bot := NewBot(Options{
+		Client:    &Client{Token: testToken, APIURL: api.URL},
+		ChatIDs:   []int64{42},
+		Scheduler: sched,
+		Config:    func() config.Config { return cfg },
+		History:   history.NewStore(filepath.Join(t.TempDir(), "history.jsonl")),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bot.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(api.messages()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	sent := api.messages()
+	if len(sent) != 5 {
+		t.Fatalf("expected 5 replies, got %d: %+v", len(sent), sent)
+	}
+	for _, msg := range sent {
+		if msg.ChatID != 42 || msg.ParseMode != "MarkdownV2" {
+			t.Errorf("expected MarkdownV2 replies to chat 42 only, got %+v", msg)
+		}
+	}
+	if !sched.paused || sched.runs != 1 {
+		t.Errorf("expected a pause and one run, got paused %v and %d runs", sched.paused, sched.runs)
+	}
+	if !strings.Contains(sent[1].Text, "State: paused") || !strings.Contains(sent[1].Text, "Mode: dry run") || !strings.Contains(sent[1].Text, "Next run: 2025\\-01\\-02 08:00:00") {
+		t.Errorf("unexpected status reply %q", sent[1].Text)
+	}
+	if !strings.Contains(sent[2].Text, "pause success: requested via Telegram from chat 42") {
+		t.Errorf("expected the pause in the history reply, got %q", sent[2].Text)
+	}
+	if !strings.Contains(sent[3].Text, "Run skipped: spread too wide") {
+		t.Errorf("expected the skip reason in the run reply, got %q", sent[3].Text)
+	}
+	if !strings.Contains(sent[4].Text, "Unknown command") {
+		t.Errorf("expected the help for an unknown command, got %q", sent[4].Text)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.lastOffset != 7 {
+		t.Errorf("expected the handled updates to be confirmed with offset 7, got %d", api.lastOffset)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	api := newFakeBotAPI(t)
+	client := &Client{Token: "wrong-token", APIURL: api.URL}
+	err := client.SendMessage(context.Background(), 42, "hello")
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+
+	api.Close()
+	err = (&Client{Token: testToken, APIURL: api.URL}).SendMessage(context.Background(), 42, "hello")
+	if err == nil || strings.Contains(err.Error(), testToken) {
+		t.Errorf("expected a connection error without the token, got %v", err)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	got := EscapeMarkdown("Bought 0.001 BTC (dry-run) at 50,000 EUR! [limit_order]")
+	want := `Bought 0\.001 BTC \(dry\-run\) at 50,000 EUR\! \[limit\_order\]`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package telegram provides a minimal Telegram Bot API client and a bot that controls a running
+// cron scheduler through commands from whitelisted chats.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the URL of the official Bot API server.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// requestTimeout limits Bot API requests, in addition to the long-poll timeout of getUpdates.
+const requestTimeout = 30 * time.Second
+
+// Client calls the Telegram Bot API.
+type Client struct {
+	Token  string       // Bot token from @BotFather
+	APIURL string       // Bot API server (default DefaultAPIURL), e.g. a local Bot API server
+	HTTP   *http.Client // HTTP client (default http.DefaultClient); requests time out on their own
+}
+
+// Update is an incoming update of getUpdates. Only messages are requested.
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message is a Telegram message.
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// Chat is the chat a message was sent in.
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// response is the envelope of every Bot API response.
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// SendMessage sends a message formatted with MarkdownV2 to a chat. Text from outside the bot
+// must be escaped with EscapeMarkdown.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return c.call(ctx, "sendMessage", map[string]any{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "MarkdownV2",
+	}, nil, requestTimeout)
+}
+
+// GetUpdates long-polls for messages after offset, waiting up to timeout for one to arrive.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates, timeout+requestTimeout)
+	return updates, err
+}
+
+// call calls a Bot API method with JSON parameters and decodes its result into result, if not nil.
+func (c *Client) call(ctx context.Context, method string, params map[string]any, result any, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(apiURL, "/"), c.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		// The URL contains the token
+		return fmt.Errorf("telegram %s: invalid API URL", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL contains the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram %s: %s: invalid response", method, resp.Status)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram %s: %d %s", method, r.ErrorCode, r.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fmt.Errorf("telegram %s: invalid result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// markdownEscaper escapes the characters MarkdownV2 reserves.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// EscapeMarkdown escapes text for MarkdownV2 messages, so it is shown as written.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}